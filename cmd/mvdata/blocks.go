@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/df-mc/worldupgrader/blockupgrader"
+	"github.com/flonja/multiversion/internal"
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+	"github.com/segmentio/fasthash/fnv1"
+	"os"
+	"sort"
+)
+
+// readBlockStates reads the block states from the canonical block state dump at the path passed. The dump holds
+// concatenated NBT compounds, written with either the network or the little endian NBT encoding.
+func readBlockStates(path string) ([]blockupgrader.BlockState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read block states: %w", err)
+	}
+	states, err := decodeBlockStates(data, nbt.NetworkLittleEndian)
+	if err != nil {
+		// Dumps written to disk by the game itself use the little endian encoding.
+		var leErr error
+		if states, leErr = decodeBlockStates(data, nbt.LittleEndian); leErr != nil {
+			return nil, fmt.Errorf("decode block states: %w", err)
+		}
+	}
+	return states, nil
+}
+
+// decodeBlockStates decodes the concatenated block states held by the data passed using the encoding passed.
+func decodeBlockStates(data []byte, encoding nbt.Encoding) (states []blockupgrader.BlockState, err error) {
+	defer func() {
+		// Property values of unknown types make hashing the states panic further on, so they're reported here.
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	buf := bytes.NewBuffer(data)
+	for buf.Len() > 0 {
+		// A new decoder is used for every state, as a decoder limits the amount of bytes it reads in total.
+		var s blockupgrader.BlockState
+		if err := nbt.NewDecoderWithEncoding(buf, encoding).Decode(&s); err != nil {
+			return nil, fmt.Errorf("block state %v: %w", len(states), err)
+		}
+		if s.Properties == nil {
+			s.Properties = map[string]any{}
+		}
+		internal.HashState(s)
+		states = append(states, s)
+	}
+	return states, nil
+}
+
+// sortBlockStates sorts the block states passed by the FNV-1 hash of their name, like the game does to assign the
+// runtime IDs of block states. The states of a single block keep their order.
+func sortBlockStates(states []blockupgrader.BlockState) {
+	sort.SliceStable(states, func(i, j int) bool {
+		a, b := states[i].Name, states[j].Name
+		return a != b && fnv1.HashString64(a) < fnv1.HashString64(b)
+	})
+}
+
+// checkBlockStates checks the block states passed, returning a description of every problem found.
+func checkBlockStates(states []blockupgrader.BlockState) []string {
+	var problems []string
+	air := false
+	seen := make(map[internal.StateHash]int, len(states))
+	for rid, s := range states {
+		if s.Name == "" {
+			problems = append(problems, fmt.Sprintf("block state %v has no name", rid))
+			continue
+		}
+		if s.Name == "minecraft:air" {
+			air = true
+		}
+		hash := internal.HashState(s)
+		if other, ok := seen[hash]; ok {
+			problems = append(problems, fmt.Sprintf("block state %v duplicates block state %v: %v %v", rid, other, s.Name, s.Properties))
+			continue
+		}
+		seen[hash] = rid
+	}
+	if !air {
+		problems = append(problems, "block states have no minecraft:air")
+	}
+	return problems
+}
+
+// encodeBlockStates encodes the block states passed in the format read by mapping.NewBlockMapping.
+func encodeBlockStates(states []blockupgrader.BlockState) ([]byte, error) {
+	var b []byte
+	for _, s := range states {
+		encoded, err := encodeCompound(map[string]any{
+			"name":    s.Name,
+			"states":  s.Properties,
+			"version": s.Version,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("block state %v: %w", s.Name, err)
+		}
+		b = append(b, encoded...)
+	}
+	return b, nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/flonja/multiversion/mapping"
+	"github.com/flonja/multiversion/protocols/latest"
+	v486 "github.com/flonja/multiversion/protocols/v486"
+	v582 "github.com/flonja/multiversion/protocols/v582"
+	v589 "github.com/flonja/multiversion/protocols/v589"
+	v594 "github.com/flonja/multiversion/protocols/v594"
+	v618 "github.com/flonja/multiversion/protocols/v618"
+	v622 "github.com/flonja/multiversion/protocols/v622"
+	v630 "github.com/flonja/multiversion/protocols/v630"
+	v649 "github.com/flonja/multiversion/protocols/v649"
+	v662 "github.com/flonja/multiversion/protocols/v662"
+	"io"
+	"os"
+	"strings"
+)
+
+// version is a protocol version of which the coverage may be reported.
+type version struct {
+	name           string
+	blocks         func() *mapping.DefaultBlockMapping
+	items          func() *mapping.DefaultItemMapping
+	blockFallbacks func() *mapping.BlockFallbacks
+	itemFallbacks  func() *mapping.ItemFallbacks
+}
+
+// versions holds all protocol versions with mappings of their own, from the newest to the oldest.
+var versions = []version{
+	{name: "v662", blocks: v662.NewBlockMapping, items: v662.NewItemMapping, blockFallbacks: v662.BlockFallbacks, itemFallbacks: v662.ItemFallbacks},
+	{name: "v649", blocks: v649.NewBlockMapping, items: v649.NewItemMapping, blockFallbacks: v649.BlockFallbacks, itemFallbacks: v649.ItemFallbacks},
+	{name: "v630", blocks: v630.NewBlockMapping, items: v630.NewItemMapping, blockFallbacks: v630.BlockFallbacks, itemFallbacks: v630.ItemFallbacks},
+	{name: "v622", blocks: v622.NewBlockMapping, items: v622.NewItemMapping, blockFallbacks: v622.BlockFallbacks, itemFallbacks: v622.ItemFallbacks},
+	{name: "v618", blocks: v618.NewBlockMapping, items: v618.NewItemMapping, blockFallbacks: v618.BlockFallbacks, itemFallbacks: v618.ItemFallbacks},
+	{name: "v594", blocks: v594.NewBlockMapping, items: v594.NewItemMapping, blockFallbacks: v594.BlockFallbacks, itemFallbacks: v594.ItemFallbacks},
+	{name: "v589", blocks: v589.NewBlockMapping, items: v589.NewItemMapping, blockFallbacks: v589.BlockFallbacks, itemFallbacks: v589.ItemFallbacks},
+	{name: "v582", blocks: v582.NewBlockMapping, items: v582.NewItemMapping, blockFallbacks: v582.BlockFallbacks, itemFallbacks: v582.ItemFallbacks},
+	{name: "v486", blocks: v486.NewBlockMapping, items: v486.NewItemMapping, blockFallbacks: v486.BlockFallbacks, itemFallbacks: v486.ItemFallbacks},
+}
+
+// versionCoverage is the coverage of a single protocol version.
+type versionCoverage struct {
+	Version string `json:"version"`
+	mapping.Coverage
+}
+
+// runCoverage runs the coverage command with the arguments passed.
+func runCoverage(args []string) error {
+	fs := flag.NewFlagSet("coverage", flag.ExitOnError)
+	format := fs.String("format", "markdown", "output format: json or markdown")
+	out := fs.String("out", "", "file to write the report to, instead of standard output")
+	only := fs.String("versions", "", "comma separated protocol versions to report, such as v662,v649; all versions if empty")
+	_ = fs.Parse(args)
+
+	var write func(io.Writer, []versionCoverage) error
+	switch *format {
+	case "json":
+		write = writeCoverageJSON
+	case "markdown", "md":
+		write = writeCoverageMarkdown
+	default:
+		return fmt.Errorf("unknown format %v", *format)
+	}
+
+	selected := versions
+	if *only != "" {
+		selected = nil
+		for _, name := range strings.Split(*only, ",") {
+			i := indexVersion(strings.TrimSpace(name))
+			if i == -1 {
+				return fmt.Errorf("unknown protocol version %v", name)
+			}
+			selected = append(selected, versions[i])
+		}
+	}
+
+	latestBlocks, latestItems := latest.NewBlockMapping(), latest.NewItemMapping()
+	report := make([]versionCoverage, 0, len(selected))
+	for _, v := range selected {
+		report = append(report, versionCoverage{
+			Version:  v.name,
+			Coverage: mapping.NewCoverage(latestBlocks, v.blocks(), v.blockFallbacks(), latestItems, v.items(), v.itemFallbacks()),
+		})
+	}
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return write(w, report)
+}
+
+// indexVersion returns the index of the protocol version with the name passed in versions, or -1 if there is none.
+func indexVersion(name string) int {
+	for i, v := range versions {
+		if v.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// writeCoverageJSON writes the report passed as JSON.
+func writeCoverageJSON(w io.Writer, report []versionCoverage) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(report)
+}
+
+// writeCoverageMarkdown writes the report passed as Markdown, with a section for every protocol version.
+func writeCoverageMarkdown(w io.Writer, report []versionCoverage) error {
+	var b strings.Builder
+	b.WriteString("# Coverage\n\n| Version | Block states | Items |\n| --- | --- | --- |\n")
+	for _, v := range report {
+		fmt.Fprintf(&b, "| %v | %v | %v |\n", v.Version, countStates(v.Blocks), len(flatten(v.Items)))
+	}
+	for _, v := range report {
+		fmt.Fprintf(&b, "\n## %v\n", v.Version)
+		writeNamespacesMarkdown(&b, "Blocks", v.Blocks)
+		writeNamespacesMarkdown(&b, "Items", v.Items)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeNamespacesMarkdown writes the entries of the namespaces passed as a list under a heading.
+func writeNamespacesMarkdown(b *strings.Builder, heading string, namespaces []mapping.NamespaceCoverage) {
+	fmt.Fprintf(b, "\n### %v\n", heading)
+	if len(namespaces) == 0 {
+		b.WriteString("\nEvery entry has a target.\n")
+		return
+	}
+	for _, ns := range namespaces {
+		fmt.Fprintf(b, "\n#### %v\n\n", ns.Namespace)
+		for _, e := range ns.Entries {
+			switch {
+			case len(e.States) == 0:
+				fmt.Fprintf(b, "- `%v`\n", e.Name)
+			case e.AllStates:
+				fmt.Fprintf(b, "- `%v` (all states)\n", e.Name)
+			default:
+				fmt.Fprintf(b, "- `%v` (%v states)\n", e.Name, len(e.States))
+				for _, properties := range e.States {
+					fmt.Fprintf(b, "  - `%v`\n", properties)
+				}
+			}
+		}
+	}
+}
+
+// flatten returns the entries of all namespaces passed.
+func flatten(namespaces []mapping.NamespaceCoverage) []mapping.CoverageEntry {
+	var entries []mapping.CoverageEntry
+	for _, ns := range namespaces {
+		entries = append(entries, ns.Entries...)
+	}
+	return entries
+}
+
+// countStates returns the amount of block states held by the entries of the namespaces passed.
+func countStates(namespaces []mapping.NamespaceCoverage) int {
+	n := 0
+	for _, e := range flatten(namespaces) {
+		n += len(e.States)
+	}
+	return n
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// item is an entry of an item table.
+type item struct {
+	Name      string
+	RuntimeID int32
+}
+
+// jsonItem is an entry of an item table in JSON. Tables extracted from the game name the runtime ID either id or
+// runtime_id.
+type jsonItem struct {
+	Name      string `json:"name"`
+	ID        *int32 `json:"id"`
+	RuntimeID *int32 `json:"runtime_id"`
+}
+
+// runtimeID returns the runtime ID of the entry.
+func (i jsonItem) runtimeID() (int32, error) {
+	switch {
+	case i.RuntimeID != nil:
+		return *i.RuntimeID, nil
+	case i.ID != nil:
+		return *i.ID, nil
+	}
+	return 0, fmt.Errorf("item %v has no runtime ID", i.Name)
+}
+
+// readItems reads the item table at the path passed. The table is either an item_runtime_ids.nbt file, or a JSON
+// file holding one of the following:
+//   - an object of item names to runtime IDs;
+//   - an object of item names to objects holding a runtime_id or id, such as required_item_list.json;
+//   - an array of objects holding a name and a runtime_id or id, such as the item list sent in the StartGame packet.
+//
+// The items are returned in the order they were found in.
+func readItems(path string) ([]item, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read items: %w", err)
+	}
+	if filepath.Ext(path) == ".nbt" {
+		var m map[string]int32
+		if err := nbt.Unmarshal(data, &m); err != nil {
+			return nil, fmt.Errorf("decode items: %w", err)
+		}
+		items := make([]item, 0, len(m))
+		for name, rid := range m {
+			items = append(items, item{Name: name, RuntimeID: rid})
+		}
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].RuntimeID < items[j].RuntimeID
+		})
+		return items, nil
+	}
+	items, err := decodeJSONItems(data)
+	if err != nil {
+		return nil, fmt.Errorf("decode items: %w", err)
+	}
+	return items, nil
+}
+
+// decodeJSONItems decodes a JSON item table. Objects are decoded entry by entry, so that duplicate names are kept
+// and can be reported.
+func decodeJSONItems(data []byte) ([]item, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	var items []item
+	switch tok {
+	case json.Delim('['):
+		for dec.More() {
+			var entry jsonItem
+			if err := dec.Decode(&entry); err != nil {
+				return nil, err
+			}
+			rid, err := entry.runtimeID()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item{Name: entry.Name, RuntimeID: rid})
+		}
+	case json.Delim('{'):
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			name := tok.(string)
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return nil, err
+			}
+			var rid int32
+			if err := json.Unmarshal(raw, &rid); err != nil {
+				entry := jsonItem{Name: name}
+				if err := json.Unmarshal(raw, &entry); err != nil {
+					return nil, fmt.Errorf("item %v: %w", name, err)
+				}
+				if rid, err = entry.runtimeID(); err != nil {
+					return nil, err
+				}
+			}
+			items = append(items, item{Name: name, RuntimeID: rid})
+		}
+	default:
+		return nil, fmt.Errorf("expected a JSON object or array, got %v", tok)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// checkItems checks the items passed, returning a description of every problem found.
+func checkItems(items []item) []string {
+	var problems []string
+	air := false
+	names := make(map[string]int32, len(items))
+	runtimeIDs := make(map[int32]string, len(items))
+	for _, i := range items {
+		if i.Name == "" {
+			problems = append(problems, fmt.Sprintf("item with runtime ID %v has no name", i.RuntimeID))
+			continue
+		}
+		if i.Name == "minecraft:air" {
+			air = true
+		}
+		if rid, ok := names[i.Name]; ok {
+			problems = append(problems, fmt.Sprintf("item %v is listed twice, with runtime IDs %v and %v", i.Name, rid, i.RuntimeID))
+			continue
+		}
+		names[i.Name] = i.RuntimeID
+		if other, ok := runtimeIDs[i.RuntimeID]; ok {
+			problems = append(problems, fmt.Sprintf("items %v and %v share runtime ID %v", other, i.Name, i.RuntimeID))
+		}
+		runtimeIDs[i.RuntimeID] = i.Name
+	}
+	if !air {
+		problems = append(problems, "items have no minecraft:air")
+	}
+	return problems
+}
+
+// encodeItems encodes the items passed in the format read by mapping.NewItemMapping.
+func encodeItems(items []item) ([]byte, error) {
+	m := make(map[string]any, len(items))
+	for _, i := range items {
+		m[i.Name] = i.RuntimeID
+	}
+	return encodeCompound(m)
+}
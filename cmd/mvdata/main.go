@@ -0,0 +1,105 @@
+// Command mvdata maintains the mapping data embedded by the protocols of multiversion.
+//
+// Usage:
+//
+//	mvdata gen -blocks <block states> -items <item table> [-out <dir>] [-sort] [-check]
+//	mvdata coverage [-format json|markdown] [-out <file>] [-versions <versions>]
+//
+// The gen command reads a canonical block state dump and an item table, as extracted from a Bedrock Dedicated Server
+// install, checks them and writes them as the normalized block_states.nbt and item_runtime_ids.nbt files expected by
+// mapping.NewBlockMapping and mapping.NewItemMapping.
+//
+// The coverage command lists the block states and items of the latest version that have no target in each protocol
+// version, and are thus translated to air or minecraft:info_update.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	switch os.Args[1] {
+	case "gen":
+		err = runGen(os.Args[2:])
+	case "coverage":
+		err = runCoverage(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "mvdata:", err)
+		os.Exit(1)
+	}
+}
+
+// usage prints the usage of mvdata and exits.
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: mvdata gen -blocks <block states> -items <item table> [-out <dir>] [-sort] [-check]")
+	fmt.Fprintln(os.Stderr, "       mvdata coverage [-format json|markdown] [-out <file>] [-versions <versions>]")
+	os.Exit(2)
+}
+
+// runGen runs the gen command with the arguments passed.
+func runGen(args []string) error {
+	fs := flag.NewFlagSet("gen", flag.ExitOnError)
+	blocksPath := fs.String("blocks", "", "canonical block state dump: concatenated NBT compounds holding name, states and version")
+	itemsPath := fs.String("items", "", "item table: a JSON object or array mapping item names to runtime IDs, or an item_runtime_ids.nbt file")
+	out := fs.String("out", ".", "directory to write block_states.nbt and item_runtime_ids.nbt to")
+	sortStates := fs.Bool("sort", false, "sort the block states by the hash of their name, like the game assigns runtime IDs")
+	check := fs.Bool("check", false, "only check the input files, without writing anything")
+	_ = fs.Parse(args)
+	if *blocksPath == "" || *itemsPath == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	states, err := readBlockStates(*blocksPath)
+	if err != nil {
+		return err
+	}
+	if *sortStates {
+		sortBlockStates(states)
+	}
+	items, err := readItems(*itemsPath)
+	if err != nil {
+		return err
+	}
+
+	problems := append(checkBlockStates(states), checkItems(items)...)
+	for _, problem := range problems {
+		fmt.Fprintln(os.Stderr, problem)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("found %v problems in the input files", len(problems))
+	}
+	if *check {
+		fmt.Printf("%v block states and %v items are valid\n", len(states), len(items))
+		return nil
+	}
+
+	blockData, err := encodeBlockStates(states)
+	if err != nil {
+		return fmt.Errorf("encode block states: %w", err)
+	}
+	itemData, err := encodeItems(items)
+	if err != nil {
+		return fmt.Errorf("encode items: %w", err)
+	}
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		return err
+	}
+	if err := writeFile(*out, "block_states.nbt", blockData); err != nil {
+		return err
+	}
+	if err := writeFile(*out, "item_runtime_ids.nbt", itemData); err != nil {
+		return err
+	}
+	fmt.Printf("wrote %v block states and %v items to %v\n", len(states), len(items), *out)
+	return nil
+}
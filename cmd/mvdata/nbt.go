@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// tagCompound is the NBT tag type of compounds.
+const tagCompound = 10
+
+// encodeCompound encodes the map passed as a network NBT compound. The nbt package writes the entries of maps in
+// random order, so the entries are written sorted by their name here instead, which makes the files generated
+// reproducible.
+func encodeCompound(m map[string]any) ([]byte, error) {
+	// The root compound has an empty name, encoded as its length only.
+	b := []byte{tagCompound, 0}
+	payload, err := encodeCompoundPayload(m)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, payload...), nil
+}
+
+// encodeCompoundPayload encodes the entries of the map passed sorted by name, followed by the end tag.
+func encodeCompoundPayload(m map[string]any) ([]byte, error) {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b []byte
+	for _, name := range names {
+		nested, ok := m[name].(map[string]any)
+		if !ok {
+			entry, err := encodeEntry(name, m[name])
+			if err != nil {
+				return nil, err
+			}
+			b = append(b, entry...)
+			continue
+		}
+		// Encode the header of the nested compound through an empty compound, without its end tag.
+		entry, err := encodeEntry(name, map[string]any{})
+		if err != nil {
+			return nil, err
+		}
+		payload, err := encodeCompoundPayload(nested)
+		if err != nil {
+			return nil, err
+		}
+		b = append(append(b, entry[:len(entry)-1]...), payload...)
+	}
+	return append(b, 0), nil
+}
+
+// encodeEntry encodes the named tag passed as it is found in a compound.
+func encodeEntry(name string, v any) ([]byte, error) {
+	b, err := nbt.Marshal(map[string]any{name: v})
+	if err != nil {
+		return nil, fmt.Errorf("encode %v: %w", name, err)
+	}
+	// Strip the header of the root compound and its end tag.
+	return b[2 : len(b)-1], nil
+}
+
+// writeFile writes the data passed to the file with the name passed in the directory passed.
+func writeFile(dir, name string, data []byte) error {
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+		return fmt.Errorf("write %v: %w", name, err)
+	}
+	return nil
+}
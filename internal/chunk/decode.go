@@ -65,6 +65,27 @@ func NetworkDecode(air uint32, buf *bytes.Buffer, count int, oldFormat bool, r c
 	return c, nil
 }
 
+// DecodeBiomes decodes the biome storages held by the network serialised data passed, until no data is left. It is
+// used for the biome blobs of the client blob cache, which hold the biomes of a chunk without its sub chunks.
+func DecodeBiomes(buf *bytes.Buffer, e Encoding) ([]*PalettedStorage, error) {
+	var biomes []*PalettedStorage
+	for buf.Len() > 0 {
+		b, err := decodePalettedStorage(buf, e, BiomePaletteEncoding)
+		if err != nil {
+			return nil, err
+		}
+		if b == nil {
+			if len(biomes) == 0 {
+				// This should never happen and there is no way to handle this.
+				return nil, fmt.Errorf("first biome storage pointed to previous one")
+			}
+			b = biomes[len(biomes)-1]
+		}
+		biomes = append(biomes, b)
+	}
+	return biomes, nil
+}
+
 // DecodeSubChunk decodes a SubChunk from a bytes.Buffer. The Encoding passed defines how the block storages of the
 // SubChunk are decoded.
 func DecodeSubChunk(air uint32, r cube.Range, buf *bytes.Buffer, index *byte, e Encoding) (*SubChunk, error) {
@@ -65,6 +65,11 @@ func EncodeSubChunk(s *SubChunk, e Encoding, subChunkVer subChunkVersion, r cube
 // EncodeBiomes encodes the biomes of a chunk into bytes. An Encoding may be passed to encode either for network or
 // disk purposed, the most notable difference being that the network encoding generally uses varints and no NBT.
 func EncodeBiomes(c *Chunk, e Encoding) []byte {
+	return EncodeBiomeStorages(c.biomes, e)
+}
+
+// EncodeBiomeStorages encodes the biome storages passed into bytes, in the same way as EncodeBiomes.
+func EncodeBiomeStorages(biomes []*PalettedStorage, e Encoding) []byte {
 	buf := pool.Get().(*bytes.Buffer)
 	defer func() {
 		buf.Reset()
@@ -72,13 +77,13 @@ func EncodeBiomes(c *Chunk, e Encoding) []byte {
 	}()
 
 	var previous *PalettedStorage
-	for _, b := range c.biomes {
+	for _, b := range biomes {
 		encodePalettedStorage(buf, b, previous, e, BiomePaletteEncoding)
 		previous = b
 	}
-	biomes := make([]byte, buf.Len())
-	_, _ = buf.Read(biomes)
-	return biomes
+	encoded := make([]byte, buf.Len())
+	_, _ = buf.Read(encoded)
+	return encoded
 }
 
 // encodePalettedStorage encodes a PalettedStorage into a bytes.Buffer. The Encoding passed is used to write the Palette
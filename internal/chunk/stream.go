@@ -0,0 +1,120 @@
+package chunk
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrNotStreamable is returned by TranslateSubChunk and TranslateBiomes if the data passed can't be translated by
+// rewriting its palettes only. The data should then be fully decoded and encoded again instead.
+var ErrNotStreamable = errors.New("data cannot be translated by rewriting its palettes")
+
+// errShortPayload is returned if a payload ends before the data it should hold.
+var errShortPayload = errors.New("unexpected end of payload")
+
+// TranslateSubChunk translates the network encoded sub chunk at the start of the payload passed, by replacing every
+// palette entry with the value returned by the function passed. The bit-packed indices are copied as they are, so
+// the sub chunk is never decoded as a whole. The translated sub chunk and the amount of bytes read from the payload
+// are returned.
+func TranslateSubChunk(payload []byte, f func(v uint32) uint32) ([]byte, int, error) {
+	if len(payload) == 0 {
+		return nil, 0, fmt.Errorf("error reading version: %w", errShortPayload)
+	}
+	off, storageCount := 1, 1
+	switch ver := payload[0]; ver {
+	default:
+		return nil, 0, fmt.Errorf("unknown sub chunk version %v: can't translate", ver)
+	case 1:
+	case 8, 9:
+		// Version 9 holds the Y value of the sub chunk right after the storage count.
+		header := 1
+		if ver == 9 {
+			header = 2
+		}
+		if len(payload) < off+header {
+			return nil, 0, fmt.Errorf("error reading storage count: %w", errShortPayload)
+		}
+		storageCount = int(payload[off])
+		off += header
+	}
+	translated := make([]byte, 0, len(payload))
+	translated = append(translated, payload[:off]...)
+
+	var err error
+	for i := 0; i < storageCount; i++ {
+		if translated, off, err = translatePalettedStorage(payload, off, translated, f); err != nil {
+			return nil, 0, err
+		}
+	}
+	return translated, off, nil
+}
+
+// TranslateBiomes works like TranslateSubChunk, but for count network encoded biome storages. If count is negative,
+// biome storages are translated until the end of the payload.
+func TranslateBiomes(payload []byte, count int, f func(v uint32) uint32) ([]byte, int, error) {
+	translated := make([]byte, 0, len(payload))
+	off := 0
+
+	var err error
+	for i := 0; i != count && (count >= 0 || off < len(payload)); i++ {
+		if translated, off, err = translatePalettedStorage(payload, off, translated, f); err != nil {
+			return nil, 0, err
+		}
+	}
+	return translated, off, nil
+}
+
+// translatePalettedStorage translates the network encoded PalettedStorage at the offset passed, appending it to the
+// translated data. The translated data and the offset right after the storage are returned.
+func translatePalettedStorage(payload []byte, off int, translated []byte, f func(v uint32) uint32) ([]byte, int, error) {
+	if off >= len(payload) {
+		return nil, 0, fmt.Errorf("error reading block size: %w", errShortPayload)
+	}
+	header := payload[off]
+	if header&1 != 1 {
+		// Persistent storages hold NBT palettes, which are not translated in place.
+		return nil, 0, ErrNotStreamable
+	}
+	if header>>1 == 0x7f {
+		// The storage is the same as the previous one, which is already translated.
+		return append(translated, header), off + 1, nil
+	}
+	size := paletteSize(header >> 1)
+	if !slices.Contains(sizes[:], size) {
+		return nil, 0, fmt.Errorf("invalid palette size %v", size)
+	}
+	end := off + 1 + size.uint32s()*4
+	if end > len(payload) {
+		return nil, 0, fmt.Errorf("cannot read paletted storage (size=%v): %w", size, errShortPayload)
+	}
+	translated = append(translated, payload[off:end]...)
+	off = end
+
+	paletteCount := int64(1)
+	if size != 0 {
+		var n int
+		if paletteCount, n = binary.Varint(payload[off:]); n <= 0 {
+			return nil, 0, fmt.Errorf("error reading palette entry count: %w", errShortPayload)
+		}
+		if paletteCount <= 0 {
+			return nil, 0, fmt.Errorf("invalid palette entry count %v", paletteCount)
+		}
+		if paletteCount > 1<<size {
+			// The palette doesn't fit the size class of the indices, so they have to be written again.
+			return nil, 0, ErrNotStreamable
+		}
+		translated = append(translated, payload[off:off+n]...)
+		off += n
+	}
+	for i := int64(0); i < paletteCount; i++ {
+		v, n := binary.Varint(payload[off:])
+		if n <= 0 {
+			return nil, 0, fmt.Errorf("error decoding palette entry: %w", errShortPayload)
+		}
+		translated = binary.AppendVarint(translated, int64(int32(f(uint32(v)))))
+		off += n
+	}
+	return translated, off, nil
+}
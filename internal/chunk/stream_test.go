@@ -0,0 +1,237 @@
+package chunk
+
+import (
+	"bytes"
+	"errors"
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"testing"
+)
+
+// testRange is the range of the chunks used by the tests.
+var testRange = cube.Range{-64, 319}
+
+// translateTest is the function palettes are translated with by the tests.
+func translateTest(v uint32) uint32 {
+	return v*3 + 1000
+}
+
+// testChunk returns a chunk with blocks in two layers. The biomes of the second and third sub chunk are the same, so
+// that the storage of the third points to the previous storage when encoded.
+func testChunk() *Chunk {
+	c := New(0, testRange)
+	for x := uint8(0); x < 16; x++ {
+		for z := uint8(0); z < 16; z++ {
+			for y := int16(-64); y < 64; y++ {
+				c.SetBlock(x, y, z, 0, uint32(x)+uint32(z)*16+uint32(y+64)%5)
+			}
+			c.SetBlock(x, 0, z, 1, uint32(x%3)+1)
+			for y := int16(-64); y < -48; y++ {
+				c.SetBiome(x, y, z, uint32(z)+1)
+			}
+			for y := int16(-48); y < -16; y++ {
+				c.SetBiome(x, y, z, 7+uint32(x%2))
+			}
+		}
+	}
+	// Storages are only encoded as pointing to the previous storage if their palettes hold no unused values.
+	for _, b := range c.biomes {
+		b.compact()
+	}
+	return c
+}
+
+// translatedSubChunk returns a copy of the sub chunk passed with its palettes translated by translateTest, decoded
+// from the payload passed.
+func translatedSubChunk(t testing.TB, payload []byte) *SubChunk {
+	var index byte
+	sub, err := DecodeSubChunk(0, testRange, bytes.NewBuffer(payload), &index, NetworkEncoding)
+	if err != nil {
+		t.Fatalf("decode sub chunk: %v", err)
+	}
+	for _, storage := range sub.storages {
+		storage.Palette().Replace(translateTest)
+	}
+	return sub
+}
+
+// assertSubChunksEqual fails the test if the sub chunks passed do not hold the same blocks.
+func assertSubChunksEqual(t *testing.T, want, got *SubChunk) {
+	t.Helper()
+	if len(want.storages) != len(got.storages) {
+		t.Fatalf("layer count: want %v, got %v", len(want.storages), len(got.storages))
+	}
+	for layer := range want.storages {
+		assertStoragesEqual(t, want.storages[layer], got.storages[layer])
+	}
+}
+
+// assertStoragesEqual fails the test if the storages passed do not hold the same values.
+func assertStoragesEqual(t *testing.T, want, got *PalettedStorage) {
+	t.Helper()
+	for x := byte(0); x < 16; x++ {
+		for y := byte(0); y < 16; y++ {
+			for z := byte(0); z < 16; z++ {
+				if w, g := want.At(x, y, z), got.At(x, y, z); w != g {
+					t.Fatalf("value at %v %v %v: want %v, got %v", x, y, z, w, g)
+				}
+			}
+		}
+	}
+}
+
+func TestTranslateSubChunk(t *testing.T) {
+	sub := testChunk().Sub()[4]
+	v8 := EncodeSubChunk(sub, NetworkEncoding, SubChunkVersion8, testRange, 4)
+	// Version 1 holds a single storage right after the version, which EncodeSubChunk can't write.
+	single := &SubChunk{air: 0, storages: sub.storages[:1]}
+	v1 := append([]byte{1}, EncodeSubChunk(single, NetworkEncoding, SubChunkVersion8, testRange, 4)[2:]...)
+
+	for _, tc := range []struct {
+		name    string
+		payload []byte
+	}{
+		{name: "version 1", payload: v1},
+		{name: "version 8", payload: v8},
+		{name: "version 9", payload: EncodeSubChunk(sub, NetworkEncoding, SubChunkVersion9, testRange, 4)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// The sub chunk is followed by other data, such as block actors, which must not be read.
+			payload := append(bytes.Clone(tc.payload), 0xa, 0xb)
+			translated, n, err := TranslateSubChunk(payload, translateTest)
+			if err != nil {
+				t.Fatalf("translate: %v", err)
+			}
+			if n != len(tc.payload) {
+				t.Fatalf("bytes read: want %v, got %v", len(tc.payload), n)
+			}
+			var index byte
+			got, err := DecodeSubChunk(0, testRange, bytes.NewBuffer(translated), &index, NetworkEncoding)
+			if err != nil {
+				t.Fatalf("decode translated sub chunk: %v", err)
+			}
+			assertSubChunksEqual(t, translatedSubChunk(t, tc.payload), got)
+		})
+	}
+}
+
+func TestTranslateLevelChunk(t *testing.T) {
+	c := testChunk()
+	payload, err := NetworkEncode(0, c, false)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	want, err := NetworkDecode(0, bytes.NewBuffer(payload), len(c.Sub()), false, testRange)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	for _, sub := range want.Sub() {
+		for _, storage := range sub.storages {
+			storage.Palette().Replace(translateTest)
+		}
+	}
+	for i, b := range want.biomes {
+		// Storages pointing to the previous storage share it, so it must only be translated once.
+		if i == 0 || b != want.biomes[i-1] {
+			b.Palette().Replace(translateTest)
+		}
+	}
+
+	var translated []byte
+	off := 0
+	for range c.Sub() {
+		sub, n, err := TranslateSubChunk(payload[off:], translateTest)
+		if err != nil {
+			t.Fatalf("translate sub chunk: %v", err)
+		}
+		translated, off = append(translated, sub...), off+n
+	}
+	biomes, n, err := TranslateBiomes(payload[off:], len(c.biomes), translateTest)
+	if err != nil {
+		t.Fatalf("translate biomes: %v", err)
+	}
+	if off+n != len(payload) {
+		t.Fatalf("bytes read: want %v, got %v", len(payload), off+n)
+	}
+	got, err := NetworkDecode(0, bytes.NewBuffer(append(translated, biomes...)), len(c.Sub()), false, testRange)
+	if err != nil {
+		t.Fatalf("decode translated chunk: %v", err)
+	}
+	for i := range want.Sub() {
+		assertSubChunksEqual(t, want.Sub()[i], got.Sub()[i])
+	}
+	for i := range want.biomes {
+		assertStoragesEqual(t, want.biomes[i], got.biomes[i])
+	}
+}
+
+func TestTranslateBiomesSameAsPrevious(t *testing.T) {
+	c := testChunk()
+	payload := EncodeBiomeStorages(c.biomes[:3], NetworkEncoding)
+	if last := payload[len(payload)-1]; last != 0x7f<<1|1 {
+		t.Fatalf("last storage should point to the previous one, got header %#x", last)
+	}
+	for _, count := range []int{3, -1} {
+		translated, n, err := TranslateBiomes(payload, count, translateTest)
+		if err != nil {
+			t.Fatalf("translate %v storages: %v", count, err)
+		}
+		if n != len(payload) {
+			t.Fatalf("bytes read: want %v, got %v", len(payload), n)
+		}
+		biomes, err := DecodeBiomes(bytes.NewBuffer(translated), NetworkEncoding)
+		if err != nil {
+			t.Fatalf("decode translated biomes: %v", err)
+		}
+		if len(biomes) != 3 || biomes[2] != biomes[1] {
+			t.Fatalf("last storage should be the same as the previous one")
+		}
+		if want, got := translateTest(4), biomes[0].At(0, 0, 3); got != want {
+			t.Fatalf("biome of first storage: want %v, got %v", want, got)
+		}
+		if want, got := translateTest(8), biomes[2].At(1, 0, 0); got != want {
+			t.Fatalf("biome of last storage: want %v, got %v", want, got)
+		}
+	}
+}
+
+func TestTranslateSubChunkPersistent(t *testing.T) {
+	payload := EncodeSubChunk(testChunk().Sub()[4], NetworkPersistentEncoding, SubChunkVersion9, testRange, 4)
+	if _, _, err := TranslateSubChunk(payload, translateTest); !errors.Is(err, ErrNotStreamable) {
+		t.Fatalf("want ErrNotStreamable, got %v", err)
+	}
+}
+
+func TestTranslateTruncated(t *testing.T) {
+	c := testChunk()
+	sub := EncodeSubChunk(c.Sub()[4], NetworkEncoding, SubChunkVersion9, testRange, 4)
+	for i := 0; i < len(sub); i++ {
+		if _, _, err := TranslateSubChunk(sub[:i], translateTest); err == nil {
+			t.Fatalf("sub chunk truncated to %v of %v bytes: want error", i, len(sub))
+		}
+	}
+	biomes := EncodeBiomeStorages(c.biomes, NetworkEncoding)
+	for i := 0; i < len(biomes); i++ {
+		if _, _, err := TranslateBiomes(biomes[:i], len(c.biomes), translateTest); err == nil {
+			t.Fatalf("biomes truncated to %v of %v bytes: want error", i, len(biomes))
+		}
+	}
+}
+
+func BenchmarkTranslateSubChunk(b *testing.B) {
+	payload := EncodeSubChunk(testChunk().Sub()[4], NetworkEncoding, SubChunkVersion9, testRange, 4)
+	b.Run("stream", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, _, err := TranslateSubChunk(payload, translateTest); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("decode", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sub := translatedSubChunk(b, payload)
+			_ = EncodeSubChunk(sub, NetworkEncoding, SubChunkVersion9, testRange, 4)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package mapping
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+)
+
+type Biome interface {
+	// BiomeIDToName converts a biome ID to a biome name.
+	BiomeIDToName(uint32) (string, bool)
+	// BiomeNameToID converts a biome name to a biome ID.
+	BiomeNameToID(string) (uint32, bool)
+	// Nearest returns the ID of the biome closest to the biome name passed that is present in the mapping.
+	Nearest(string) uint32
+}
+
+type DefaultBiomeMapping struct {
+	// biomeIDsToNames holds a map to translate biome IDs to biome names.
+	biomeIDsToNames map[uint32]string
+	// biomeNamesToIDs holds a map to translate biome names to biome IDs.
+	biomeNamesToIDs map[string]uint32
+	// fallbacks holds the names of the biomes to use instead of biomes missing from the mapping.
+	fallbacks map[string]string
+	// fallbackID is the ID of the biome used when no other fallback could be found.
+	fallbackID uint32
+}
+
+func NewBiomeMapping(raw []byte) *DefaultBiomeMapping {
+	biomeIDsToNames := make(map[uint32]string)
+	biomeNamesToIDs := make(map[string]uint32)
+
+	var biomes map[string]int32
+	if err := nbt.Unmarshal(raw, &biomes); err != nil {
+		panic(err)
+	}
+	for name, id := range biomes {
+		biomeNamesToIDs[name] = uint32(id)
+		biomeIDsToNames[uint32(id)] = name
+	}
+	fallbackID, ok := biomeNamesToIDs["plains"]
+	if !ok {
+		panic("couldn't find plains")
+	}
+
+	return &DefaultBiomeMapping{
+		biomeIDsToNames: biomeIDsToNames,
+		biomeNamesToIDs: biomeNamesToIDs,
+		fallbacks:       make(map[string]string),
+		fallbackID:      fallbackID,
+	}
+}
+
+// WithFallback makes the mapping use the biome nearest when a client receives the biome name passed, which does
+// not exist in the mapping. Fallbacks may be chained, as long as the last biome exists in the mapping.
+func (m *DefaultBiomeMapping) WithFallback(name, nearest string) *DefaultBiomeMapping {
+	m.fallbacks[name] = nearest
+	return m
+}
+
+// WithDefaultFallback sets the biome used when no fallback was registered for a missing biome. By default, this
+// is plains.
+func (m *DefaultBiomeMapping) WithDefaultFallback(name string) *DefaultBiomeMapping {
+	id, ok := m.biomeNamesToIDs[name]
+	if !ok {
+		panic("couldn't find " + name)
+	}
+	m.fallbackID = id
+	return m
+}
+
+func (m *DefaultBiomeMapping) BiomeIDToName(id uint32) (string, bool) {
+	name, ok := m.biomeIDsToNames[id]
+	return name, ok
+}
+
+func (m *DefaultBiomeMapping) BiomeNameToID(name string) (uint32, bool) {
+	id, ok := m.biomeNamesToIDs[name]
+	return id, ok
+}
+
+func (m *DefaultBiomeMapping) Nearest(name string) uint32 {
+	// Limit the amount of lookups, so that a cycle in the fallbacks can't hang the translation.
+	for i := 0; i <= len(m.fallbacks); i++ {
+		if id, ok := m.biomeNamesToIDs[name]; ok {
+			return id
+		}
+		nearest, ok := m.fallbacks[name]
+		if !ok {
+			break
+		}
+		name = nearest
+	}
+	return m.fallbackID
+}
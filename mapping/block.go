@@ -7,7 +7,11 @@ import (
 	"github.com/sandertv/gophertunnel/minecraft/nbt"
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
 	"github.com/segmentio/fasthash/fnv1"
+	"golang.org/x/exp/slices"
+	"math"
 	"sort"
+	"strings"
+	"sync"
 )
 
 type Block interface {
@@ -15,12 +19,15 @@ type Block interface {
 	StateToRuntimeID(blockupgrader.BlockState) (uint32, bool)
 	// RuntimeIDToState converts a runtime ID to a name and its state properties.
 	RuntimeIDToState(uint32) (blockupgrader.BlockState, bool)
-	// DowngradeBlockActorData downgrades the input sub chunk to a legacy block actor.
-	DowngradeBlockActorData(map[string]any)
-	// UpgradeBlockActorData upgrades the input sub chunk to the latest block actor.
-	UpgradeBlockActorData(map[string]any)
-	// Adjust adjusts the latest mappings to account for custom states.
-	Adjust([]protocol.BlockEntry)
+	// DowngradeBlockActorData downgrades the NBT of a block actor of the latest version to a legacy block actor. Nil is
+	// returned if the block actor does not exist in the legacy version.
+	DowngradeBlockActorData(map[string]any) map[string]any
+	// UpgradeBlockActorData upgrades the NBT of a legacy block actor to a block actor of the latest version. Nil is
+	// returned if the block actor does not exist in the latest version.
+	UpgradeBlockActorData(map[string]any) map[string]any
+	// Adjust returns a copy of the mapping that accounts for the custom states passed. The mapping itself is never
+	// changed, so that it may be shared between connections.
+	Adjust([]protocol.BlockEntry) Block
 	Air() uint32
 }
 
@@ -30,11 +37,29 @@ type DefaultBlockMapping struct {
 	// stateRuntimeIDs holds a map for looking up the runtime ID of a block by the stateHash it produces.
 	stateRuntimeIDs map[internal.StateHash]uint32
 	// runtimeIDToState holds a map for looking up the blockState of a block by its runtime ID.
-	runtimeIDToState     map[uint32]blockupgrader.BlockState
-	upgrader, downgrader func(map[string]any) map[string]any
+	runtimeIDToState map[uint32]blockupgrader.BlockState
+	// blockActors holds the transformers run on the NBT of block actors.
+	blockActors *BlockActorTransformers
 
 	// airRID is the runtime ID of the air block in the latest version of the game.
 	airRID uint32
+
+	// adjusted holds the mappings returned by Adjust. It is shared by all copies of the mapping.
+	adjusted *adjustedBlockMappings
+}
+
+// maxAdjustedBlockMappings is the maximum amount of adjusted copies of a block mapping kept by adjustedBlockMappings.
+const maxAdjustedBlockMappings = 32
+
+// adjustedBlockMappings holds the adjusted copies of a block mapping by the custom states they account for, so that
+// connections receiving the same custom states share the same mapping. At most maxAdjustedBlockMappings copies are
+// kept, after which the oldest is removed. Connections keep using the copy they were given, so removing a copy only
+// stops it from being shared with new connections.
+type adjustedBlockMappings struct {
+	mu       sync.Mutex
+	mappings map[string]*DefaultBlockMapping
+	// keys holds the keys of the mappings in the order they were added.
+	keys []string
 }
 
 func NewBlockMapping(raw []byte) *DefaultBlockMapping {
@@ -68,13 +93,25 @@ func NewBlockMapping(raw []byte) *DefaultBlockMapping {
 		states:           states,
 		stateRuntimeIDs:  stateRuntimeIDs,
 		runtimeIDToState: runtimeIDToState,
+		blockActors:      NewBlockActorTransformers(),
 		airRID:           *airRID,
+		adjusted:         &adjustedBlockMappings{mappings: make(map[string]*DefaultBlockMapping)},
 	}
 }
 
+// WithBlockActorTransformers sets the transformers run on the NBT of block actors, which should only hold the
+// transformers of the protocol version of the mapping. See BlockActorTransformers.ForProtocol.
+func (m *DefaultBlockMapping) WithBlockActorTransformers(transformers *BlockActorTransformers) *DefaultBlockMapping {
+	m.blockActors = transformers
+	return m
+}
+
+// WithBlockActorRemapper registers functions run on the NBT of all block actors, after the transformers set through
+// WithBlockActorTransformers when downgrading and before them when upgrading.
+//
+// Deprecated: Register a BlockActorTransformer for the block actor ID it applies to through WithBlockActorTransformers.
 func (m *DefaultBlockMapping) WithBlockActorRemapper(downgrader, upgrader func(map[string]any) map[string]any) *DefaultBlockMapping {
-	m.downgrader = downgrader
-	m.upgrader = upgrader
+	m.blockActors.With("", math.MinInt32, math.MaxInt32, BlockActorFuncs{Downgrade: downgrader, Upgrade: upgrader})
 	return m
 }
 
@@ -88,46 +125,77 @@ func (m *DefaultBlockMapping) RuntimeIDToState(runtimeId uint32) (blockupgrader.
 	return state, found
 }
 
-func (m *DefaultBlockMapping) DowngradeBlockActorData(actorData map[string]any) {
-	if m.downgrader != nil {
-		m.downgrader(actorData)
-	}
+func (m *DefaultBlockMapping) DowngradeBlockActorData(actorData map[string]any) map[string]any {
+	return m.blockActors.Downgrade(actorData)
 }
 
-func (m *DefaultBlockMapping) UpgradeBlockActorData(actorData map[string]any) {
-	if m.upgrader != nil {
-		m.upgrader(actorData)
+func (m *DefaultBlockMapping) UpgradeBlockActorData(actorData map[string]any) map[string]any {
+	return m.blockActors.Upgrade(actorData)
+}
+
+func (m *DefaultBlockMapping) Adjust(entries []protocol.BlockEntry) Block {
+	adjusted, key := m.adjust(entries)
+	if adjusted == m {
+		return m
+	}
+
+	m.adjusted.mu.Lock()
+	defer m.adjusted.mu.Unlock()
+	if existing, ok := m.adjusted.mappings[key]; ok {
+		return existing
 	}
+	if len(m.adjusted.keys) >= maxAdjustedBlockMappings {
+		delete(m.adjusted.mappings, m.adjusted.keys[0])
+		m.adjusted.keys = m.adjusted.keys[1:]
+	}
+	m.adjusted.mappings[key] = adjusted
+	m.adjusted.keys = append(m.adjusted.keys, key)
+	return adjusted
 }
 
-func (m *DefaultBlockMapping) Adjust(entries []protocol.BlockEntry) {
+// adjust returns a copy of the mapping that accounts for the custom states in the entries passed, together with a
+// key unique to the custom states added. The mapping itself is returned if there are no new states.
+func (m *DefaultBlockMapping) adjust(entries []protocol.BlockEntry) (*DefaultBlockMapping, string) {
 	if len(entries) == 0 {
-		return
+		return m, ""
 	}
 
-	customStates := convert(entries)
 	var newStates []blockupgrader.BlockState
-	for _, state := range customStates {
+	var key strings.Builder
+	for _, state := range convert(entries) {
 		if _, ok := m.StateToRuntimeID(state); !ok {
 			newStates = append(newStates, state)
+			hash := internal.HashState(state)
+			key.WriteString(hash.Name)
+			key.WriteString(hash.Properties)
+			key.WriteByte(0)
 		}
 	}
 	if len(newStates) == 0 {
-		return
+		return m, ""
 	}
 
-	adjustedStates := append(m.states, customStates...)
+	adjustedStates := append(slices.Clip(m.states), newStates...)
 	sort.SliceStable(adjustedStates, func(i, j int) bool {
 		stateOne, stateTwo := adjustedStates[i], adjustedStates[j]
-		return stateOne.Name == stateTwo.Name && fnv1.HashString64(stateOne.Name) < fnv1.HashString64(stateTwo.Name)
+		return stateOne.Name != stateTwo.Name && fnv1.HashString64(stateOne.Name) < fnv1.HashString64(stateTwo.Name)
 	})
 
-	m.stateRuntimeIDs = make(map[internal.StateHash]uint32, len(adjustedStates))
-	m.runtimeIDToState = make(map[uint32]blockupgrader.BlockState, len(adjustedStates))
+	adjusted := &DefaultBlockMapping{
+		states:           adjustedStates,
+		stateRuntimeIDs:  make(map[internal.StateHash]uint32, len(adjustedStates)),
+		runtimeIDToState: make(map[uint32]blockupgrader.BlockState, len(adjustedStates)),
+		blockActors:      m.blockActors,
+		adjusted:         m.adjusted,
+	}
 	for rid, state := range adjustedStates {
-		m.stateRuntimeIDs[internal.HashState(blockupgrader.Upgrade(state))] = uint32(rid)
-		m.runtimeIDToState[uint32(rid)] = state
+		adjusted.stateRuntimeIDs[internal.HashState(blockupgrader.Upgrade(state))] = uint32(rid)
+		adjusted.runtimeIDToState[uint32(rid)] = state
+		if state.Name == "minecraft:air" {
+			adjusted.airRID = uint32(rid)
+		}
 	}
+	return adjusted, key.String()
 }
 
 func (m *DefaultBlockMapping) Air() uint32 {
@@ -0,0 +1,192 @@
+package mapping
+
+// BlockActorTransformer transforms the NBT of block actors of which the format differs between the latest version and
+// a legacy version. The NBT passed may be changed in place.
+type BlockActorTransformer interface {
+	// DowngradeBlockActor transforms the NBT of a block actor of the latest version to the format of the legacy
+	// version. Nil is returned if the block actor should not be sent to the legacy version at all.
+	DowngradeBlockActor(data map[string]any) map[string]any
+	// UpgradeBlockActor transforms the NBT of a block actor of the legacy version to the format of the latest version.
+	UpgradeBlockActor(data map[string]any) map[string]any
+}
+
+// BlockActorFuncs is a BlockActorTransformer made out of functions. A nil function leaves the NBT unchanged.
+type BlockActorFuncs struct {
+	// Downgrade transforms the NBT of a block actor of the latest version to the format of the legacy version.
+	Downgrade func(data map[string]any) map[string]any
+	// Upgrade transforms the NBT of a block actor of the legacy version to the format of the latest version.
+	Upgrade func(data map[string]any) map[string]any
+}
+
+// DowngradeBlockActor ...
+func (f BlockActorFuncs) DowngradeBlockActor(data map[string]any) map[string]any {
+	if f.Downgrade == nil {
+		return data
+	}
+	return f.Downgrade(data)
+}
+
+// UpgradeBlockActor ...
+func (f BlockActorFuncs) UpgradeBlockActor(data map[string]any) map[string]any {
+	if f.Upgrade == nil {
+		return data
+	}
+	return f.Upgrade(data)
+}
+
+// BlockActorTransformers is a registry of BlockActorTransformers keyed by block actor ID and by the range of protocol
+// versions they apply to.
+type BlockActorTransformers struct {
+	entries []blockActorEntry
+}
+
+// blockActorEntry is a BlockActorTransformer registered for a block actor ID and a range of protocol versions.
+type blockActorEntry struct {
+	// id is the ID of the block actors the transformer applies to. It applies to all block actors if empty.
+	id string
+	// from and to are the oldest and the newest protocol version the transformer applies to, inclusive.
+	from, to    int32
+	transformer BlockActorTransformer
+}
+
+// NewBlockActorTransformers returns an empty BlockActorTransformers.
+func NewBlockActorTransformers() *BlockActorTransformers {
+	return &BlockActorTransformers{}
+}
+
+// With registers the transformer passed for the block actors with the ID passed, or for all block actors if the ID is
+// empty, in the protocol versions from up to and including to. Transformers are run in the order they were registered
+// in when downgrading, and in the reverse order when upgrading. A transformer may change the ID of a block actor, after
+// which the transformers registered for the new ID are run on it.
+func (r *BlockActorTransformers) With(id string, from, to int32, transformer BlockActorTransformer) *BlockActorTransformers {
+	r.entries = append(r.entries, blockActorEntry{id: id, from: from, to: to, transformer: transformer})
+	return r
+}
+
+// ForProtocol returns a BlockActorTransformers holding only the transformers that apply to the protocol version
+// passed.
+func (r *BlockActorTransformers) ForProtocol(protocolID int32) *BlockActorTransformers {
+	filtered := NewBlockActorTransformers()
+	for _, e := range r.entries {
+		if protocolID >= e.from && protocolID <= e.to {
+			filtered.entries = append(filtered.entries, e)
+		}
+	}
+	return filtered
+}
+
+// Downgrade runs the transformers registered for the block actor passed, returning its NBT in the format of the
+// legacy version. Nil is returned if the block actor should not be sent to the legacy version.
+func (r *BlockActorTransformers) Downgrade(data map[string]any) map[string]any {
+	for _, e := range r.entries {
+		if data == nil {
+			return nil
+		}
+		if e.appliesTo(data) {
+			data = e.transformer.DowngradeBlockActor(data)
+		}
+	}
+	return data
+}
+
+// Upgrade runs the transformers registered for the block actor passed, returning its NBT in the format of the latest
+// version. Nil is returned if the block actor should not be sent to the latest version.
+func (r *BlockActorTransformers) Upgrade(data map[string]any) map[string]any {
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		if data == nil {
+			return nil
+		}
+		if e := r.entries[i]; e.appliesTo(data) {
+			data = e.transformer.UpgradeBlockActor(data)
+		}
+	}
+	return data
+}
+
+// appliesTo checks if the transformer of the entry applies to the block actor passed.
+func (e blockActorEntry) appliesTo(data map[string]any) bool {
+	if e.id == "" {
+		return true
+	}
+	id, _ := data["id"].(string)
+	return id == e.id
+}
+
+// DefaultBlockActorTransformers returns the transformers of all block actors of which the format changed since v486,
+// each registered for the protocol versions that lack the change. Use ForProtocol to get the transformers of a single
+// protocol version.
+func DefaultBlockActorTransformers() *BlockActorTransformers {
+	return NewBlockActorTransformers().
+		// Hanging signs and the back text of signs were added in 1.19.80 (v582).
+		With("HangingSign", 0, 581, BlockActorFuncs{Downgrade: renameBlockActor("Sign")}).
+		With("Sign", 0, 581, BlockActorFuncs{Downgrade: downgradeSignText, Upgrade: upgradeSignText}).
+		With("DecoratedPot", 0, 581, BlockActorFuncs{Downgrade: replaceBlockActor("FlowerPot")}).
+		With("ChiseledBookshelf", 0, 581, BlockActorFuncs{Downgrade: removeBlockActor}).
+		With("BrushableBlock", 0, 581, BlockActorFuncs{Downgrade: removeBlockActor}).
+		// Trial spawners were added in 1.20.60 (v649), vaults in 1.20.70 (v662).
+		With("TrialSpawner", 0, 630, BlockActorFuncs{Downgrade: replaceBlockActor("MobSpawner")}).
+		With("Vault", 0, 649, BlockActorFuncs{Downgrade: replaceBlockActor("MobSpawner")})
+}
+
+// signTextFields holds the fields of the text on a side of a sign, which are held by the sign itself in versions
+// where signs only have a front side.
+var signTextFields = []string{"Text", "TextOwner", "SignTextColor", "IgnoreLighting", "PersistFormatting", "HideGlowOutline"}
+
+// downgradeSignText moves the fields of the front text of a sign to the sign itself, for versions where signs only
+// have a front side.
+func downgradeSignText(data map[string]any) map[string]any {
+	front, _ := data["FrontText"].(map[string]any)
+	for _, k := range signTextFields {
+		if v, ok := front[k]; ok {
+			data[k] = v
+		}
+	}
+	if _, ok := data["Text"].(string); !ok {
+		data["Text"] = ""
+	}
+	delete(data, "FrontText")
+	delete(data, "BackText")
+	delete(data, "IsWaxed")
+	return data
+}
+
+// upgradeSignText moves the text fields of a sign without a back side to its front text, leaving the back text empty.
+func upgradeSignText(data map[string]any) map[string]any {
+	front := map[string]any{"Text": ""}
+	for _, k := range signTextFields {
+		if v, ok := data[k]; ok {
+			front[k] = v
+			delete(data, k)
+		}
+	}
+	data["FrontText"] = front
+	data["BackText"] = map[string]any{"Text": ""}
+	return data
+}
+
+// renameBlockActor returns a function changing the ID of a block actor to the ID passed, keeping the rest of its NBT.
+func renameBlockActor(id string) func(map[string]any) map[string]any {
+	return func(data map[string]any) map[string]any {
+		data["id"] = id
+		return data
+	}
+}
+
+// replaceBlockActor returns a function replacing a block actor with an empty block actor with the ID passed at the
+// same position.
+func replaceBlockActor(id string) func(map[string]any) map[string]any {
+	return func(data map[string]any) map[string]any {
+		replacement := map[string]any{"id": id}
+		for _, k := range []string{"x", "y", "z", "isMovable"} {
+			if v, ok := data[k]; ok {
+				replacement[k] = v
+			}
+		}
+		return replacement
+	}
+}
+
+// removeBlockActor removes a block actor that has no counterpart in the legacy version.
+func removeBlockActor(map[string]any) map[string]any {
+	return nil
+}
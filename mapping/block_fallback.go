@@ -0,0 +1,225 @@
+package mapping
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/df-mc/worldupgrader/blockupgrader"
+	"github.com/flonja/multiversion/internal"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// maxFallbackDepth is the maximum amount of fallbacks chained to find the nearest state, so that a cycle in the
+// rules can't hang the translation.
+const maxFallbackDepth = 4
+
+// BlockFallback is a rule that maps block states missing from a mapping to the nearest state that is present. A
+// rule may map an exact state, all states with a name matching a wildcard, or drop properties of the states it
+// applies to.
+type BlockFallback struct {
+	// Name is the name of the states the rule applies to. It may hold * wildcards, which match any amount of
+	// characters, such as in minecraft:*_copper_bulb.
+	Name string `json:"name"`
+	// Properties holds the properties a state must have for the rule to apply. The rule applies to every state with
+	// a matching name if it is empty.
+	Properties map[string]any `json:"properties,omitempty"`
+	// Drop holds the names of the properties removed from the state.
+	Drop []string `json:"drop,omitempty"`
+	// Target is the name of the state to use instead. Every * wildcard in it is replaced with the characters matched
+	// by the corresponding wildcard in Name. The name of the state is kept if Target is empty.
+	Target string `json:"target,omitempty"`
+	// TargetProperties holds the properties of the state to use instead. They are first tried on top of the
+	// properties of the original state, and then on their own, so that they should form a complete state if the
+	// target doesn't share the properties of the original.
+	TargetProperties map[string]any `json:"target_properties,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// match checks if the rule applies to the state passed, returning the characters matched by the wildcards in Name.
+func (f BlockFallback) match(state blockupgrader.BlockState) ([]string, bool) {
+	captures := f.pattern.FindStringSubmatch(state.Name)
+	if captures == nil {
+		return nil, false
+	}
+	for k, v := range f.Properties {
+		if state.Properties[k] != v {
+			return nil, false
+		}
+	}
+	return captures[1:], true
+}
+
+// candidates returns the states the rule maps the state passed to, in the order they should be tried in.
+func (f BlockFallback) candidates(state blockupgrader.BlockState, captures []string) []blockupgrader.BlockState {
+	name := state.Name
+	if f.Target != "" {
+		name = expandWildcards(f.Target, captures)
+	}
+	properties := make(map[string]any, len(state.Properties)+len(f.TargetProperties))
+	for k, v := range state.Properties {
+		properties[k] = v
+	}
+	for _, k := range f.Drop {
+		delete(properties, k)
+	}
+	for k, v := range f.TargetProperties {
+		properties[k] = v
+	}
+	candidates := []blockupgrader.BlockState{{Name: name, Properties: properties, Version: state.Version}}
+	if f.TargetProperties != nil {
+		target := make(map[string]any, len(f.TargetProperties))
+		for k, v := range f.TargetProperties {
+			target[k] = v
+		}
+		candidates = append(candidates, blockupgrader.BlockState{Name: name, Properties: target, Version: state.Version})
+	}
+	return candidates
+}
+
+// BlockFallbacks is a set of BlockFallback rules, used to find the nearest state present in a mapping for states
+// that are missing from it.
+type BlockFallbacks struct {
+	rules []BlockFallback
+	// nearest caches the nearest runtime ID found for a state in a mapping.
+	nearest *sync.Map
+}
+
+// nearestKey is the key of the nearest runtime ID of a state in a mapping.
+type nearestKey struct {
+	mapping Block
+	state   internal.StateHash
+}
+
+// nearestResult is the nearest runtime ID found for a state, if any.
+type nearestResult struct {
+	runtimeID uint32
+	ok        bool
+}
+
+// NewBlockFallbacks returns BlockFallbacks holding the rules passed.
+func NewBlockFallbacks(rules ...BlockFallback) *BlockFallbacks {
+	return (&BlockFallbacks{nearest: new(sync.Map)}).With(rules...)
+}
+
+// ParseBlockFallbacks parses BlockFallbacks from a JSON array of rules. Numbers in the properties of the rules are
+// parsed as int32 and booleans as uint8, like the properties of block states.
+func ParseBlockFallbacks(data []byte) (*BlockFallbacks, error) {
+	var rules []BlockFallback
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("decode block fallbacks: %w", err)
+	}
+	for i, rule := range rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("block fallback %v has no name", i)
+		}
+		var err error
+		if rules[i].Properties, err = normaliseProperties(rule.Properties); err != nil {
+			return nil, fmt.Errorf("block fallback %v: %w", rule.Name, err)
+		}
+		if rules[i].TargetProperties, err = normaliseProperties(rule.TargetProperties); err != nil {
+			return nil, fmt.Errorf("block fallback %v: %w", rule.Name, err)
+		}
+	}
+	return NewBlockFallbacks(rules...), nil
+}
+
+// With adds the rules passed to the BlockFallbacks. Rules are tried in the order they were added, so more specific
+// rules should be added before the rules they take precedence over. Rules should not be added once the BlockFallbacks
+// are in use.
+func (f *BlockFallbacks) With(rules ...BlockFallback) *BlockFallbacks {
+	for _, rule := range rules {
+		rule.pattern = compileWildcards(rule.Name)
+		f.rules = append(f.rules, rule)
+	}
+	f.nearest = new(sync.Map)
+	return f
+}
+
+// Rules returns the rules of the BlockFallbacks, in the order they are tried in.
+func (f *BlockFallbacks) Rules() []BlockFallback {
+	return append([]BlockFallback(nil), f.rules...)
+}
+
+// Nearest returns the runtime ID in the mapping passed of the state nearest to the state passed, according to the
+// rules. False is returned if none of the rules lead to a state present in the mapping.
+func (f *BlockFallbacks) Nearest(state blockupgrader.BlockState, m Block) (uint32, bool) {
+	key := nearestKey{mapping: m, state: internal.HashState(state)}
+	if result, ok := f.nearest.Load(key); ok {
+		return result.(nearestResult).runtimeID, result.(nearestResult).ok
+	}
+	rid, ok := f.find(state, m, 0)
+	f.nearest.Store(key, nearestResult{runtimeID: rid, ok: ok})
+	return rid, ok
+}
+
+// find applies the rules to the state passed, chaining them until a state present in the mapping is found.
+func (f *BlockFallbacks) find(state blockupgrader.BlockState, m Block, depth int) (uint32, bool) {
+	for _, rule := range f.rules {
+		captures, ok := rule.match(state)
+		if !ok {
+			continue
+		}
+		for _, candidate := range rule.candidates(state, captures) {
+			// Looking up a state upgrades it, which may change its properties in place.
+			if rid, ok := m.StateToRuntimeID(copyState(candidate)); ok {
+				return rid, true
+			}
+			if depth < maxFallbackDepth {
+				if rid, ok := f.find(candidate, m, depth+1); ok {
+					return rid, true
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
+// normaliseProperties converts the JSON values of the properties passed to the types used by block states.
+func normaliseProperties(properties map[string]any) (map[string]any, error) {
+	if properties == nil {
+		return nil, nil
+	}
+	normalised := make(map[string]any, len(properties))
+	for k, v := range properties {
+		switch v := v.(type) {
+		case bool:
+			if v {
+				normalised[k] = uint8(1)
+			} else {
+				normalised[k] = uint8(0)
+			}
+		case float64:
+			normalised[k] = int32(v)
+		case string:
+			normalised[k] = v
+		default:
+			return nil, fmt.Errorf("invalid type %T of property %v", v, k)
+		}
+	}
+	return normalised, nil
+}
+
+// compileWildcards compiles a name holding * wildcards to a regular expression capturing the characters matched by
+// every wildcard.
+func compileWildcards(name string) *regexp.Regexp {
+	parts := strings.Split(name, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, "(.*)") + "$")
+}
+
+// expandWildcards replaces every * wildcard in the target passed with the corresponding capture.
+func expandWildcards(target string, captures []string) string {
+	parts := strings.Split(target, "*")
+	var b strings.Builder
+	for i, part := range parts {
+		if i > 0 && i <= len(captures) {
+			b.WriteString(captures[i-1])
+		}
+		b.WriteString(part)
+	}
+	return b.String()
+}
@@ -0,0 +1,129 @@
+package mapping
+
+import (
+	"github.com/df-mc/worldupgrader/blockupgrader"
+	"github.com/df-mc/worldupgrader/itemupgrader"
+	"sort"
+	"strings"
+)
+
+// Coverage lists the blocks and items of the latest version that have no target in a legacy version, and are thus
+// translated to air or minecraft:info_update respectively.
+type Coverage struct {
+	// Blocks holds the block states without target, grouped by namespace.
+	Blocks []NamespaceCoverage `json:"blocks"`
+	// Items holds the items without target, grouped by namespace.
+	Items []NamespaceCoverage `json:"items"`
+}
+
+// NamespaceCoverage holds the blocks or items of a single namespace that have no target.
+type NamespaceCoverage struct {
+	// Namespace is the namespace of the entries, such as minecraft.
+	Namespace string `json:"namespace"`
+	// Entries holds the entries without target, sorted by name.
+	Entries []CoverageEntry `json:"entries"`
+}
+
+// CoverageEntry is a base block or item without target.
+type CoverageEntry struct {
+	// Name is the name of the block or item, without its namespace.
+	Name string `json:"name"`
+	// States holds the properties of the states of the block without target. It is empty for items.
+	States []map[string]any `json:"states,omitempty"`
+	// AllStates is true if none of the states of the block has a target, in which case the block is missing from the
+	// legacy version as a whole.
+	AllStates bool `json:"all_states,omitempty"`
+}
+
+// NewCoverage returns the Coverage of the legacy mappings passed, compared to the latest mappings passed. The
+// fallbacks passed may be nil.
+func NewCoverage(latestBlocks, legacyBlocks Block, blockFallbacks *BlockFallbacks, latestItems *DefaultItemMapping, legacyItems Item, itemFallbacks *ItemFallbacks) Coverage {
+	return Coverage{
+		Blocks: BlockCoverage(latestBlocks, legacyBlocks, blockFallbacks),
+		Items:  ItemCoverage(latestItems, legacyItems, itemFallbacks),
+	}
+}
+
+// BlockCoverage returns the block states of the latest mapping that have no target in the legacy mapping, grouped by
+// namespace and base block. States that the fallbacks passed find a nearest state for are not listed. The fallbacks
+// may be nil.
+func BlockCoverage(latest, legacy Block, fallbacks *BlockFallbacks) []NamespaceCoverage {
+	missing := make(map[string][]map[string]any)
+	total := make(map[string]int)
+	for rid := uint32(0); ; rid++ {
+		state, ok := latest.RuntimeIDToState(rid)
+		if !ok {
+			break
+		}
+		total[state.Name]++
+		if _, ok := legacy.StateToRuntimeID(copyState(state)); ok {
+			continue
+		}
+		if fallbacks != nil {
+			if _, ok := fallbacks.Nearest(state, legacy); ok {
+				continue
+			}
+		}
+		missing[state.Name] = append(missing[state.Name], state.Properties)
+	}
+
+	entries := make(map[string][]CoverageEntry)
+	for name, states := range missing {
+		namespace, base := splitName(name)
+		entries[namespace] = append(entries[namespace], CoverageEntry{Name: base, States: states, AllStates: len(states) == total[name]})
+	}
+	return groupCoverage(entries)
+}
+
+// ItemCoverage returns the items of the latest mapping that have no target in the legacy mapping, grouped by
+// namespace. Items that the fallbacks passed find a nearest item for are not listed. The fallbacks may be nil.
+func ItemCoverage(latest *DefaultItemMapping, legacy Item, fallbacks *ItemFallbacks) []NamespaceCoverage {
+	entries := make(map[string][]CoverageEntry)
+	for _, rid := range latest.RuntimeIDs() {
+		meta, _ := latest.ItemRuntimeIDToName(rid)
+		if _, ok := legacy.ItemNameToRuntimeID(itemupgrader.Upgrade(meta)); ok {
+			continue
+		}
+		if fallbacks != nil {
+			if _, _, ok := fallbacks.Nearest(meta.Name, legacy); ok {
+				continue
+			}
+		}
+		namespace, base := splitName(meta.Name)
+		entries[namespace] = append(entries[namespace], CoverageEntry{Name: base})
+	}
+	return groupCoverage(entries)
+}
+
+// groupCoverage sorts the entries passed by namespace and name.
+func groupCoverage(entries map[string][]CoverageEntry) []NamespaceCoverage {
+	groups := make([]NamespaceCoverage, 0, len(entries))
+	for namespace, e := range entries {
+		sort.Slice(e, func(i, j int) bool {
+			return e[i].Name < e[j].Name
+		})
+		groups = append(groups, NamespaceCoverage{Namespace: namespace, Entries: e})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Namespace < groups[j].Namespace
+	})
+	return groups
+}
+
+// splitName splits the namespaced name passed into its namespace and base name.
+func splitName(name string) (namespace, base string) {
+	if namespace, base, ok := strings.Cut(name, ":"); ok {
+		return namespace, base
+	}
+	return "minecraft", name
+}
+
+// copyState returns a copy of the state passed, as upgrading a state may change its properties in place.
+func copyState(state blockupgrader.BlockState) blockupgrader.BlockState {
+	properties := make(map[string]any, len(state.Properties))
+	for k, v := range state.Properties {
+		properties[k] = v
+	}
+	state.Properties = properties
+	return state
+}
@@ -0,0 +1,51 @@
+package mapping
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+)
+
+type Entity interface {
+	// EntityIdentifiers lists the identifiers of all vanilla entities.
+	EntityIdentifiers() []string
+	// KnownEntity returns true if the entity identifier is a vanilla entity.
+	KnownEntity(string) bool
+}
+
+type DefaultEntityMapping struct {
+	// identifiers holds a list of all vanilla entity identifiers.
+	identifiers []string
+	// known holds a set of all vanilla entity identifiers, for quick lookups.
+	known map[string]struct{}
+}
+
+func NewEntityMapping(raw []byte) *DefaultEntityMapping {
+	var data struct {
+		IDList []struct {
+			ID string `nbt:"id"`
+		} `nbt:"idlist"`
+	}
+	if err := nbt.Unmarshal(raw, &data); err != nil {
+		panic(err)
+	}
+
+	identifiers := make([]string, 0, len(data.IDList))
+	known := make(map[string]struct{}, len(data.IDList))
+	for _, entry := range data.IDList {
+		identifiers = append(identifiers, entry.ID)
+		known[entry.ID] = struct{}{}
+	}
+	if _, ok := known["minecraft:player"]; !ok {
+		panic("couldn't find player")
+	}
+
+	return &DefaultEntityMapping{identifiers: identifiers, known: known}
+}
+
+func (m *DefaultEntityMapping) EntityIdentifiers() []string {
+	return m.identifiers
+}
+
+func (m *DefaultEntityMapping) KnownEntity(identifier string) bool {
+	_, ok := m.known[identifier]
+	return ok
+}
@@ -0,0 +1,94 @@
+package mapping
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// EntityFlags describes the entity flags of a legacy version compared to the entity flags of the latest version. The
+// flags of an entity are spread over the EntityDataKeyFlags and EntityDataKeyFlagsTwo metadata, holding the first and
+// last 64 flags respectively.
+type EntityFlags struct {
+	// latestToLegacy holds a map to translate latest flag bits to legacy flag bits.
+	latestToLegacy map[uint8]uint8
+	// legacyToLatest holds a map to translate legacy flag bits to latest flag bits.
+	legacyToLatest map[uint8]uint8
+	// substitutes holds the latest flags that are used for latest flags unknown to the legacy version.
+	substitutes map[uint8]uint8
+}
+
+// NewEntityFlags creates an EntityFlags from the flags of a legacy version. The index of a flag in the slice is the
+// bit of the flag in the legacy version, while the value is the bit of the same flag in the latest version.
+func NewEntityFlags(flags []uint8) *EntityFlags {
+	latestToLegacy := make(map[uint8]uint8, len(flags))
+	legacyToLatest := make(map[uint8]uint8, len(flags))
+	for legacy, latest := range flags {
+		latestToLegacy[latest] = uint8(legacy)
+		legacyToLatest[uint8(legacy)] = latest
+	}
+	return &EntityFlags{latestToLegacy: latestToLegacy, legacyToLatest: legacyToLatest, substitutes: make(map[uint8]uint8)}
+}
+
+// WithSubstitute makes the latest flag passed show up as the substitute flag for the legacy version, if the flag is
+// unknown to it. The substitute must be a latest flag known to the legacy version.
+func (f *EntityFlags) WithSubstitute(flag, substitute uint8) *EntityFlags {
+	f.substitutes[flag] = substitute
+	return f
+}
+
+// Downgrade downgrades the flags in the latest entity metadata passed to legacy flags.
+func (f *EntityFlags) Downgrade(metadata map[uint32]any) {
+	f.remap(metadata, func(flag uint8) (uint8, bool) {
+		if legacy, ok := f.latestToLegacy[flag]; ok {
+			return legacy, true
+		}
+		if substitute, ok := f.substitutes[flag]; ok {
+			legacy, ok := f.latestToLegacy[substitute]
+			return legacy, ok
+		}
+		return 0, false
+	})
+}
+
+// Upgrade upgrades the flags in the legacy entity metadata passed to latest flags.
+func (f *EntityFlags) Upgrade(metadata map[uint32]any) {
+	f.remap(metadata, func(flag uint8) (uint8, bool) {
+		latest, ok := f.legacyToLatest[flag]
+		return latest, ok
+	})
+}
+
+// remap moves every flag set in the metadata to the bit returned by the function passed. Flags for which false is
+// returned are cleared.
+func (f *EntityFlags) remap(metadata map[uint32]any, remap func(flag uint8) (uint8, bool)) {
+	flags, ok := metadata[protocol.EntityDataKeyFlags].(int64)
+	flagsTwo, okTwo := metadata[protocol.EntityDataKeyFlagsTwo].(int64)
+	if !ok && !okTwo {
+		return
+	}
+
+	var newFlags, newFlagsTwo int64
+	for i := 0; i < 128; i++ {
+		set := flags&(1<<i) != 0
+		if i >= 64 {
+			set = flagsTwo&(1<<(i-64)) != 0
+		}
+		if !set {
+			continue
+		}
+		bit, found := remap(uint8(i))
+		if !found {
+			continue
+		}
+		if bit >= 64 {
+			newFlagsTwo |= 1 << (bit - 64)
+		} else {
+			newFlags |= 1 << bit
+		}
+	}
+	if ok || newFlags != 0 {
+		metadata[protocol.EntityDataKeyFlags] = newFlags
+	}
+	if okTwo || newFlagsTwo != 0 {
+		metadata[protocol.EntityDataKeyFlagsTwo] = newFlagsTwo
+	}
+}
@@ -3,6 +3,9 @@ package mapping
 import (
 	"github.com/df-mc/worldupgrader/itemupgrader"
 	"github.com/sandertv/gophertunnel/minecraft/nbt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"golang.org/x/exp/slices"
+	"sync"
 )
 
 type Item interface {
@@ -10,7 +13,16 @@ type Item interface {
 	ItemRuntimeIDToName(int32) (itemupgrader.ItemMeta, bool)
 	// ItemNameToRuntimeID converts a string ID to an item runtime ID.
 	ItemNameToRuntimeID(itemupgrader.ItemMeta) (int32, bool)
+	// RegisterEntry registers an item with the name passed and returns its runtime ID. Registering a name that was
+	// registered before returns the runtime ID it was registered with.
 	RegisterEntry(string) int32
+	// RegisterEntryAt works like RegisterEntry, but registers the item with the runtime ID passed if it is not
+	// taken yet, so that the runtime ID doesn't depend on the order items are registered in.
+	RegisterEntryAt(string, int32) int32
+	// Adjust returns a mapping holding the items of the mapping and the custom items passed, which are registered
+	// with their own runtime ID if it is not taken yet. The mapping itself is never changed, so that it may be shared
+	// between connections.
+	Adjust([]protocol.ItemEntry) Item
 	Air() int32
 }
 
@@ -20,12 +32,17 @@ type DefaultItemMapping struct {
 	// itemNamesToRuntimeIDs holds a map to translate item string IDs to runtime IDs.
 	itemNamesToRuntimeIDs map[itemupgrader.ItemMeta]int32
 	airRID                int32
+	// nextRID is the runtime ID given to the next item registered without a runtime ID of its own.
+	nextRID int32
+	// mu guards the maps above, as items may be registered while other connections translate items.
+	mu sync.RWMutex
 }
 
 func NewItemMapping(raw []byte) *DefaultItemMapping {
 	itemRuntimeIDsToNames := make(map[int32]itemupgrader.ItemMeta)
 	itemNamesToRuntimeIDs := make(map[itemupgrader.ItemMeta]int32)
 	var airRID *int32
+	var nextRID int32
 
 	var items map[string]int32
 	if err := nbt.Unmarshal(raw, &items); err != nil {
@@ -39,21 +56,26 @@ func NewItemMapping(raw []byte) *DefaultItemMapping {
 		itemMeta := itemupgrader.Upgrade(itemupgrader.ItemMeta{Name: name})
 		itemNamesToRuntimeIDs[itemMeta] = rid
 		itemRuntimeIDsToNames[rid] = itemMeta
+		nextRID = max(nextRID, rid+1)
 	}
 	if airRID == nil {
 		panic("couldn't find air")
 	}
 
-	return &DefaultItemMapping{itemRuntimeIDsToNames: itemRuntimeIDsToNames, itemNamesToRuntimeIDs: itemNamesToRuntimeIDs}
+	return &DefaultItemMapping{itemRuntimeIDsToNames: itemRuntimeIDsToNames, itemNamesToRuntimeIDs: itemNamesToRuntimeIDs, nextRID: nextRID}
 }
 
 func (m *DefaultItemMapping) ItemRuntimeIDToName(runtimeID int32) (itemMeta itemupgrader.ItemMeta, found bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	itemMeta, ok := m.itemRuntimeIDsToNames[runtimeID]
 	return itemMeta, ok
 }
 
 func (m *DefaultItemMapping) ItemNameToRuntimeID(itemMeta itemupgrader.ItemMeta) (runtimeID int32, found bool) {
 	itemMeta = itemupgrader.Upgrade(itemMeta)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	rid, ok := m.itemNamesToRuntimeIDs[itemMeta]
 	if !ok {
 		itemMeta.Meta = 0
@@ -62,14 +84,146 @@ func (m *DefaultItemMapping) ItemNameToRuntimeID(itemMeta itemupgrader.ItemMeta)
 	return rid, ok
 }
 
+// RuntimeIDs returns the runtime IDs of all items in the mapping, sorted in ascending order.
+func (m *DefaultItemMapping) RuntimeIDs() []int32 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	runtimeIDs := make([]int32, 0, len(m.itemRuntimeIDsToNames))
+	for rid := range m.itemRuntimeIDsToNames {
+		runtimeIDs = append(runtimeIDs, rid)
+	}
+	slices.Sort(runtimeIDs)
+	return runtimeIDs
+}
+
 func (m *DefaultItemMapping) RegisterEntry(name string) int32 {
-	nextRID := int32(len(m.itemRuntimeIDsToNames))
+	return m.RegisterEntryAt(name, -1)
+}
+
+func (m *DefaultItemMapping) RegisterEntryAt(name string, runtimeID int32) int32 {
 	itemMeta := itemupgrader.Upgrade(itemupgrader.ItemMeta{Name: name})
-	m.itemNamesToRuntimeIDs[itemMeta] = nextRID
-	m.itemRuntimeIDsToNames[nextRID] = itemMeta
-	return nextRID
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rid, ok := m.itemNamesToRuntimeIDs[itemMeta]; ok {
+		return rid
+	}
+	if _, taken := m.itemRuntimeIDsToNames[runtimeID]; taken || runtimeID < 0 {
+		runtimeID = m.nextRID
+	}
+	m.nextRID = max(m.nextRID, runtimeID+1)
+	m.itemNamesToRuntimeIDs[itemMeta] = runtimeID
+	m.itemRuntimeIDsToNames[runtimeID] = itemMeta
+	return runtimeID
 }
 
 func (m *DefaultItemMapping) Air() int32 {
 	return m.airRID
 }
+
+func (m *DefaultItemMapping) Adjust(entries []protocol.ItemEntry) Item {
+	if len(entries) == 0 {
+		return m
+	}
+	adjusted := &adjustedItemMapping{
+		base:                  m,
+		itemRuntimeIDsToNames: make(map[int32]itemupgrader.ItemMeta, len(entries)),
+		itemNamesToRuntimeIDs: make(map[itemupgrader.ItemMeta]int32, len(entries)),
+	}
+	for _, entry := range entries {
+		adjusted.RegisterEntryAt(entry.Name, int32(entry.RuntimeID))
+	}
+	return adjusted
+}
+
+// adjustedItemMapping is a mapping returned by DefaultItemMapping.Adjust. It holds the custom items of a connection on
+// top of the items of the mapping it was adjusted from, which is shared between connections.
+type adjustedItemMapping struct {
+	base *DefaultItemMapping
+	// itemRuntimeIDsToNames holds a map to translate the runtime IDs of the custom items to string IDs.
+	itemRuntimeIDsToNames map[int32]itemupgrader.ItemMeta
+	// itemNamesToRuntimeIDs holds a map to translate the string IDs of the custom items to runtime IDs.
+	itemNamesToRuntimeIDs map[itemupgrader.ItemMeta]int32
+	// nextRID is the runtime ID given to the next custom item registered without a runtime ID of its own, unless the
+	// base mapping has items with higher runtime IDs.
+	nextRID int32
+	mu      sync.RWMutex
+}
+
+func (m *adjustedItemMapping) ItemRuntimeIDToName(runtimeID int32) (itemupgrader.ItemMeta, bool) {
+	m.mu.RLock()
+	itemMeta, ok := m.itemRuntimeIDsToNames[runtimeID]
+	m.mu.RUnlock()
+	if ok {
+		return itemMeta, true
+	}
+	return m.base.ItemRuntimeIDToName(runtimeID)
+}
+
+func (m *adjustedItemMapping) ItemNameToRuntimeID(itemMeta itemupgrader.ItemMeta) (int32, bool) {
+	upgraded := itemupgrader.Upgrade(itemMeta)
+	m.mu.RLock()
+	rid, ok := m.itemNamesToRuntimeIDs[upgraded]
+	if !ok {
+		rid, ok = m.itemNamesToRuntimeIDs[itemupgrader.ItemMeta{Name: upgraded.Name}]
+	}
+	m.mu.RUnlock()
+	if ok {
+		return rid, true
+	}
+	return m.base.ItemNameToRuntimeID(itemMeta)
+}
+
+func (m *adjustedItemMapping) RegisterEntry(name string) int32 {
+	return m.RegisterEntryAt(name, -1)
+}
+
+func (m *adjustedItemMapping) RegisterEntryAt(name string, runtimeID int32) int32 {
+	itemMeta := itemupgrader.Upgrade(itemupgrader.ItemMeta{Name: name})
+	if rid, ok := m.base.ItemNameToRuntimeID(itemMeta); ok {
+		return rid
+	}
+	_, takenByBase := m.base.ItemRuntimeIDToName(runtimeID)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rid, ok := m.itemNamesToRuntimeIDs[itemMeta]; ok {
+		return rid
+	}
+	m.base.mu.RLock()
+	m.nextRID = max(m.nextRID, m.base.nextRID)
+	m.base.mu.RUnlock()
+	if _, taken := m.itemRuntimeIDsToNames[runtimeID]; taken || takenByBase || runtimeID < 0 {
+		runtimeID = m.nextRID
+	}
+	m.nextRID = max(m.nextRID, runtimeID+1)
+	m.itemNamesToRuntimeIDs[itemMeta] = runtimeID
+	m.itemRuntimeIDsToNames[runtimeID] = itemMeta
+	return runtimeID
+}
+
+func (m *adjustedItemMapping) Adjust(entries []protocol.ItemEntry) Item {
+	if len(entries) == 0 {
+		return m
+	}
+	m.mu.RLock()
+	adjusted := &adjustedItemMapping{
+		base:                  m.base,
+		itemRuntimeIDsToNames: make(map[int32]itemupgrader.ItemMeta, len(m.itemRuntimeIDsToNames)+len(entries)),
+		itemNamesToRuntimeIDs: make(map[itemupgrader.ItemMeta]int32, len(m.itemNamesToRuntimeIDs)+len(entries)),
+		nextRID:               m.nextRID,
+	}
+	for rid, itemMeta := range m.itemRuntimeIDsToNames {
+		adjusted.itemRuntimeIDsToNames[rid] = itemMeta
+		adjusted.itemNamesToRuntimeIDs[itemMeta] = rid
+	}
+	m.mu.RUnlock()
+	for _, entry := range entries {
+		adjusted.RegisterEntryAt(entry.Name, int32(entry.RuntimeID))
+	}
+	return adjusted
+}
+
+func (m *adjustedItemMapping) Air() int32 {
+	return m.base.Air()
+}
@@ -0,0 +1,120 @@
+package mapping
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/df-mc/worldupgrader/itemupgrader"
+	"regexp"
+	"sync"
+)
+
+// ItemFallback is a rule that maps items missing from a mapping to the nearest item that is present, such as a mace
+// to an iron sword.
+type ItemFallback struct {
+	// Name is the name of the items the rule applies to. It may hold * wildcards, which match any amount of
+	// characters, such as in minecraft:*_pottery_sherd.
+	Name string `json:"name"`
+	// Target is the name of the item to use instead. Every * wildcard in it is replaced with the characters matched
+	// by the corresponding wildcard in Name.
+	Target string `json:"target"`
+	// Meta is the metadata value of the item to use instead.
+	Meta int16 `json:"meta,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// ItemFallbacks is a set of ItemFallback rules, used to find the nearest item present in a mapping for items that
+// are missing from it.
+type ItemFallbacks struct {
+	rules []ItemFallback
+	// nearest caches the nearest item found for an item in a mapping.
+	nearest *sync.Map
+}
+
+// nearestItemKey is the key of the nearest item of an item in a mapping.
+type nearestItemKey struct {
+	mapping Item
+	name    string
+}
+
+// nearestItemResult is the nearest item found for an item, if any.
+type nearestItemResult struct {
+	meta      itemupgrader.ItemMeta
+	runtimeID int32
+	ok        bool
+}
+
+// NewItemFallbacks returns ItemFallbacks holding the rules passed.
+func NewItemFallbacks(rules ...ItemFallback) *ItemFallbacks {
+	return (&ItemFallbacks{nearest: new(sync.Map)}).With(rules...)
+}
+
+// ParseItemFallbacks parses ItemFallbacks from a JSON array of rules.
+func ParseItemFallbacks(data []byte) (*ItemFallbacks, error) {
+	var rules []ItemFallback
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("decode item fallbacks: %w", err)
+	}
+	for i, rule := range rules {
+		if rule.Name == "" || rule.Target == "" {
+			return nil, fmt.Errorf("item fallback %v has no name or target", i)
+		}
+	}
+	return NewItemFallbacks(rules...), nil
+}
+
+// With adds the rules passed to the ItemFallbacks. Rules are tried in the order they were added, so more specific
+// rules should be added before the rules they take precedence over. Rules should not be added once the ItemFallbacks
+// are in use.
+func (f *ItemFallbacks) With(rules ...ItemFallback) *ItemFallbacks {
+	for _, rule := range rules {
+		rule.pattern = compileWildcards(rule.Name)
+		f.rules = append(f.rules, rule)
+	}
+	f.nearest = new(sync.Map)
+	return f
+}
+
+// Rules returns the rules of the ItemFallbacks, in the order they are tried in.
+func (f *ItemFallbacks) Rules() []ItemFallback {
+	return append([]ItemFallback(nil), f.rules...)
+}
+
+// Nearest returns the item and its runtime ID in the mapping passed nearest to the item with the name passed,
+// according to the rules. False is returned if none of the rules lead to an item present in the mapping.
+func (f *ItemFallbacks) Nearest(name string, m Item) (itemupgrader.ItemMeta, int32, bool) {
+	if adjusted, ok := m.(*adjustedItemMapping); ok {
+		// Items fall back to vanilla items, so the mapping adjusted for the custom items of a connection is not
+		// cached, but the mapping it was adjusted from, which is shared between connections.
+		m = adjusted.base
+	}
+	key := nearestItemKey{mapping: m, name: name}
+	if result, ok := f.nearest.Load(key); ok {
+		r := result.(nearestItemResult)
+		return r.meta, r.runtimeID, r.ok
+	}
+	meta, rid, ok := f.find(name, m, 0)
+	f.nearest.Store(key, nearestItemResult{meta: meta, runtimeID: rid, ok: ok})
+	return meta, rid, ok
+}
+
+// find applies the rules to the item with the name passed, chaining them until an item present in the mapping is
+// found.
+func (f *ItemFallbacks) find(name string, m Item, depth int) (itemupgrader.ItemMeta, int32, bool) {
+	for _, rule := range f.rules {
+		captures := rule.pattern.FindStringSubmatch(name)
+		if captures == nil {
+			continue
+		}
+		target := itemupgrader.ItemMeta{Name: expandWildcards(rule.Target, captures[1:]), Meta: rule.Meta}
+		if rid, ok := m.ItemNameToRuntimeID(target); ok {
+			return target, rid, true
+		}
+		if depth < maxFallbackDepth {
+			if meta, rid, ok := f.find(target.Name, m, depth+1); ok {
+				return meta, rid, true
+			}
+		}
+	}
+	return itemupgrader.ItemMeta{}, 0, false
+}
@@ -0,0 +1,163 @@
+package mapping
+
+import (
+	"golang.org/x/exp/slices"
+	"strings"
+)
+
+type LevelEvent interface {
+	// DowngradeLevelEvent returns the legacy level event sent instead of the latest level event passed. False is
+	// returned if the level event should not be sent at all.
+	DowngradeLevelEvent(int32) (int32, bool)
+	// UpgradeLevelEvent returns the latest level event of the legacy level event passed.
+	UpgradeLevelEvent(int32) int32
+	// DowngradeParticle returns the legacy particle shown instead of the latest particle passed. False is returned
+	// if the particle should not be shown at all.
+	DowngradeParticle(int32) (int32, bool)
+	// UpgradeParticle returns the latest particle of the legacy particle passed.
+	UpgradeParticle(int32) int32
+	// DowngradeParticleName returns the name of the legacy particle effect shown instead of the latest particle
+	// effect name passed. False is returned if the particle effect should not be shown at all.
+	DowngradeParticleName(string) (string, bool)
+}
+
+type DefaultLevelEventMapping struct {
+	// missingEvents holds the latest level events unknown to the legacy version.
+	missingEvents map[int32]struct{}
+	// eventSubstitutes holds the latest level events that are sent instead of latest level events unknown to the
+	// legacy version.
+	eventSubstitutes map[int32]int32
+	// missingParticles holds the sorted latest particles unknown to the legacy version. Every latest particle that
+	// follows a missing particle is shifted down by one in the legacy version.
+	missingParticles []int32
+	// particleSubstitutes holds the latest particles that are shown instead of latest particles unknown to the
+	// legacy version.
+	particleSubstitutes map[int32]int32
+	// particleNames holds the names of the particle effects shown instead of particle effect names missing from the
+	// resource packs of the legacy version. An empty name means the particle effect is not shown at all.
+	particleNames map[string]string
+	// particleNamePrefixes holds the same as particleNames, but for every particle effect name starting with the
+	// prefix.
+	particleNamePrefixes map[string]string
+}
+
+// NewLevelEventMapping creates a level event mapping for a legacy version that knows all level events and particles
+// of the latest version. The level events and particles unknown to the legacy version should be registered using
+// the With methods.
+func NewLevelEventMapping() *DefaultLevelEventMapping {
+	return &DefaultLevelEventMapping{
+		missingEvents:        make(map[int32]struct{}),
+		eventSubstitutes:     make(map[int32]int32),
+		particleSubstitutes:  make(map[int32]int32),
+		particleNames:        make(map[string]string),
+		particleNamePrefixes: make(map[string]string),
+	}
+}
+
+// WithMissingLevelEvents marks the latest level events passed as unknown to the legacy version. Unless a
+// substitute is registered, these level events are not sent.
+func (m *DefaultLevelEventMapping) WithMissingLevelEvents(events ...int32) *DefaultLevelEventMapping {
+	for _, event := range events {
+		m.missingEvents[event] = struct{}{}
+	}
+	return m
+}
+
+// WithLevelEventSubstitute makes the latest level event passed show up as the substitute level event for the legacy
+// version, if the level event is unknown to it.
+func (m *DefaultLevelEventMapping) WithLevelEventSubstitute(event, substitute int32) *DefaultLevelEventMapping {
+	m.eventSubstitutes[event] = substitute
+	return m
+}
+
+// WithMissingParticles marks the latest particles passed as unknown to the legacy version. The particles that follow
+// a missing particle are shifted, so that they show up as the same particle for the legacy version. Unless a
+// substitute is registered, the missing particles are not shown.
+func (m *DefaultLevelEventMapping) WithMissingParticles(particles ...int32) *DefaultLevelEventMapping {
+	for _, particle := range particles {
+		if !slices.Contains(m.missingParticles, particle) {
+			m.missingParticles = append(m.missingParticles, particle)
+		}
+	}
+	slices.Sort(m.missingParticles)
+	return m
+}
+
+// WithParticleSubstitute makes the latest particle passed show up as the substitute particle for the legacy version,
+// if the particle is unknown to it. The substitute is a latest particle too.
+func (m *DefaultLevelEventMapping) WithParticleSubstitute(particle, substitute int32) *DefaultLevelEventMapping {
+	m.particleSubstitutes[particle] = substitute
+	return m
+}
+
+// WithParticleName makes the legacy version show the substitute particle effect instead of the particle effect name
+// passed. If the substitute is empty, the particle effect is not shown at all.
+func (m *DefaultLevelEventMapping) WithParticleName(name, substitute string) *DefaultLevelEventMapping {
+	m.particleNames[name] = substitute
+	return m
+}
+
+// WithParticleNamePrefix works like WithParticleName, but for every particle effect name starting with the prefix
+// passed, such as "minecraft:trial_spawner".
+func (m *DefaultLevelEventMapping) WithParticleNamePrefix(prefix, substitute string) *DefaultLevelEventMapping {
+	m.particleNamePrefixes[prefix] = substitute
+	return m
+}
+
+func (m *DefaultLevelEventMapping) DowngradeLevelEvent(event int32) (int32, bool) {
+	// Limit the amount of lookups, so that a cycle in the substitutes can't hang the translation.
+	for i := 0; i <= len(m.eventSubstitutes); i++ {
+		if _, ok := m.missingEvents[event]; !ok {
+			return event, true
+		}
+		substitute, ok := m.eventSubstitutes[event]
+		if !ok {
+			break
+		}
+		event = substitute
+	}
+	return 0, false
+}
+
+func (m *DefaultLevelEventMapping) UpgradeLevelEvent(event int32) int32 {
+	return event
+}
+
+func (m *DefaultLevelEventMapping) DowngradeParticle(particle int32) (int32, bool) {
+	// Limit the amount of lookups, so that a cycle in the substitutes can't hang the translation.
+	for i := 0; i <= len(m.particleSubstitutes); i++ {
+		// The amount of missing particles before the particle is the amount the particle is shifted by.
+		shift, missing := slices.BinarySearch(m.missingParticles, particle)
+		if !missing {
+			return particle - int32(shift), true
+		}
+		substitute, ok := m.particleSubstitutes[particle]
+		if !ok {
+			break
+		}
+		particle = substitute
+	}
+	return 0, false
+}
+
+func (m *DefaultLevelEventMapping) UpgradeParticle(particle int32) int32 {
+	for _, missing := range m.missingParticles {
+		if missing > particle {
+			break
+		}
+		particle++
+	}
+	return particle
+}
+
+func (m *DefaultLevelEventMapping) DowngradeParticleName(name string) (string, bool) {
+	if substitute, ok := m.particleNames[name]; ok {
+		return substitute, substitute != ""
+	}
+	for prefix, substitute := range m.particleNamePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return substitute, substitute != ""
+		}
+	}
+	return name, true
+}
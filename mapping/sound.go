@@ -0,0 +1,118 @@
+package mapping
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"strings"
+)
+
+type Sound interface {
+	// DowngradeSound returns the legacy sound played instead of the latest sound passed. False is returned if the
+	// sound should not be played at all.
+	DowngradeSound(uint32) (uint32, bool)
+	// DowngradeSoundName returns the name of the legacy sound played instead of the latest sound name passed. False is
+	// returned if the sound should not be played at all.
+	DowngradeSoundName(string) (string, bool)
+}
+
+// defaultSoundSubstitutes holds the substitutes of latest sounds that sound alike to a sound that was added earlier.
+var defaultSoundSubstitutes = map[uint32]uint32{
+	packet.SoundEventPigDeath:            packet.SoundEventDeath,
+	packet.SoundEventConvertHuskToZombie: packet.SoundEventConvertToDrowned,
+	packet.SoundEventBottleFill:          packet.SoundEventBucketFillWater,
+	packet.SoundEventBottleEmpty:         packet.SoundEventBucketEmptyWater,
+	packet.SoundEventCrafterCraft:        packet.SoundEventBlockClick,
+	packet.SoundEventCrafterFail:         packet.SoundEventBlockClickFail,
+	packet.SoundEventCrafterDisableSlot:  packet.SoundEventButtonClickOff,
+	packet.SoundEventDecoratedPotInsert:  packet.SoundEventInsert,
+	packet.SoundEventCopperBulbTurnOn:    packet.SoundEventButtonClickOn,
+	packet.SoundEventCopperBulbTurnOff:   packet.SoundEventButtonClickOff,
+}
+
+type DefaultSoundMapping struct {
+	// count is the amount of sounds known to the legacy version. Sounds are only ever appended, so every latest sound
+	// below the count is the same sound in the legacy version.
+	count uint32
+	// substitutes holds the latest sounds that are played instead of latest sounds unknown to the legacy version.
+	substitutes map[uint32]uint32
+	// dropped holds the latest sounds that are never played, even if the legacy version knows them.
+	dropped map[uint32]struct{}
+	// names holds the names of the sounds played instead of sound names missing from the resource packs of the
+	// legacy version. An empty name means the sound is not played at all.
+	names map[string]string
+	// prefixes holds the same as names, but for every sound name starting with the prefix.
+	prefixes map[string]string
+}
+
+// NewSoundMapping creates a sound mapping for a legacy version that knows the first count sounds of the latest
+// version. Sounds unknown to the legacy version are substituted with a similar sound where possible, and are not
+// played otherwise.
+func NewSoundMapping(count uint32) *DefaultSoundMapping {
+	substitutes := make(map[uint32]uint32, len(defaultSoundSubstitutes))
+	for sound, substitute := range defaultSoundSubstitutes {
+		substitutes[sound] = substitute
+	}
+	return &DefaultSoundMapping{
+		count:       count,
+		substitutes: substitutes,
+		dropped:     make(map[uint32]struct{}),
+		names:       make(map[string]string),
+		prefixes:    make(map[string]string),
+	}
+}
+
+// WithSubstitute makes the latest sound passed play the substitute sound for the legacy version, if the sound is
+// unknown to it. Substitutes may be chained, as long as the last sound is known to the legacy version.
+func (m *DefaultSoundMapping) WithSubstitute(sound, substitute uint32) *DefaultSoundMapping {
+	m.substitutes[sound] = substitute
+	return m
+}
+
+// WithDropped makes the latest sound passed never play for the legacy version.
+func (m *DefaultSoundMapping) WithDropped(sound uint32) *DefaultSoundMapping {
+	m.dropped[sound] = struct{}{}
+	return m
+}
+
+// WithSoundName makes the legacy version play the substitute sound name instead of the sound name passed. If the
+// substitute is empty, the sound is not played at all.
+func (m *DefaultSoundMapping) WithSoundName(name, substitute string) *DefaultSoundMapping {
+	m.names[name] = substitute
+	return m
+}
+
+// WithSoundNamePrefix works like WithSoundName, but for every sound name starting with the prefix passed, such as
+// "trial_spawner.".
+func (m *DefaultSoundMapping) WithSoundNamePrefix(prefix, substitute string) *DefaultSoundMapping {
+	m.prefixes[prefix] = substitute
+	return m
+}
+
+func (m *DefaultSoundMapping) DowngradeSound(sound uint32) (uint32, bool) {
+	// Limit the amount of lookups, so that a cycle in the substitutes can't hang the translation.
+	for i := 0; i <= len(m.substitutes); i++ {
+		if _, ok := m.dropped[sound]; ok {
+			return 0, false
+		}
+		if sound < m.count {
+			return sound, true
+		}
+		substitute, ok := m.substitutes[sound]
+		if !ok {
+			break
+		}
+		sound = substitute
+	}
+	return 0, false
+}
+
+func (m *DefaultSoundMapping) DowngradeSoundName(name string) (string, bool) {
+	if substitute, ok := m.names[name]; ok {
+		return substitute, substitute != ""
+	}
+	for prefix, substitute := range m.prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return substitute, substitute != ""
+		}
+	}
+	return name, true
+}
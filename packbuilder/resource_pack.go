@@ -10,7 +10,7 @@ import (
 // BuildResourcePack builds a resource pack based on custom features that have been registered to the server.
 // It creates a UUID based on the hash of the directory so the client will only be prompted to download it
 // once it is changed.
-func BuildResourcePack(customItems []world.CustomItem, version string) (*resource.Pack, bool) {
+func BuildResourcePack(customItems []world.CustomItem, customBlocks []world.CustomBlock, version string) (*resource.Pack, bool) {
 	dir, err := os.MkdirTemp("", "dragonfly_resource_pack-")
 	if err != nil {
 		panic(err)
@@ -24,6 +24,10 @@ func BuildResourcePack(customItems []world.CustomItem, version string) (*resourc
 	assets += itemCount
 	lang = append(lang, itemLang...)
 
+	blockCount, blockLang := buildBlocks(dir, customBlocks)
+	assets += blockCount
+	lang = append(lang, blockLang...)
+
 	if assets > 0 {
 		buildLanguageFile(dir, lang)
 		hash, err := dirhash.HashDir(dir, "", dirhash.Hash1)
@@ -0,0 +1,318 @@
+package chain
+
+import (
+	"github.com/flonja/multiversion/mapping"
+	"github.com/flonja/multiversion/packbuilder"
+	"github.com/flonja/multiversion/translator"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/sandertv/gophertunnel/minecraft/resource"
+	"golang.org/x/exp/maps"
+)
+
+// ProtocolStep describes the changes of a single protocol version compared to the protocol version that directly
+// follows it. A ProtocolStep only converts between the packets of its own version and those of the next version, so
+// that a new protocol version only requires changing the step directly preceding it. The packets of the next version
+// are those registered by the next step that changed the packet, or those of the latest version if no later step
+// changed it.
+type ProtocolStep interface {
+	// Packets registers the packets that differ from the next protocol version in the pool passed.
+	Packets(pool packet.Pool)
+	// Upgrade converts a packet of this step to packets of the next protocol version. False is returned if the
+	// packet was not changed in this step, in which case it is passed on unchanged.
+	Upgrade(pk packet.Packet, conn *minecraft.Conn) ([]packet.Packet, bool)
+	// Downgrade converts a packet of the next protocol version to packets of this step. False is returned if the
+	// packet was not changed in this step, in which case it is passed on unchanged.
+	Downgrade(pk packet.Packet, conn *minecraft.Conn) ([]packet.Packet, bool)
+}
+
+// IOStep is a ProtocolStep that also changed the way types shared between packets are encoded.
+type IOStep interface {
+	ProtocolStep
+	// NewReader wraps the protocol.Reader passed to read the types of this step.
+	NewReader(r *protocol.Reader) protocol.IO
+	// NewWriter wraps the protocol.Writer passed to write the types of this step.
+	NewWriter(w *protocol.Writer) protocol.IO
+}
+
+// Protocol is a minecraft.Protocol composed out of multiple ProtocolSteps.
+type Protocol struct {
+	id  int32
+	ver string
+
+	itemTranslator   translator.ItemTranslator
+	blockTranslator  translator.BlockTranslator
+	entityTranslator translator.EntityTranslator
+	// soundTranslator translates the sounds of the protocol. If nil, the sounds are the same as the latest version.
+	soundTranslator translator.SoundTranslator
+	// levelEventTranslator translates the level events and particles of the protocol. If nil, the level events and
+	// particles are the same as the latest version.
+	levelEventTranslator translator.LevelEventTranslator
+	// commandTranslator translates the commands of the protocol. If nil, the commands are the same as the latest
+	// version.
+	commandTranslator translator.CommandTranslator
+
+	// steps holds all steps of the protocol, ordered from the oldest to the newest step.
+	steps []ProtocolStep
+	// clientSide is true if the protocol is used on the client side of connections, such as those obtained using a
+	// minecraft.Dialer.
+	clientSide bool
+}
+
+// Chain builds a minecraft.Protocol by composing the steps passed. The steps must be ordered from the oldest to the
+// newest protocol version, with the first step being the one of the protocol version returned by ID. Packets are
+// upgraded by passing them through every step from the oldest to the newest, and downgraded the other way around.
+func Chain(id int32, ver string, itemTranslator translator.ItemTranslator, blockTranslator translator.BlockTranslator, entityTranslator translator.EntityTranslator, steps ...ProtocolStep) *Protocol {
+	return &Protocol{id: id, ver: ver, itemTranslator: itemTranslator, blockTranslator: blockTranslator, entityTranslator: entityTranslator, steps: steps}
+}
+
+// WithSoundTranslator sets the translator.SoundTranslator used to translate sounds unknown to the protocol.
+func (p *Protocol) WithSoundTranslator(soundTranslator translator.SoundTranslator) *Protocol {
+	p.soundTranslator = soundTranslator
+	return p
+}
+
+// WithLevelEventTranslator sets the translator.LevelEventTranslator used to translate level events and particles
+// unknown to the protocol.
+func (p *Protocol) WithLevelEventTranslator(levelEventTranslator translator.LevelEventTranslator) *Protocol {
+	p.levelEventTranslator = levelEventTranslator
+	return p
+}
+
+// WithCommandTranslator sets the translator.CommandTranslator used to translate the commands sent to the protocol.
+func (p *Protocol) WithCommandTranslator(commandTranslator translator.CommandTranslator) *Protocol {
+	p.commandTranslator = commandTranslator
+	return p
+}
+
+// WithClientSide makes the protocol translate packets for the client side of connections, such as those obtained
+// using a minecraft.Dialer to connect to a legacy server. Packets of the server are then upgraded, and packets of the
+// client downgraded. A protocol built for the client side should not be used by a minecraft.Listener.
+func (p *Protocol) WithClientSide() *Protocol {
+	p.clientSide = true
+	return p
+}
+
+// WithTranslationObserver sets the translator.TranslationObserver notified of every item, block, biome and entity of
+// the protocol that could not be translated.
+func (p *Protocol) WithTranslationObserver(observer translator.TranslationObserver) *Protocol {
+	observer = translator.ObserverForProtocol(p.id, observer)
+	p.itemTranslator.SetObserver(observer)
+	p.blockTranslator.SetObserver(observer)
+	p.entityTranslator.SetObserver(observer)
+	return p
+}
+
+// WithChunkCache makes the protocol cache its translated LevelChunk and SubChunk payloads in the translator.ChunkCache
+// passed, so that chunks sent to multiple players are only translated once. The cache may be shared between protocols.
+func (p *Protocol) WithChunkCache(cache *translator.ChunkCache) *Protocol {
+	p.blockTranslator.SetChunkCache(cache, p.id)
+	return p
+}
+
+// WithChunkErrorHandler sets the translator.ChunkErrorHandler deciding what happens with chunks of the protocol that
+// could not be translated. By default, these chunks are sent untranslated.
+func (p *Protocol) WithChunkErrorHandler(handler translator.ChunkErrorHandler) *Protocol {
+	p.blockTranslator.SetChunkErrorHandler(handler)
+	return p
+}
+
+// WithItemFallbacks sets the mapping.ItemFallbacks used to find the nearest item of the protocol for items that could
+// not be translated. By default, these items are translated to minecraft:info_update.
+func (p *Protocol) WithItemFallbacks(fallbacks *mapping.ItemFallbacks) *Protocol {
+	p.itemTranslator.SetItemFallbacks(fallbacks)
+	return p
+}
+
+// WithItemNBTTransformers sets the translator.ItemNBTTransformers run on the NBT of every item stack translated for
+// the protocol. By default, the NBT of item stacks is left unchanged.
+func (p *Protocol) WithItemNBTTransformers(transformers *translator.ItemNBTTransformers) *Protocol {
+	p.itemTranslator.SetItemNBTTransformers(transformers)
+	return p
+}
+
+// WithBlockFallbacks sets the mapping.BlockFallbacks used to find the nearest block of the protocol for blocks that
+// could not be translated. By default, these blocks are translated to air.
+func (p *Protocol) WithBlockFallbacks(fallbacks *mapping.BlockFallbacks) *Protocol {
+	p.blockTranslator.SetBlockFallbacks(fallbacks)
+	return p
+}
+
+// ID ...
+func (p *Protocol) ID() int32 {
+	return p.id
+}
+
+// Ver ...
+func (p *Protocol) Ver() string {
+	return p.ver
+}
+
+// Packets ...
+func (p *Protocol) Packets(_ bool) packet.Pool {
+	pool := packet.NewClientPool()
+	for k, v := range packet.NewServerPool() {
+		pool[k] = v
+	}
+	// Register the newest steps first, so that older steps overwrite the packets they changed.
+	for i := len(p.steps) - 1; i >= 0; i-- {
+		p.steps[i].Packets(pool)
+	}
+	return pool
+}
+
+// ItemTranslator returns the translator.ItemTranslator of the protocol, which may be used to register custom items.
+func (p *Protocol) ItemTranslator() translator.ItemTranslator {
+	return p.itemTranslator
+}
+
+// BlockTranslator returns the translator.BlockTranslator of the protocol.
+func (p *Protocol) BlockTranslator() translator.BlockTranslator {
+	return p.blockTranslator
+}
+
+// EntityTranslator returns the translator.EntityTranslator of the protocol, which may be used to register entity
+// substitutes.
+func (p *Protocol) EntityTranslator() translator.EntityTranslator {
+	return p.entityTranslator
+}
+
+// SoundTranslator returns the translator.SoundTranslator of the protocol, which is nil if the protocol knows all
+// sounds of the latest version.
+func (p *Protocol) SoundTranslator() translator.SoundTranslator {
+	return p.soundTranslator
+}
+
+// LevelEventTranslator returns the translator.LevelEventTranslator of the protocol, which is nil if the protocol
+// knows all level events and particles of the latest version.
+func (p *Protocol) LevelEventTranslator() translator.LevelEventTranslator {
+	return p.levelEventTranslator
+}
+
+// CommandTranslator returns the translator.CommandTranslator of the protocol, which is nil if the commands of the
+// protocol are the same as the latest version.
+func (p *Protocol) CommandTranslator() translator.CommandTranslator {
+	return p.commandTranslator
+}
+
+// ResourcePack builds a resource pack holding the textures of all custom items and blocks registered to the
+// translators of the protocol. False is returned if no custom items or blocks were registered.
+func (p *Protocol) ResourcePack(ver string) (*resource.Pack, bool) {
+	return packbuilder.BuildResourcePack(maps.Values(p.itemTranslator.CustomItems()), maps.Values(p.blockTranslator.CustomBlocks()), ver)
+}
+
+// Release removes the state the protocol keeps for the connection passed. It must be called once the connection is
+// closed, for example after the read loop of a connection accepted from a minecraft.Listener returns, as the state
+// is kept forever otherwise.
+func (p *Protocol) Release(conn *minecraft.Conn) {
+	translator.ReleaseConn(conn)
+}
+
+// Encryption ...
+func (p *Protocol) Encryption(key [32]byte) packet.Encryption {
+	return packet.NewCTREncryption(key[:])
+}
+
+// NewReader ...
+func (p *Protocol) NewReader(r minecraft.ByteReader, shieldID int32, enableLimits bool) protocol.IO {
+	reader := protocol.NewReader(r, shieldID, enableLimits)
+	if s, ok := p.ioStep(); ok {
+		return s.NewReader(reader)
+	}
+	return reader
+}
+
+// NewWriter ...
+func (p *Protocol) NewWriter(w minecraft.ByteWriter, shieldID int32) protocol.IO {
+	writer := protocol.NewWriter(w, shieldID)
+	if s, ok := p.ioStep(); ok {
+		return s.NewWriter(writer)
+	}
+	return writer
+}
+
+// ConvertToLatest ...
+func (p *Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []packet.Packet {
+	translator.TrackConn(conn, p.id, p.clientSide)
+	pks := p.convertToLatest(pk, conn)
+	if p.levelEventTranslator != nil {
+		// Level events are upgraded first, as the other translators expect level events of the latest version.
+		pks = p.levelEventTranslator.UpgradeLevelEventPackets(pks, conn)
+	}
+	pks = p.itemTranslator.UpgradeItemPackets(pks, conn)
+	pks = p.blockTranslator.UpgradeBlockPackets(pks, conn)
+	pks = p.entityTranslator.UpgradeEntityPackets(pks, conn)
+	if p.soundTranslator != nil {
+		pks = p.soundTranslator.UpgradeSoundPackets(pks, conn)
+	}
+	if p.commandTranslator != nil {
+		pks = p.commandTranslator.UpgradeCommandPackets(pks, conn)
+	}
+	return pks
+}
+
+// ConvertFromLatest ...
+func (p *Protocol) ConvertFromLatest(pk packet.Packet, conn *minecraft.Conn) (result []packet.Packet) {
+	translator.TrackConn(conn, p.id, p.clientSide)
+	pks := p.itemTranslator.DowngradeItemPackets([]packet.Packet{pk}, conn)
+	pks = p.blockTranslator.DowngradeBlockPackets(pks, conn)
+	pks = p.entityTranslator.DowngradeEntityPackets(pks, conn)
+	if p.soundTranslator != nil {
+		pks = p.soundTranslator.DowngradeSoundPackets(pks, conn)
+	}
+	if p.levelEventTranslator != nil {
+		pks = p.levelEventTranslator.DowngradeLevelEventPackets(pks, conn)
+	}
+	if p.commandTranslator != nil {
+		pks = p.commandTranslator.DowngradeCommandPackets(pks, conn)
+	}
+	for _, pk := range pks {
+		result = append(result, p.convertFromLatest(pk, conn)...)
+	}
+	return result
+}
+
+// convertToLatest converts a single packet of the protocol to the latest protocol version by upgrading it with every
+// step, starting with the oldest.
+func (p *Protocol) convertToLatest(pk packet.Packet, conn *minecraft.Conn) []packet.Packet {
+	pks := []packet.Packet{pk}
+	for _, s := range p.steps {
+		pks = convert(pks, conn, s.Upgrade)
+	}
+	return pks
+}
+
+// convertFromLatest converts a single packet of the latest protocol version to the protocol by downgrading it with
+// every step, starting with the newest.
+func (p *Protocol) convertFromLatest(pk packet.Packet, conn *minecraft.Conn) []packet.Packet {
+	pks := []packet.Packet{pk}
+	for i := len(p.steps) - 1; i >= 0; i-- {
+		pks = convert(pks, conn, p.steps[i].Downgrade)
+	}
+	return pks
+}
+
+// convert converts every packet passed using the conversion function of a step. Packets not changed by the step are
+// kept as they are.
+func convert(pks []packet.Packet, conn *minecraft.Conn, f func(pk packet.Packet, conn *minecraft.Conn) ([]packet.Packet, bool)) []packet.Packet {
+	result := make([]packet.Packet, 0, len(pks))
+	for _, pk := range pks {
+		if converted, ok := f(pk, conn); ok {
+			result = append(result, converted...)
+			continue
+		}
+		result = append(result, pk)
+	}
+	return result
+}
+
+// ioStep returns the oldest step that changed the encoding of shared types, if any.
+func (p *Protocol) ioStep() (IOStep, bool) {
+	for _, s := range p.steps {
+		if s, ok := s.(IOStep); ok {
+			return s, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,15 @@
+package latest
+
+import (
+	_ "embed"
+	"github.com/flonja/multiversion/mapping"
+)
+
+var (
+	//go:embed entity_identifiers.nbt
+	entityIdentifierData []byte
+)
+
+func NewEntityMapping() *mapping.DefaultEntityMapping {
+	return mapping.NewEntityMapping(entityIdentifierData)
+}
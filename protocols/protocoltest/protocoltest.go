@@ -0,0 +1,274 @@
+// Package protocoltest implements a golden test harness for minecraft.Protocol implementations. Every Case is encoded
+// with the protocol and compared to a byte fixture, which is then decoded, converted to the latest protocol version
+// and back, and encoded again. Differences are reported field by field.
+//
+// Fixtures are stored in the testdata directory of the package under test. Most are generated by encoding the cases
+// with the protocol itself, and (re)written by running the tests with the -update flag, after which the changes to the
+// fixtures should be reviewed like any other change. These pin down the current encoding so that changes to it are
+// noticed, but a mistake made both in encoding and decoding a packet is not caught.
+//
+// Fixtures of cases marked External are not produced by the protocol, but written as annotated hexadecimal and never
+// rewritten by -update. They hold bytes captured from a real client or server, or, where no capture is available,
+// bytes assembled field by field from the documentation of the protocol, so that they catch mistakes made in both
+// encoding and decoding a packet.
+package protocoltest
+
+import (
+	"bytes"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "write the golden fixtures instead of comparing against them")
+
+// Case is a single golden test case of a protocol.
+type Case struct {
+	// Name is the name of the case, which is also the name of its fixture.
+	Name string
+	// Packet is the packet as sent over the connection of the protocol.
+	Packet packet.Packet
+	// Latest holds the packets Packet is expected to convert to using ConvertToLatest. The conversion is not checked
+	// if Latest is nil.
+	Latest []packet.Packet
+	// OneWay skips converting the latest packets back using ConvertFromLatest. It should be set for packets of which
+	// the conversion is lossy by design.
+	OneWay bool
+	// External marks the fixture of the case as not produced by the protocol. It is stored in testdata/<Name>.hex as
+	// hexadecimal bytes, in which whitespace is ignored and # starts a comment running to the end of the line, and is
+	// never rewritten by -update.
+	External bool
+}
+
+// Run runs the golden test cases passed for the protocol, each as a subtest of the test passed.
+func Run(t *testing.T, proto minecraft.Protocol, cases ...Case) {
+	t.Helper()
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			run(t, proto, c)
+		})
+	}
+}
+
+// run runs a single golden test case.
+func run(t *testing.T, proto minecraft.Protocol, c Case) {
+	encoded := Encode(proto, c.Packet)
+	fixture, err := readFixture(c, encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareEncoded(t, proto, c.Packet.ID(), "encoded packet", encoded, fixture)
+
+	decoded, err := Decode(proto, c.Packet.ID(), fixture)
+	if err != nil {
+		t.Fatalf("decode fixture: %v", err)
+	}
+	for _, diff := range Diff(c.Packet, decoded) {
+		t.Errorf("decoded packet: %v", diff)
+	}
+
+	conn := new(minecraft.Conn)
+	latest := proto.ConvertToLatest(decoded, conn)
+	if c.Latest != nil {
+		if len(latest) != len(c.Latest) {
+			t.Fatalf("converted to %v latest packets, expected %v", len(latest), len(c.Latest))
+		}
+		for i, pk := range latest {
+			for _, diff := range Diff(c.Latest[i], pk) {
+				t.Errorf("latest packet %v: %v", i, diff)
+			}
+		}
+	}
+	if c.OneWay {
+		return
+	}
+
+	var legacy []packet.Packet
+	for _, pk := range latest {
+		legacy = append(legacy, proto.ConvertFromLatest(pk, conn)...)
+	}
+	if len(legacy) != 1 {
+		t.Fatalf("converted back to %v packets, expected 1", len(legacy))
+	}
+	for _, diff := range Diff(c.Packet, legacy[0]) {
+		t.Errorf("converted packet: %v", diff)
+	}
+	compareEncoded(t, proto, c.Packet.ID(), "converted packet", Encode(proto, legacy[0]), fixture)
+}
+
+// readFixture reads the fixture of the case passed. Unless the case is External, the encoded packet passed is written
+// as fixture first if the tests are run with the -update flag.
+func readFixture(c Case, encoded []byte) ([]byte, error) {
+	if c.External {
+		data, err := os.ReadFile(filepath.Join("testdata", c.Name+".hex"))
+		if err != nil {
+			return nil, fmt.Errorf("read external fixture: %w", err)
+		}
+		var digits strings.Builder
+		for _, line := range strings.Split(string(data), "\n") {
+			line, _, _ = strings.Cut(line, "#")
+			for _, field := range strings.Fields(line) {
+				digits.WriteString(field)
+			}
+		}
+		fixture, err := hex.DecodeString(digits.String())
+		if err != nil {
+			return nil, fmt.Errorf("decode external fixture: %w", err)
+		}
+		return fixture, nil
+	}
+
+	path := filepath.Join("testdata", c.Name+".bin")
+	if *update {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			return nil, fmt.Errorf("create testdata: %w", err)
+		}
+		if err := os.WriteFile(path, encoded, 0644); err != nil {
+			return nil, fmt.Errorf("write fixture: %w", err)
+		}
+	}
+	fixture, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read fixture (run with -update to create it): %w", err)
+	}
+	return fixture, nil
+}
+
+// compareEncoded compares the encoded packet passed to the fixture. NBT compounds held in maps are encoded in random
+// order, so if the bytes differ, the packets decoded from both are compared instead.
+func compareEncoded(t *testing.T, proto minecraft.Protocol, id uint32, name string, encoded, fixture []byte) {
+	t.Helper()
+	if bytes.Equal(encoded, fixture) {
+		return
+	}
+	expected, err := Decode(proto, id, fixture)
+	if err != nil {
+		t.Errorf("%v differs from fixture at byte %v", name, firstDifference(encoded, fixture))
+		return
+	}
+	actual, err := Decode(proto, id, encoded)
+	if err != nil {
+		t.Errorf("%v differs from fixture at byte %v: %v", name, firstDifference(encoded, fixture), err)
+		return
+	}
+	if diffs := Diff(expected, actual); len(diffs) != 0 {
+		t.Errorf("%v differs from fixture at byte %v: %v", name, firstDifference(encoded, fixture), diffs[0])
+	}
+}
+
+// Encode encodes the packet passed using the protocol passed. The packet header is not included.
+func Encode(proto minecraft.Protocol, pk packet.Packet) []byte {
+	buf := bytes.NewBuffer(nil)
+	pk.Marshal(proto.NewWriter(buf, 0))
+	return buf.Bytes()
+}
+
+// Decode decodes the packet with the ID passed from the data passed, using the protocol passed. The packet is looked
+// up in the pools of both the client and the server.
+func Decode(proto minecraft.Protocol, id uint32, data []byte) (pk packet.Packet, err error) {
+	newPacket, ok := proto.Packets(false)[id]
+	if !ok {
+		if newPacket, ok = proto.Packets(true)[id]; !ok {
+			return nil, fmt.Errorf("unknown packet %v", id)
+		}
+	}
+	defer func() {
+		// Reading invalid data panics, just like it does for a minecraft.Conn.
+		if r := recover(); r != nil {
+			err = fmt.Errorf("decode packet %T: %v", pk, r)
+		}
+	}()
+	buf := bytes.NewBuffer(data)
+	pk = newPacket()
+	pk.Marshal(proto.NewReader(buf, 0, false))
+	if buf.Len() != 0 {
+		return pk, fmt.Errorf("%v unread bytes left after decoding packet %T", buf.Len(), pk)
+	}
+	return pk, nil
+}
+
+// Diff compares the values passed field by field and returns a description of every difference. Nil and empty
+// slices and maps are considered equal, as the encoding doesn't distinguish between them.
+func Diff(expected, actual any) []string {
+	var diffs []string
+	diff(&diffs, fmt.Sprintf("%T", expected), reflect.ValueOf(expected), reflect.ValueOf(actual))
+	return diffs
+}
+
+// diff appends a description of every difference between the values passed to diffs.
+func diff(diffs *[]string, path string, expected, actual reflect.Value) {
+	if !expected.IsValid() || !actual.IsValid() {
+		if expected.IsValid() != actual.IsValid() {
+			*diffs = append(*diffs, fmt.Sprintf("%v: expected %v, got %v", path, describe(expected), describe(actual)))
+		}
+		return
+	}
+	if expected.Type() != actual.Type() {
+		*diffs = append(*diffs, fmt.Sprintf("%v: expected type %v, got %v", path, expected.Type(), actual.Type()))
+		return
+	}
+	switch expected.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if expected.IsNil() || actual.IsNil() {
+			if expected.IsNil() != actual.IsNil() {
+				*diffs = append(*diffs, fmt.Sprintf("%v: expected %v, got %v", path, describe(expected), describe(actual)))
+			}
+			return
+		}
+		diff(diffs, path, expected.Elem(), actual.Elem())
+	case reflect.Struct:
+		for i := 0; i < expected.NumField(); i++ {
+			diff(diffs, path+"."+expected.Type().Field(i).Name, expected.Field(i), actual.Field(i))
+		}
+	case reflect.Slice, reflect.Array:
+		if expected.Len() != actual.Len() {
+			*diffs = append(*diffs, fmt.Sprintf("%v: expected length %v, got %v", path, expected.Len(), actual.Len()))
+			return
+		}
+		for i := 0; i < expected.Len(); i++ {
+			diff(diffs, fmt.Sprintf("%v[%v]", path, i), expected.Index(i), actual.Index(i))
+		}
+	case reflect.Map:
+		if expected.Len() != actual.Len() {
+			*diffs = append(*diffs, fmt.Sprintf("%v: expected length %v, got %v", path, expected.Len(), actual.Len()))
+			return
+		}
+		iter := expected.MapRange()
+		for iter.Next() {
+			diff(diffs, fmt.Sprintf("%v[%v]", path, describe(iter.Key())), iter.Value(), actual.MapIndex(iter.Key()))
+		}
+	default:
+		if !expected.Equal(actual) {
+			*diffs = append(*diffs, fmt.Sprintf("%v: expected %v, got %v", path, describe(expected), describe(actual)))
+		}
+	}
+}
+
+// describe returns a description of the value passed for use in a difference.
+func describe(v reflect.Value) string {
+	if !v.IsValid() {
+		return "nothing"
+	}
+	if v.CanInterface() {
+		return fmt.Sprintf("%#v", v.Interface())
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// firstDifference returns the offset of the first byte that differs between the data passed.
+func firstDifference(a, b []byte) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return min(len(a), len(b))
+}
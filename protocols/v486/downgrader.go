@@ -4,37 +4,18 @@ import (
 	"github.com/df-mc/worldupgrader/itemupgrader"
 	"github.com/flonja/multiversion/mapping"
 	"github.com/flonja/multiversion/protocols/v486/types"
+	"github.com/samber/lo"
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
 )
 
-// downgradeBlockActorData downgrades a block actor from latest version to legacy version.
-func downgradeBlockActorData(data map[string]any) map[string]any {
-	switch data["id"] {
-	case "Sign":
-		delete(data, "BackText")
-		frontRaw, ok := data["FrontText"]
-		if !ok {
-			frontRaw = map[string]any{"Text": ""}
-		}
-		front, ok := frontRaw.(map[string]any)
-		if !ok {
-			front = map[string]any{"Text": ""}
-		}
-		textRaw, ok := front["Text"]
-		if !ok {
-			textRaw = ""
-		}
-		text, ok := textRaw.(string)
-		if !ok {
-			text = ""
-		}
-		data["Text"] = text
-	}
-	return data
-}
+// entityFlags holds the entity flags known to v486, which lacks all flags added since 1.19.
+var entityFlags = mapping.NewEntityFlags(lo.RangeFrom[uint8](0, 101)).
+	WithSubstitute(protocol.EntityDataFlagCrawling, protocol.EntityDataFlagSwimming)
 
 // downgradeEntityMetadata downgrades entity metadata from latest version to legacy version.
 func downgradeEntityMetadata(data map[uint32]any) map[uint32]any {
+	entityFlags.Downgrade(data)
+
 	newData := make(map[uint32]any)
 	for key, value := range data {
 		switch key {
@@ -86,5 +67,3 @@ func downgradeCraftingDescription(descriptor protocol.ItemDescriptor, m mapping.
 		MetadataValue: metadata,
 	}
 }
-
-// TODO: add downgrade entity flags
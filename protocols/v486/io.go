@@ -123,6 +123,65 @@ func (w *Writer) ItemDescriptorCount(i *protocol.ItemDescriptorCount) {
 	w.Varint32(&i.Count)
 }
 
+// StackRequestAction writes a StackRequestAction to the writer.
+func (w *Writer) StackRequestAction(x *protocol.StackRequestAction) {
+	var id uint8
+	if !lookupStackRequestActionType(*x, &id) {
+		w.UnknownEnumOption(fmt.Sprintf("%T", *x), "stack request action type")
+	}
+	w.Uint8(&id)
+	(*x).Marshal(w)
+}
+
+// lookupStackRequestActionType looks up the ID of a StackRequestAction. False is returned if none was found.
+func lookupStackRequestActionType(x protocol.StackRequestAction, id *uint8) bool {
+	switch x.(type) {
+	case *types.TakeStackRequestAction:
+		*id = protocol.StackRequestActionTake
+	case *types.PlaceStackRequestAction:
+		*id = protocol.StackRequestActionPlace
+	case *types.SwapStackRequestAction:
+		*id = protocol.StackRequestActionSwap
+	case *types.DropStackRequestAction:
+		*id = protocol.StackRequestActionDrop
+	case *types.DestroyStackRequestAction:
+		*id = protocol.StackRequestActionDestroy
+	case *types.ConsumeStackRequestAction:
+		*id = protocol.StackRequestActionConsume
+	case *protocol.CreateStackRequestAction:
+		*id = protocol.StackRequestActionCreate
+	case *types.PlaceInContainerStackRequestAction:
+		*id = protocol.StackRequestActionPlaceInContainer
+	case *types.TakeOutContainerStackRequestAction:
+		*id = protocol.StackRequestActionTakeOutContainer
+	case *protocol.LabTableCombineStackRequestAction:
+		*id = protocol.StackRequestActionLabTableCombine
+	case *protocol.BeaconPaymentStackRequestAction:
+		*id = protocol.StackRequestActionBeaconPayment
+	case *protocol.MineBlockStackRequestAction:
+		*id = protocol.StackRequestActionMineBlock
+	case *protocol.CraftRecipeStackRequestAction:
+		*id = protocol.StackRequestActionCraftRecipe
+	case *types.AutoCraftRecipeStackRequestAction:
+		*id = protocol.StackRequestActionCraftRecipeAuto
+	case *protocol.CraftCreativeStackRequestAction:
+		*id = protocol.StackRequestActionCraftCreative
+	case *protocol.CraftRecipeOptionalStackRequestAction:
+		*id = protocol.StackRequestActionCraftRecipeOptional
+	case *protocol.CraftGrindstoneRecipeStackRequestAction:
+		*id = protocol.StackRequestActionCraftGrindstone
+	case *protocol.CraftLoomRecipeStackRequestAction:
+		*id = protocol.StackRequestActionCraftLoom
+	case *protocol.CraftNonImplementedStackRequestAction:
+		*id = protocol.StackRequestActionCraftNonImplementedDeprecated
+	case *protocol.CraftResultsDeprecatedStackRequestAction:
+		*id = protocol.StackRequestActionCraftResultsDeprecated
+	default:
+		return false
+	}
+	return true
+}
+
 // Recipe writes a Recipe to the writer.
 func (w *Writer) Recipe(x *protocol.Recipe) {
 	var recipeType int32
@@ -4,12 +4,14 @@ import (
 	_ "embed"
 	"encoding/json"
 	"github.com/flonja/multiversion/mapping"
+	"github.com/flonja/multiversion/protocols/chain"
 	"github.com/flonja/multiversion/protocols/latest"
 	legacypacket "github.com/flonja/multiversion/protocols/v486/packet"
 	"github.com/flonja/multiversion/protocols/v486/types"
-	legacypacket_v582 "github.com/flonja/multiversion/protocols/v582/packet"
-	legacypacket_v589 "github.com/flonja/multiversion/protocols/v589/packet"
-	types_v589 "github.com/flonja/multiversion/protocols/v589/types"
+	v582 "github.com/flonja/multiversion/protocols/v582"
+	v582packet "github.com/flonja/multiversion/protocols/v582/packet"
+	v630packet "github.com/flonja/multiversion/protocols/v630/packet"
+	v630types "github.com/flonja/multiversion/protocols/v630/types"
 	"github.com/flonja/multiversion/translator"
 	"github.com/samber/lo"
 	"github.com/sandertv/gophertunnel/minecraft"
@@ -22,45 +24,206 @@ var (
 	itemRuntimeIDData []byte
 	//go:embed block_states.nbt
 	blockStateData []byte
+	//go:embed biome_ids.nbt
+	biomeIDData []byte
+	//go:embed entity_identifiers.nbt
+	entityIdentifierData []byte
 )
 
-// Protocol Deprecated due to Mojang not supporting <1.20
-type Protocol struct {
-	itemMapping     mapping.Item
-	blockMapping    mapping.Block
-	itemTranslator  translator.ItemTranslator
-	blockTranslator translator.BlockTranslator
+// New returns a minecraft.Protocol for v486, composed out of the steps returned by Steps.
+//
+// Deprecated: Mojang does not support versions older than 1.20.
+func New() *chain.Protocol {
+	itemMapping := NewItemMapping()
+	blockMapping := NewBlockMapping()
+	latestBlockMapping := latest.NewBlockMapping()
+	soundMapping := mapping.NewSoundMapping(372).
+		WithSoundNamePrefix("trial_spawner.", "").
+		WithSoundNamePrefix("wind_charge.", "").
+		WithSoundNamePrefix("breeze.", "").
+		WithSoundNamePrefix("crafter.", "")
+	return chain.Chain(486, "1.18.12",
+		translator.NewItemTranslator(itemMapping, latest.NewItemMapping(), blockMapping, latestBlockMapping),
+		translator.NewBlockTranslator(blockMapping, latestBlockMapping, NewBiomeMapping(), latest.NewBiomeMapping()),
+		translator.NewEntityTranslator(mapping.NewEntityMapping(entityIdentifierData), latest.NewEntityMapping(), nil),
+		Steps()...,
+	).WithSoundTranslator(translator.NewSoundTranslator(soundMapping)).
+		WithLevelEventTranslator(translator.NewLevelEventTranslator(LevelEvents())).
+		WithCommandTranslator(Commands()).
+		WithBlockFallbacks(BlockFallbacks()).
+		WithItemFallbacks(ItemFallbacks()).
+		WithItemNBTTransformers(ItemNBTTransformers())
 }
 
-func New() *Protocol {
-	// TODOn't: add custom block/item replacements (aka make it cool)
+// NewBlockMapping returns the block mapping of v486.
+func NewBlockMapping() *mapping.DefaultBlockMapping {
+	return mapping.NewBlockMapping(blockStateData).
+		WithBlockActorTransformers(mapping.DefaultBlockActorTransformers().ForProtocol(486))
+}
 
-	itemMapping := mapping.NewItemMapping(itemRuntimeIDData)
-	blockMapping := mapping.NewBlockMapping(blockStateData).WithBlockActorRemapper(downgradeBlockActorData, upgradeBlockActorData)
-	latestBlockMapping := latest.NewBlockMapping()
-	return &Protocol{itemMapping: itemMapping, blockMapping: blockMapping,
-		itemTranslator:  translator.NewItemTranslator(itemMapping, latest.NewItemMapping(), blockMapping, latestBlockMapping),
-		blockTranslator: translator.NewBlockTranslator(blockMapping, latestBlockMapping)}
+// NewBiomeMapping returns the biome mapping of v486, in which the biomes added since fall back to the nearest biome
+// v486 knows.
+func NewBiomeMapping() *mapping.DefaultBiomeMapping {
+	return mapping.NewBiomeMapping(biomeIDData).
+		WithFallback("deep_dark", "dripstone_caves").
+		WithFallback("mangrove_swamp", "swampland").
+		WithFallback("cherry_grove", "meadow")
 }
 
-func (p Protocol) ID() int32 {
-	return 486
+// NewItemMapping returns the item mapping of v486.
+func NewItemMapping() *mapping.DefaultItemMapping {
+	return mapping.NewItemMapping(itemRuntimeIDData)
 }
 
-func (p Protocol) Ver() string {
-	return "1.18.12"
+// Steps returns the steps required to convert between v486 and the latest protocol version, ordered from the oldest
+// to the newest step.
+func Steps() []chain.ProtocolStep {
+	return append([]chain.ProtocolStep{Step{itemMapping: NewItemMapping()}}, v582.Steps()...)
 }
 
-func (Protocol) Packets(_ bool) packet.Pool {
-	pool := packet.NewClientPool()
-	for k, v := range packet.NewServerPool() {
-		pool[k] = v
-	}
+// BlockFallbacks returns the rules used to find the nearest block of v486 for blocks unknown to it, including those
+// unknown to the protocol versions that follow it.
+func BlockFallbacks() *mapping.BlockFallbacks {
+	hangingSign := []string{"attached_bit", "facing_direction", "hanging"}
+	return v582.BlockFallbacks().With(
+		mapping.BlockFallback{Name: "minecraft:oak_hanging_sign", Drop: hangingSign, Target: "minecraft:standing_sign"},
+		mapping.BlockFallback{Name: "minecraft:dark_oak_hanging_sign", Drop: hangingSign, Target: "minecraft:darkoak_standing_sign"},
+		mapping.BlockFallback{Name: "minecraft:*_hanging_sign", Drop: hangingSign, Target: "minecraft:*_standing_sign"},
+		mapping.BlockFallback{Name: "minecraft:mangrove_roots", Target: "minecraft:oak_fence"},
+		mapping.BlockFallback{Name: "minecraft:muddy_mangrove_roots", Target: "minecraft:dirt", TargetProperties: map[string]any{"dirt_type": "normal"}},
+		mapping.BlockFallback{Name: "minecraft:mangrove_propagule", Target: "minecraft:acacia_sapling", TargetProperties: map[string]any{"age_bit": uint8(0)}},
+		mapping.BlockFallback{Name: "minecraft:*_wood", Properties: map[string]any{"stripped_bit": uint8(1)}, Drop: []string{"stripped_bit"}, Target: "minecraft:stripped_*_wood"},
+		mapping.BlockFallback{Name: "minecraft:*_wood", Drop: []string{"stripped_bit"}},
+		mapping.BlockFallback{Name: "minecraft:*mangrove_*", Target: "minecraft:*acacia_*"},
+		mapping.BlockFallback{Name: "minecraft:*cherry_*", Target: "minecraft:*birch_*"},
+		mapping.BlockFallback{Name: "minecraft:bamboo_block", Target: "minecraft:birch_log"},
+		mapping.BlockFallback{Name: "minecraft:stripped_bamboo_block", Target: "minecraft:stripped_birch_log"},
+		mapping.BlockFallback{Name: "minecraft:bamboo_mosaic", Target: "minecraft:birch_planks"},
+		mapping.BlockFallback{Name: "minecraft:bamboo_mosaic_*", Target: "minecraft:birch_*"},
+		mapping.BlockFallback{Name: "minecraft:bamboo_*", Target: "minecraft:birch_*"},
+		mapping.BlockFallback{Name: "minecraft:mud", Target: "minecraft:dirt", TargetProperties: map[string]any{"dirt_type": "normal"}},
+		mapping.BlockFallback{Name: "minecraft:packed_mud", Target: "minecraft:dirt", TargetProperties: map[string]any{"dirt_type": "coarse"}},
+		mapping.BlockFallback{Name: "minecraft:mud_bricks", Target: "minecraft:brick_block"},
+		mapping.BlockFallback{Name: "minecraft:mud_brick_wall", Target: "minecraft:cobblestone_wall", TargetProperties: map[string]any{"wall_block_type": "brick"}},
+		mapping.BlockFallback{Name: "minecraft:mud_brick_slab", Target: "minecraft:stone_block_slab", TargetProperties: map[string]any{"stone_slab_type": "brick"}},
+		mapping.BlockFallback{Name: "minecraft:mud_brick_double_slab", Target: "minecraft:double_stone_block_slab", TargetProperties: map[string]any{"stone_slab_type": "brick"}},
+		mapping.BlockFallback{Name: "minecraft:mud_brick_*", Target: "minecraft:brick_*"},
+		mapping.BlockFallback{Name: "minecraft:*_froglight", TargetProperties: map[string]any{"pillar_axis": "y"}},
+		mapping.BlockFallback{Name: "minecraft:calibrated_sculk_sensor", Drop: []string{"minecraft:cardinal_direction"}, Target: "minecraft:sculk_sensor"},
+		mapping.BlockFallback{Name: "minecraft:sculk_shrieker", TargetProperties: map[string]any{"can_summon": uint8(0)}},
+		mapping.BlockFallback{Name: "minecraft:chiseled_bookshelf", Target: "minecraft:bookshelf", TargetProperties: map[string]any{}},
+		mapping.BlockFallback{Name: "minecraft:decorated_pot", Target: "minecraft:flower_pot", TargetProperties: map[string]any{"update_bit": uint8(0)}},
+		mapping.BlockFallback{Name: "minecraft:pink_petals", Target: "minecraft:pink_tulip", TargetProperties: map[string]any{}},
+		mapping.BlockFallback{Name: "minecraft:torchflower", Target: "minecraft:orange_tulip"},
+		mapping.BlockFallback{Name: "minecraft:torchflower_crop", Target: "minecraft:wheat"},
+		mapping.BlockFallback{Name: "minecraft:suspicious_sand", Target: "minecraft:sand", TargetProperties: map[string]any{"sand_type": "normal"}},
+		mapping.BlockFallback{Name: "minecraft:suspicious_gravel", Target: "minecraft:gravel", TargetProperties: map[string]any{}},
+	)
+}
+
+// ItemFallbacks returns the rules used to find the nearest item of v486 for items unknown to it, including those
+// unknown to the protocol versions that follow it.
+func ItemFallbacks() *mapping.ItemFallbacks {
+	return v582.ItemFallbacks().With(
+		mapping.ItemFallback{Name: "minecraft:*_smithing_template", Target: "minecraft:paper"},
+		mapping.ItemFallback{Name: "minecraft:*_hanging_sign", Target: "minecraft:*_sign"},
+		mapping.ItemFallback{Name: "minecraft:bamboo_raft", Target: "minecraft:birch_boat"},
+		mapping.ItemFallback{Name: "minecraft:bamboo_chest_raft", Target: "minecraft:birch_boat"},
+		mapping.ItemFallback{Name: "minecraft:*_chest_boat", Target: "minecraft:*_boat"},
+		mapping.ItemFallback{Name: "minecraft:mangrove_roots", Target: "minecraft:oak_fence"},
+		mapping.ItemFallback{Name: "minecraft:muddy_mangrove_roots", Target: "minecraft:dirt"},
+		mapping.ItemFallback{Name: "minecraft:mangrove_propagule", Target: "minecraft:oak_sapling"},
+		mapping.ItemFallback{Name: "minecraft:*mangrove_*", Target: "minecraft:*acacia_*"},
+		mapping.ItemFallback{Name: "minecraft:*cherry_*", Target: "minecraft:*birch_*"},
+		mapping.ItemFallback{Name: "minecraft:bamboo_block", Target: "minecraft:birch_log"},
+		mapping.ItemFallback{Name: "minecraft:stripped_bamboo_block", Target: "minecraft:stripped_birch_log"},
+		mapping.ItemFallback{Name: "minecraft:bamboo_mosaic", Target: "minecraft:birch_planks"},
+		mapping.ItemFallback{Name: "minecraft:bamboo_mosaic_*", Target: "minecraft:birch_*"},
+		mapping.ItemFallback{Name: "minecraft:bamboo_*", Target: "minecraft:birch_*"},
+		mapping.ItemFallback{Name: "minecraft:mud", Target: "minecraft:dirt"},
+		mapping.ItemFallback{Name: "minecraft:packed_mud", Target: "minecraft:dirt"},
+		mapping.ItemFallback{Name: "minecraft:mud_bricks", Target: "minecraft:brick_block"},
+		mapping.ItemFallback{Name: "minecraft:mud_brick_*", Target: "minecraft:brick_*"},
+		mapping.ItemFallback{Name: "minecraft:calibrated_sculk_sensor", Target: "minecraft:sculk_sensor"},
+		mapping.ItemFallback{Name: "minecraft:chiseled_bookshelf", Target: "minecraft:bookshelf"},
+		mapping.ItemFallback{Name: "minecraft:decorated_pot", Target: "minecraft:flower_pot"},
+		mapping.ItemFallback{Name: "minecraft:pink_petals", Target: "minecraft:poppy"},
+		mapping.ItemFallback{Name: "minecraft:torchflower", Target: "minecraft:poppy"},
+		mapping.ItemFallback{Name: "minecraft:torchflower_seeds", Target: "minecraft:wheat_seeds"},
+		mapping.ItemFallback{Name: "minecraft:suspicious_sand", Target: "minecraft:sand"},
+		mapping.ItemFallback{Name: "minecraft:suspicious_gravel", Target: "minecraft:gravel"},
+		mapping.ItemFallback{Name: "minecraft:reinforced_deepslate", Target: "minecraft:deepslate"},
+		mapping.ItemFallback{Name: "minecraft:brush", Target: "minecraft:feather"},
+		mapping.ItemFallback{Name: "minecraft:recovery_compass", Target: "minecraft:compass"},
+		mapping.ItemFallback{Name: "minecraft:echo_shard", Target: "minecraft:amethyst_shard"},
+		mapping.ItemFallback{Name: "minecraft:disc_fragment_5", Target: "minecraft:flint"},
+		mapping.ItemFallback{Name: "minecraft:music_disc_5", Target: "minecraft:music_disc_13"},
+		mapping.ItemFallback{Name: "minecraft:*_log", Target: "minecraft:oak_log"},
+		mapping.ItemFallback{Name: "minecraft:*_fence", Target: "minecraft:oak_fence"},
+		mapping.ItemFallback{Name: "minecraft:*_sapling", Target: "minecraft:oak_sapling"},
+	)
+}
+
+// ItemNBTTransformers returns the transformers of the NBT of item stacks of which the format differs in v486, including
+// those of the protocol versions that follow it.
+func ItemNBTTransformers() *translator.ItemNBTTransformers {
+	return v582.ItemNBTTransformers().
+		With(translator.NewEnchantmentRemover(37)). // swift sneak
+		With(translator.NewTrimRemover()).
+		// The formatting codes of the armour trim materials were added along with armour trims, so they are replaced
+		// with the nearest colour known.
+		With(translator.NewFormattingTransformer(map[rune]rune{
+			'h': 'f', // quartz
+			'i': '7', // iron
+			'j': '8', // netherite
+			'm': '4', // redstone
+			'n': '6', // copper
+			'p': '6', // gold
+			'q': '2', // emerald
+			's': 'b', // diamond
+			't': '1', // lapis
+			'u': '5', // amethyst
+		}))
+}
+
+// LevelEvents returns the mapping of the level events and particles unknown to v486, including those unknown to the
+// protocol versions that follow it.
+func LevelEvents() *mapping.DefaultLevelEventMapping {
+	return v582.LevelEvents()
+}
+
+// Commands returns the translator of the commands of v486. The command argument types of v486 were all renumbered since,
+// so the translator is not built on the one of the protocol versions that follow it.
+func Commands() *translator.DefaultCommandTranslator {
+	return translator.NewCommandTranslator().
+		WithArgType(protocol.CommandArgTypeCompareOperator, protocol.CommandArgTypeOperator).
+		WithLegacyArgType(protocol.CommandArgTypeOperator, protocol.CommandArgTypeOperator).
+		WithArgType(protocol.CommandArgTypeTarget, 7).
+		WithArgType(protocol.CommandArgTypeWildcardTarget, 8).
+		WithArgType(protocol.CommandArgTypeFilepath, 16).
+		WithArgType(protocol.CommandArgTypeString, 32).
+		WithArgType(protocol.CommandArgTypePosition, 40).
+		WithArgType(protocol.CommandArgTypeBlockPosition, 40).
+		WithLegacyArgType(40, protocol.CommandArgTypePosition).
+		WithArgType(protocol.CommandArgTypeMessage, 44).
+		WithArgType(protocol.CommandArgTypeRawText, 46).
+		WithArgType(protocol.CommandArgTypeJSON, 50).
+		WithArgType(protocol.CommandArgTypeCommand, 63).
+		WithoutChaining()
+}
+
+// Step is the chain.ProtocolStep holding the packets changed in v486. It also changed the encoding of item stack
+// request actions, so it is a chain.IOStep.
+type Step struct {
+	// itemMapping is used to look up the runtime IDs of the items of recipes, which v486 does not know by name.
+	itemMapping mapping.Item
+}
+
+// Packets ...
+func (Step) Packets(pool packet.Pool) {
 	pool[packet.IDAddActor] = func() packet.Packet { return &legacypacket.AddActor{} }
 	pool[packet.IDAddPlayer] = func() packet.Packet { return &legacypacket.AddPlayer{} }
 	pool[packet.IDAddVolumeEntity] = func() packet.Packet { return &legacypacket.AddVolumeEntity{} }
-	pool[packet.IDAvailableCommands] = func() packet.Packet { return &legacypacket_v589.AvailableCommands{} }
-	pool[packet.IDEmote] = func() packet.Packet { return &legacypacket_v582.Emote{} }
 	pool[packet.IDCommandRequest] = func() packet.Packet { return &legacypacket.CommandRequest{} }
 	pool[packet.IDNetworkChunkPublisherUpdate] = func() packet.Packet { return &legacypacket.NetworkChunkPublisherUpdate{} }
 	pool[packet.IDPlayerAction] = func() packet.Packet { return &legacypacket.PlayerAction{} }
@@ -69,6 +232,7 @@ func (Protocol) Packets(_ bool) packet.Pool {
 	pool[packet.IDPlayerSkin] = func() packet.Packet { return &legacypacket.PlayerSkin{} }
 	pool[packet.IDRemoveVolumeEntity] = func() packet.Packet { return &legacypacket.RemoveVolumeEntity{} }
 	pool[packet.IDRequestChunkRadius] = func() packet.Packet { return &legacypacket.RequestChunkRadius{} }
+	pool[packet.IDSetActorData] = func() packet.Packet { return &legacypacket.SetActorData{} }
 	pool[packet.IDSpawnParticleEffect] = func() packet.Packet { return &legacypacket.SpawnParticleEffect{} }
 	pool[packet.IDStartGame] = func() packet.Packet { return &legacypacket.StartGame{} }
 	pool[packet.IDStructureBlockUpdate] = func() packet.Packet { return &legacypacket.StructureBlockUpdate{} }
@@ -76,29 +240,23 @@ func (Protocol) Packets(_ bool) packet.Pool {
 	pool[packet.IDUpdateAttributes] = func() packet.Packet { return &legacypacket.UpdateAttributes{} }
 	pool[packet.IDItemStackRequest] = func() packet.Packet { return &legacypacket.ItemStackRequest{} }
 	pool[packet.IDModalFormResponse] = func() packet.Packet { return &legacypacket.ModalFormResponse{} }
-	return pool
-}
-
-func (Protocol) Encryption(key [32]byte) packet.Encryption {
-	return packet.NewCTREncryption(key[:])
 }
 
-func (Protocol) NewReader(r minecraft.ByteReader, shieldID int32, enableLimits bool) protocol.IO {
-	return NewReader(protocol.NewReader(r, shieldID, enableLimits))
+// NewReader ...
+func (Step) NewReader(r *protocol.Reader) protocol.IO {
+	return NewReader(r)
 }
 
-func (Protocol) NewWriter(w minecraft.ByteWriter, shieldID int32) protocol.IO {
-	return NewWriter(protocol.NewWriter(w, shieldID))
+// NewWriter ...
+func (Step) NewWriter(w *protocol.Writer) protocol.IO {
+	return NewWriter(w)
 }
 
-func (p Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []packet.Packet {
-	var newPks []packet.Packet
+// Upgrade ...
+func (Step) Upgrade(pk packet.Packet, conn *minecraft.Conn) ([]packet.Packet, bool) {
 	switch pk := pk.(type) {
-	case *packet.ClientCacheStatus:
-		pk.Enabled = false
-		newPks = append(newPks, pk)
 	case *legacypacket.AddActor:
-		newPks = append(newPks, &packet.AddActor{
+		return []packet.Packet{&packet.AddActor{
 			EntityMetadata:   upgradeEntityMetadata(pk.EntityMetadata),
 			EntityRuntimeID:  pk.EntityRuntimeID,
 			EntityType:       pk.EntityType,
@@ -111,9 +269,9 @@ func (p Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 			Attributes:       pk.Attributes,
 			EntityLinks:      pk.EntityLinks,
 			EntityProperties: protocol.EntityProperties{},
-		})
+		}}, true
 	case *legacypacket.AddPlayer:
-		newPks = append(newPks, &packet.AddPlayer{
+		return []packet.Packet{&packet.AddPlayer{
 			UUID:             pk.UUID,
 			Username:         pk.Username,
 			EntityRuntimeID:  pk.EntityRuntimeID,
@@ -139,9 +297,9 @@ func (p Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 				}},
 			},
 			BuildPlatform: int32(protocol.DeviceAndroid),
-		})
+		}}, true
 	case *legacypacket.AddVolumeEntity:
-		newPks = append(newPks, &packet.AddVolumeEntity{
+		return []packet.Packet{&packet.AddVolumeEntity{
 			EntityRuntimeID:    pk.EntityRuntimeID,
 			EntityMetadata:     pk.EntityMetadata,
 			EncodingIdentifier: pk.EncodingIdentifier,
@@ -149,68 +307,13 @@ func (p Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 			EngineVersion:      pk.EngineVersion,
 			Bounds:             [2]protocol.BlockPos{},
 			Dimension:          0,
-		})
-	case *legacypacket_v589.AvailableCommands:
-		for ind1, command := range pk.Commands {
-			for ind2, overload := range command.Overloads {
-				for ind3, parameter := range overload.Parameters {
-					parameterType := uint32(0)
-					switch parameter.Type {
-					case 7:
-						parameterType = protocol.CommandArgTypeTarget
-					case 8:
-						parameterType = protocol.CommandArgTypeWildcardTarget
-					case 16:
-						parameterType = protocol.CommandArgTypeFilepath
-					case 32:
-						parameterType = protocol.CommandArgTypeString
-					case 40:
-						parameterType = protocol.CommandArgTypePosition
-					case 44:
-						parameterType = protocol.CommandArgTypeMessage
-					case 46:
-						parameterType = protocol.CommandArgTypeRawText
-					case 50:
-						parameterType = protocol.CommandArgTypeJSON
-					case 63:
-						parameterType = protocol.CommandArgTypeCommand
-					}
-					parameter.Type = parameterType
-					pk.Commands[ind1].Overloads[ind2].Parameters[ind3] = parameter
-				}
-			}
-		}
-		newPks = append(newPks, &packet.AvailableCommands{
-			EnumValues: pk.EnumValues,
-			Suffixes:   pk.Suffixes,
-			Enums:      pk.Enums,
-			Commands: lo.Map(pk.Commands, func(item types_v589.Command, _ int) protocol.Command {
-				return protocol.Command{
-					Name:            item.Name,
-					Description:     item.Description,
-					Flags:           item.Flags,
-					PermissionLevel: item.PermissionLevel,
-					AliasesOffset:   item.AliasesOffset,
-					Overloads: lo.Map(item.Overloads, func(item types_v589.CommandOverload, _ int) protocol.CommandOverload {
-						return protocol.CommandOverload{
-							Parameters: item.Parameters,
-							Chaining:   false,
-						}
-					}),
-				}
-			}),
-			DynamicEnums: pk.DynamicEnums,
-			Constraints:  pk.Constraints,
-		})
-	case *packet.BlockActorData:
-		pk.NBTData = downgradeBlockActorData(pk.NBTData)
-		newPks = append(newPks, pk)
+		}}, true
 	case *legacypacket.CommandRequest:
-		newPks = append(newPks, &packet.CommandRequest{
+		return []packet.Packet{&packet.CommandRequest{
 			CommandLine:   pk.CommandLine,
 			CommandOrigin: pk.CommandOrigin,
 			Internal:      pk.Internal,
-		})
+		}}, true
 	case *packet.CraftingData:
 		for i, recipe := range pk.Recipes {
 			switch recipe := recipe.(type) {
@@ -255,7 +358,7 @@ func (p Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 				recipe.Addition.Descriptor = upgradeCraftingDescription(recipe.Addition.Descriptor.(*types.DefaultItemDescriptor))
 			}
 		}
-		newPks = append(newPks, pk)
+		return []packet.Packet{pk}, true
 	case *packet.InventoryTransaction:
 		pk.LegacySetItemSlots = lo.Map(pk.LegacySetItemSlots, func(item protocol.LegacySetItemSlot, _ int) protocol.LegacySetItemSlot {
 			if item.ContainerID >= 21 { // RECIPE_BOOK
@@ -263,7 +366,7 @@ func (p Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 			}
 			return item
 		})
-		newPks = append(newPks, pk)
+		return []packet.Packet{pk}, true
 	case *packet.ItemStackResponse:
 		for i2, respons := range pk.Responses {
 			for i3, info := range respons.ContainerInfo {
@@ -273,9 +376,9 @@ func (p Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 				pk.Responses[i2].ContainerInfo[i3] = info
 			}
 		}
-		newPks = append(newPks, pk)
+		return []packet.Packet{pk}, true
 	case *legacypacket.ItemStackRequest:
-		newPks = append(newPks, &packet.ItemStackRequest{
+		return []packet.Packet{&packet.ItemStackRequest{
 			Requests: lo.Map(pk.Requests, func(item types.ItemStackRequest, _ int) protocol.ItemStackRequest {
 				return protocol.ItemStackRequest{
 					RequestID: item.RequestID,
@@ -292,7 +395,7 @@ func (p Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 						case *types.DestroyStackRequestAction:
 							return &action.DestroyStackRequestAction
 						case *types.ConsumeStackRequestAction:
-							return &action.DestroyStackRequestAction
+							return &protocol.ConsumeStackRequestAction{DestroyStackRequestAction: action.DestroyStackRequestAction}
 						case *types.PlaceInContainerStackRequestAction:
 							return &action.PlaceInContainerStackRequestAction
 						case *types.TakeOutContainerStackRequestAction:
@@ -305,7 +408,7 @@ func (p Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 					FilterStrings: item.FilterStrings,
 				}
 			}),
-		})
+		}}, true
 	case *legacypacket.ModalFormResponse:
 		responseData := protocol.Optional[[]byte]{}
 		cancelReason := protocol.Optional[uint8]{}
@@ -315,27 +418,27 @@ func (p Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 		} else {
 			responseData = protocol.Option(pk.ResponseData)
 		}
-		newPks = append(newPks, &packet.ModalFormResponse{
+		return []packet.Packet{&packet.ModalFormResponse{
 			FormID:       pk.FormID,
 			ResponseData: responseData,
 			CancelReason: cancelReason,
-		})
+		}}, true
 	case *legacypacket.NetworkChunkPublisherUpdate:
-		newPks = append(newPks, &packet.NetworkChunkPublisherUpdate{
+		return []packet.Packet{&packet.NetworkChunkPublisherUpdate{
 			Position:    pk.Position,
 			Radius:      pk.Radius,
 			SavedChunks: []protocol.ChunkPos{},
-		})
+		}}, true
 	case *legacypacket.PlayerAction:
-		newPks = append(newPks, &packet.PlayerAction{
+		return []packet.Packet{&packet.PlayerAction{
 			EntityRuntimeID: pk.EntityRuntimeID,
 			ActionType:      pk.ActionType,
 			BlockPosition:   pk.BlockPosition,
 			ResultPosition:  pk.BlockPosition,
 			BlockFace:       pk.BlockFace,
-		})
+		}}, true
 	case *legacypacket.PlayerAuthInput:
-		newPks = append(newPks, &packet.PlayerAuthInput{
+		return []packet.Packet{&v630packet.PlayerAuthInput{
 			Pitch:         pk.Pitch,
 			Yaw:           pk.Yaw,
 			Position:      pk.Position,
@@ -371,7 +474,7 @@ func (p Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 					case *types.DestroyStackRequestAction:
 						return &action.DestroyStackRequestAction
 					case *types.ConsumeStackRequestAction:
-						return &action.DestroyStackRequestAction
+						return &protocol.ConsumeStackRequestAction{DestroyStackRequestAction: action.DestroyStackRequestAction}
 					case *types.PlaceInContainerStackRequestAction:
 						return &action.PlaceInContainerStackRequestAction
 					case *types.TakeOutContainerStackRequestAction:
@@ -385,48 +488,58 @@ func (p Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 			},
 			BlockActions:       pk.BlockActions,
 			AnalogueMoveVector: pk.MoveVector,
-		})
+		}}, true
 	case *legacypacket.PlayerList:
-		newPks = append(newPks, &packet.PlayerList{
+		return []packet.Packet{&v630packet.PlayerList{
 			ActionType: pk.ActionType,
-			Entries: lo.Map(pk.Entries, func(item types.PlayerListEntry, _ int) protocol.PlayerListEntry {
-				return item.PlayerListEntry
+			Entries: lo.Map(pk.Entries, func(item types.PlayerListEntry, _ int) v630types.PlayerListEntry {
+				return v630types.PlayerListEntry{
+					UUID:           item.UUID,
+					EntityUniqueID: item.EntityUniqueID,
+					Username:       item.Username,
+					XUID:           item.XUID,
+					PlatformChatID: item.PlatformChatID,
+					BuildPlatform:  item.BuildPlatform,
+					Skin:           item.Skin,
+					Teacher:        item.Teacher,
+					Host:           item.Host,
+				}
 			}),
-		})
+		}}, true
 	case *legacypacket.PlayerSkin:
-		newPks = append(newPks, &packet.PlayerSkin{
+		return []packet.Packet{&packet.PlayerSkin{
 			UUID:        pk.UUID,
 			Skin:        pk.Skin.Skin,
 			NewSkinName: pk.NewSkinName,
 			OldSkinName: pk.OldSkinName,
-		})
+		}}, true
 	case *legacypacket.RemoveVolumeEntity:
-		newPks = append(newPks, &packet.RemoveVolumeEntity{
+		return []packet.Packet{&packet.RemoveVolumeEntity{
 			EntityRuntimeID: pk.EntityRuntimeID,
 			Dimension:       0,
-		})
+		}}, true
 	case *legacypacket.RequestChunkRadius:
-		newPks = append(newPks, &packet.RequestChunkRadius{
+		return []packet.Packet{&packet.RequestChunkRadius{
 			ChunkRadius:    pk.ChunkRadius,
 			MaxChunkRadius: pk.ChunkRadius,
-		})
+		}}, true
 	case *legacypacket.SetActorData:
-		newPks = append(newPks, &packet.SetActorData{
+		return []packet.Packet{&packet.SetActorData{
 			EntityRuntimeID:  pk.EntityRuntimeID,
 			EntityMetadata:   upgradeEntityMetadata(pk.EntityMetadata),
 			EntityProperties: protocol.EntityProperties{},
 			Tick:             pk.Tick,
-		})
+		}}, true
 	case *legacypacket.SpawnParticleEffect:
-		newPks = append(newPks, &packet.SpawnParticleEffect{
+		return []packet.Packet{&packet.SpawnParticleEffect{
 			Dimension:       pk.Dimension,
 			EntityUniqueID:  pk.EntityUniqueID,
 			Position:        pk.Position,
 			ParticleName:    pk.ParticleName,
 			MoLangVariables: protocol.Optional[[]byte]{},
-		})
+		}}, true
 	case *legacypacket.StartGame:
-		newPks = append(newPks, &packet.StartGame{
+		return []packet.Packet{&v582packet.StartGame{
 			EntityUniqueID:                 pk.EntityUniqueID,
 			EntityRuntimeID:                pk.EntityRuntimeID,
 			PlayerGameMode:                 pk.PlayerGameMode,
@@ -488,9 +601,9 @@ func (p Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 			ServerAuthoritativeInventory:   pk.ServerAuthoritativeInventory,
 			GameVersion:                    pk.GameVersion,
 			ServerBlockStateChecksum:       pk.ServerBlockStateChecksum,
-		})
+		}}, true
 	case *legacypacket.StructureBlockUpdate:
-		newPks = append(newPks, &packet.StructureBlockUpdate{
+		return []packet.Packet{&packet.StructureBlockUpdate{
 			Position:           pk.Position,
 			StructureName:      pk.StructureName,
 			DataField:          pk.DataField,
@@ -501,25 +614,22 @@ func (p Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 			RedstoneSaveMode:   pk.RedstoneSaveMode,
 			ShouldTrigger:      pk.ShouldTrigger,
 			Waterlogged:        pk.Waterlogged,
-		})
+		}}, true
 	case *legacypacket.StructureTemplateDataRequest:
-		newPks = append(newPks, &packet.StructureTemplateDataRequest{
+		return []packet.Packet{&packet.StructureTemplateDataRequest{
 			StructureName: pk.StructureName,
 			Position:      pk.Position,
 			Settings:      pk.Settings.StructureSettings,
 			RequestType:   pk.RequestType,
-		})
-	case *packet.SetActorData:
-		pk.EntityMetadata = upgradeEntityMetadata(pk.EntityMetadata)
-		newPks = append(newPks, pk)
+		}}, true
 	case *legacypacket.UpdateAttributes:
-		newPks = append(newPks, &packet.UpdateAttributes{
+		return []packet.Packet{&packet.UpdateAttributes{
 			EntityRuntimeID: pk.EntityRuntimeID,
 			Attributes: lo.Map(pk.Attributes, func(item types.Attribute, _ int) protocol.Attribute {
 				return item.Attribute
 			}),
 			Tick: pk.Tick,
-		})
+		}}, true
 	case *packet.AdventureSettings:
 		handleFlag := func(flags uint32, secondFlag bool) uint32 {
 			layerMapping := map[uint32]uint32{
@@ -550,471 +660,415 @@ func (p Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 			return out
 		}
 
-		_ = handleFlag
-		//newPks = append(newPks, &packet.UpdateAbilities{
-		//	AbilityData: protocol.AbilityData{
-		//		EntityUniqueID:     pk.PlayerUniqueID,
-		//		PlayerPermissions:  byte(pk.PermissionLevel),
-		//		CommandPermissions: byte(pk.CommandPermissionLevel),
-		//		Layers: []protocol.AbilityLayer{
-		//			{
-		//				Type:      protocol.AbilityLayerTypeBase,
-		//				Abilities: protocol.AbilityCount - 1,
-		//				Values:    handleFlag(pk.Flags, false) | handleFlag(pk.ActionPermissions, true),
-		//				FlySpeed:  protocol.AbilityBaseFlySpeed,
-		//				WalkSpeed: protocol.AbilityBaseWalkSpeed,
-		//			},
-		//		},
-		//	},
-		//})
-	case *legacypacket_v582.Emote:
-		newPks = append(newPks, &packet.Emote{
-			EntityRuntimeID: pk.EntityRuntimeID,
-			EmoteID:         pk.EmoteID,
-			XUID:            conn.IdentityData().XUID,
-			PlatformID:      conn.ClientData().PlatformOnlineID,
-			Flags:           pk.Flags,
-		})
-	default:
-		newPks = append(newPks, pk)
+		if !translator.ClientSide(conn) {
+			// Legacy clients send the packet when they start or stop flying, which the latest version no longer
+			// supports.
+			return nil, true
+		}
+		return []packet.Packet{&packet.UpdateAbilities{
+			AbilityData: protocol.AbilityData{
+				EntityUniqueID:     pk.PlayerUniqueID,
+				PlayerPermissions:  byte(pk.PermissionLevel),
+				CommandPermissions: byte(pk.CommandPermissionLevel),
+				Layers: []protocol.AbilityLayer{
+					{
+						Type:      protocol.AbilityLayerTypeBase,
+						Abilities: protocol.AbilityCount - 1,
+						Values:    handleFlag(pk.Flags, false) | handleFlag(pk.ActionPermissions, true),
+						FlySpeed:  protocol.AbilityBaseFlySpeed,
+						WalkSpeed: protocol.AbilityBaseWalkSpeed,
+					},
+				},
+			},
+		}}, true
 	}
-
-	return p.blockTranslator.UpgradeBlockPackets(p.itemTranslator.UpgradeItemPackets(newPks, conn), conn)
+	return nil, false
 }
 
-func (p Protocol) ConvertFromLatest(pk packet.Packet, conn *minecraft.Conn) (result []packet.Packet) {
-	result = p.blockTranslator.DowngradeBlockPackets(p.itemTranslator.DowngradeItemPackets([]packet.Packet{pk}, conn), conn)
-
-	for i, pk := range result {
-		switch pk := pk.(type) {
-		case *packet.AddActor:
-			result[i] = &legacypacket.AddActor{
-				EntityMetadata:  downgradeEntityMetadata(pk.EntityMetadata),
-				EntityRuntimeID: pk.EntityRuntimeID,
-				EntityType:      pk.EntityType,
-				EntityUniqueID:  pk.EntityUniqueID,
-				HeadYaw:         pk.HeadYaw,
-				Pitch:           pk.Pitch,
-				Position:        pk.Position,
-				Velocity:        pk.Velocity,
-				Yaw:             pk.Yaw,
-				Attributes:      pk.Attributes,
-				EntityLinks:     pk.EntityLinks,
-			}
-		case *packet.AddPlayer:
-			result[i] = &legacypacket.AddPlayer{
-				UUID:            pk.UUID,
-				Username:        pk.Username,
-				EntityUniqueID:  pk.AbilityData.EntityUniqueID,
-				EntityRuntimeID: pk.EntityRuntimeID,
-				PlatformChatID:  pk.PlatformChatID,
-				Position:        pk.Position,
-				Velocity:        pk.Velocity,
-				Pitch:           pk.Pitch,
-				Yaw:             pk.Yaw,
-				HeadYaw:         pk.HeadYaw,
-				HeldItem:        pk.HeldItem,
-				EntityMetadata:  downgradeEntityMetadata(pk.EntityMetadata),
-				AdventureSettings: packet.AdventureSettings{
-					CommandPermissionLevel: uint32(pk.AbilityData.CommandPermissions),
-					PermissionLevel:        uint32(pk.AbilityData.PlayerPermissions),
-					PlayerUniqueID:         pk.AbilityData.EntityUniqueID,
-				},
-				DeviceID:    pk.DeviceID,
-				EntityLinks: pk.EntityLinks,
+// Downgrade ...
+func (s Step) Downgrade(pk packet.Packet, conn *minecraft.Conn) ([]packet.Packet, bool) {
+	switch pk := pk.(type) {
+	case *packet.AddActor:
+		return []packet.Packet{&legacypacket.AddActor{
+			EntityMetadata:  downgradeEntityMetadata(pk.EntityMetadata),
+			EntityRuntimeID: pk.EntityRuntimeID,
+			EntityType:      pk.EntityType,
+			EntityUniqueID:  pk.EntityUniqueID,
+			HeadYaw:         pk.HeadYaw,
+			Pitch:           pk.Pitch,
+			Position:        pk.Position,
+			Velocity:        pk.Velocity,
+			Yaw:             pk.Yaw,
+			Attributes:      pk.Attributes,
+			EntityLinks:     pk.EntityLinks,
+		}}, true
+	case *packet.AddPlayer:
+		return []packet.Packet{&legacypacket.AddPlayer{
+			UUID:            pk.UUID,
+			Username:        pk.Username,
+			EntityUniqueID:  pk.AbilityData.EntityUniqueID,
+			EntityRuntimeID: pk.EntityRuntimeID,
+			PlatformChatID:  pk.PlatformChatID,
+			Position:        pk.Position,
+			Velocity:        pk.Velocity,
+			Pitch:           pk.Pitch,
+			Yaw:             pk.Yaw,
+			HeadYaw:         pk.HeadYaw,
+			HeldItem:        pk.HeldItem,
+			EntityMetadata:  downgradeEntityMetadata(pk.EntityMetadata),
+			AdventureSettings: packet.AdventureSettings{
+				CommandPermissionLevel: uint32(pk.AbilityData.CommandPermissions),
+				PermissionLevel:        uint32(pk.AbilityData.PlayerPermissions),
+				PlayerUniqueID:         pk.AbilityData.EntityUniqueID,
+			},
+			DeviceID:    pk.DeviceID,
+			EntityLinks: pk.EntityLinks,
+		}}, true
+	case *packet.AddVolumeEntity:
+		return []packet.Packet{&legacypacket.AddVolumeEntity{
+			EntityRuntimeID:    pk.EntityRuntimeID,
+			EntityMetadata:     pk.EntityMetadata,
+			EncodingIdentifier: pk.EncodingIdentifier,
+			InstanceIdentifier: pk.InstanceIdentifier,
+			EngineVersion:      pk.EngineVersion,
+		}}, true
+	case *packet.CommandRequest:
+		return []packet.Packet{&legacypacket.CommandRequest{
+			CommandLine:   pk.CommandLine,
+			CommandOrigin: pk.CommandOrigin,
+			Internal:      pk.Internal,
+		}}, true
+	case *packet.CraftingData:
+		for i, recipe := range pk.Recipes {
+			switch recipe := recipe.(type) {
+			case *protocol.ShapelessRecipe:
+				recipe.Input = lo.Map(recipe.Input, func(item protocol.ItemDescriptorCount, _ int) protocol.ItemDescriptorCount {
+					item.Descriptor = downgradeCraftingDescription(item.Descriptor, s.itemMapping)
+					return item
+				})
+				pk.Recipes[i] = recipe
+			case *protocol.ShapedRecipe:
+				recipe.Input = lo.Map(recipe.Input, func(item protocol.ItemDescriptorCount, _ int) protocol.ItemDescriptorCount {
+					item.Descriptor = downgradeCraftingDescription(item.Descriptor, s.itemMapping)
+					return item
+				})
+				pk.Recipes[i] = recipe
+			case *protocol.ShulkerBoxRecipe:
+				recipe.Input = lo.Map(recipe.Input, func(item protocol.ItemDescriptorCount, _ int) protocol.ItemDescriptorCount {
+					item.Descriptor = downgradeCraftingDescription(item.Descriptor, s.itemMapping)
+					return item
+				})
+				pk.Recipes[i] = recipe
+			case *protocol.ShapelessChemistryRecipe:
+				recipe.Input = lo.Map(recipe.Input, func(item protocol.ItemDescriptorCount, _ int) protocol.ItemDescriptorCount {
+					item.Descriptor = downgradeCraftingDescription(item.Descriptor, s.itemMapping)
+					return item
+				})
+				pk.Recipes[i] = recipe
+			case *protocol.ShapedChemistryRecipe:
+				recipe.Input = lo.Map(recipe.Input, func(item protocol.ItemDescriptorCount, _ int) protocol.ItemDescriptorCount {
+					item.Descriptor = downgradeCraftingDescription(item.Descriptor, s.itemMapping)
+					return item
+				})
+				pk.Recipes[i] = recipe
+			case *protocol.SmithingTransformRecipe:
+				recipe.Template.Descriptor = downgradeCraftingDescription(recipe.Template.Descriptor, s.itemMapping)
+				recipe.Base.Descriptor = downgradeCraftingDescription(recipe.Base.Descriptor, s.itemMapping)
+				recipe.Addition.Descriptor = downgradeCraftingDescription(recipe.Addition.Descriptor, s.itemMapping)
+				pk.Recipes[i] = recipe
+			case *protocol.SmithingTrimRecipe:
+				recipe.Template.Descriptor = downgradeCraftingDescription(recipe.Template.Descriptor, s.itemMapping)
+				recipe.Base.Descriptor = downgradeCraftingDescription(recipe.Base.Descriptor, s.itemMapping)
+				recipe.Addition.Descriptor = downgradeCraftingDescription(recipe.Addition.Descriptor, s.itemMapping)
 			}
-		case *packet.AddVolumeEntity:
-			result[i] = &legacypacket.AddVolumeEntity{
-				EntityRuntimeID:    pk.EntityRuntimeID,
-				EntityMetadata:     pk.EntityMetadata,
-				EncodingIdentifier: pk.EncodingIdentifier,
-				InstanceIdentifier: pk.InstanceIdentifier,
-				EngineVersion:      pk.EngineVersion,
+		}
+		return []packet.Packet{pk}, true
+	case *packet.InventoryTransaction:
+		pk.LegacySetItemSlots = lo.Map(pk.LegacySetItemSlots, func(item protocol.LegacySetItemSlot, _ int) protocol.LegacySetItemSlot {
+			if item.ContainerID > 21 { // RECIPE_BOOK
+				item.ContainerID -= 1
 			}
-		case *packet.AvailableCommands:
-			for ind1, command := range pk.Commands {
-				for ind2, overload := range command.Overloads {
-					for ind3, parameter := range overload.Parameters {
-						parameterType := uint32(parameter.Type) | protocol.CommandArgValid
-
-						switch parameter.Type | protocol.CommandArgValid {
-						case protocol.CommandArgTypeCompareOperator:
-							parameterType = protocol.CommandArgTypeOperator
-						case protocol.CommandArgTypeTarget:
-							parameterType = 7
-						case protocol.CommandArgTypeWildcardTarget:
-							parameterType = 8
-						case protocol.CommandArgTypeFilepath:
-							parameterType = 16
-						case protocol.CommandArgTypeString:
-							parameterType = 32
-						case protocol.CommandArgTypeBlockPosition:
-							fallthrough
-						case protocol.CommandArgTypePosition:
-							parameterType = 40
-						case protocol.CommandArgTypeMessage:
-							parameterType = 44
-						case protocol.CommandArgTypeRawText:
-							parameterType = 46
-						case protocol.CommandArgTypeJSON:
-							parameterType = 50
-						case protocol.CommandArgTypeCommand:
-							parameterType = 63
-						}
-						parameter.Type = parameterType | protocol.CommandArgValid
-						pk.Commands[ind1].Overloads[ind2].Parameters[ind3] = parameter
-					}
+			return item
+		})
+		return []packet.Packet{pk}, true
+	case *packet.ItemStackResponse:
+		for i2, respons := range pk.Responses {
+			for i3, info := range respons.ContainerInfo {
+				if info.ContainerID > 21 { // RECIPE_BOOK
+					info.ContainerID -= 1
 				}
+				pk.Responses[i2].ContainerInfo[i3] = info
 			}
-			result[i] = &legacypacket_v589.AvailableCommands{
-				EnumValues: pk.EnumValues,
-				Suffixes:   pk.Suffixes,
-				Enums:      pk.Enums,
-				Commands: lo.Map(pk.Commands, func(item protocol.Command, _ int) types_v589.Command {
-					return types_v589.Command{
-						Name:            item.Name,
-						Description:     item.Description,
-						Flags:           item.Flags,
-						PermissionLevel: item.PermissionLevel,
-						AliasesOffset:   item.AliasesOffset,
-						Overloads: lo.Map(item.Overloads, func(item protocol.CommandOverload, _ int) types_v589.CommandOverload {
-							return types_v589.CommandOverload{
-								Parameters: item.Parameters,
-							}
-						}),
+		}
+		return []packet.Packet{pk}, true
+	case *packet.ItemStackRequest:
+		return []packet.Packet{&legacypacket.ItemStackRequest{
+			Requests: lo.Map(pk.Requests, func(item protocol.ItemStackRequest, _ int) types.ItemStackRequest {
+				item.Actions = lo.Map(item.Actions, func(item protocol.StackRequestAction, _ int) protocol.StackRequestAction {
+					switch action := item.(type) {
+					case *protocol.TakeStackRequestAction:
+						return &types.TakeStackRequestAction{TakeStackRequestAction: *action}
+					case *protocol.PlaceStackRequestAction:
+						return &types.PlaceStackRequestAction{PlaceStackRequestAction: *action}
+					case *protocol.SwapStackRequestAction:
+						return &types.SwapStackRequestAction{SwapStackRequestAction: *action}
+					case *protocol.DropStackRequestAction:
+						return &types.DropStackRequestAction{DropStackRequestAction: *action}
+					case *protocol.DestroyStackRequestAction:
+						return &types.DestroyStackRequestAction{DestroyStackRequestAction: *action}
+					case *protocol.ConsumeStackRequestAction:
+						return &types.ConsumeStackRequestAction{DestroyStackRequestAction: action.DestroyStackRequestAction}
+					case *protocol.PlaceInContainerStackRequestAction:
+						return &types.PlaceInContainerStackRequestAction{PlaceInContainerStackRequestAction: *action}
+					case *protocol.TakeOutContainerStackRequestAction:
+						return &types.TakeOutContainerStackRequestAction{TakeOutContainerStackRequestAction: *action}
+					case *protocol.AutoCraftRecipeStackRequestAction:
+						return &types.AutoCraftRecipeStackRequestAction{AutoCraftRecipeStackRequestAction: *action}
 					}
-				}),
-				DynamicEnums: pk.DynamicEnums,
-				Constraints:  pk.Constraints,
-			}
-		case *packet.BlockActorData:
-			pk.NBTData = downgradeBlockActorData(pk.NBTData)
-			result[i] = pk
-		case *packet.CommandRequest:
-			result[i] = &legacypacket.CommandRequest{
-				CommandLine:   pk.CommandLine,
-				CommandOrigin: pk.CommandOrigin,
-				Internal:      pk.Internal,
-			}
-		case *packet.CraftingData:
-			for i, recipe := range pk.Recipes {
-				switch recipe := recipe.(type) {
-				case *protocol.ShapelessRecipe:
-					recipe.Input = lo.Map(recipe.Input, func(item protocol.ItemDescriptorCount, _ int) protocol.ItemDescriptorCount {
-						item.Descriptor = downgradeCraftingDescription(item.Descriptor, p.itemMapping)
-						return item
-					})
-					pk.Recipes[i] = recipe
-				case *protocol.ShapedRecipe:
-					recipe.Input = lo.Map(recipe.Input, func(item protocol.ItemDescriptorCount, _ int) protocol.ItemDescriptorCount {
-						item.Descriptor = downgradeCraftingDescription(item.Descriptor, p.itemMapping)
-						return item
-					})
-					pk.Recipes[i] = recipe
-				case *protocol.ShulkerBoxRecipe:
-					recipe.Input = lo.Map(recipe.Input, func(item protocol.ItemDescriptorCount, _ int) protocol.ItemDescriptorCount {
-						item.Descriptor = downgradeCraftingDescription(item.Descriptor, p.itemMapping)
-						return item
-					})
-					pk.Recipes[i] = recipe
-				case *protocol.ShapelessChemistryRecipe:
-					recipe.Input = lo.Map(recipe.Input, func(item protocol.ItemDescriptorCount, _ int) protocol.ItemDescriptorCount {
-						item.Descriptor = downgradeCraftingDescription(item.Descriptor, p.itemMapping)
-						return item
-					})
-					pk.Recipes[i] = recipe
-				case *protocol.ShapedChemistryRecipe:
-					recipe.Input = lo.Map(recipe.Input, func(item protocol.ItemDescriptorCount, _ int) protocol.ItemDescriptorCount {
-						item.Descriptor = downgradeCraftingDescription(item.Descriptor, p.itemMapping)
-						return item
-					})
-					pk.Recipes[i] = recipe
-				case *protocol.SmithingTransformRecipe:
-					recipe.Template.Descriptor = downgradeCraftingDescription(recipe.Template.Descriptor, p.itemMapping)
-					recipe.Base.Descriptor = downgradeCraftingDescription(recipe.Base.Descriptor, p.itemMapping)
-					recipe.Addition.Descriptor = downgradeCraftingDescription(recipe.Addition.Descriptor, p.itemMapping)
-					pk.Recipes[i] = recipe
-				case *protocol.SmithingTrimRecipe:
-					recipe.Template.Descriptor = downgradeCraftingDescription(recipe.Template.Descriptor, p.itemMapping)
-					recipe.Base.Descriptor = downgradeCraftingDescription(recipe.Base.Descriptor, p.itemMapping)
-					recipe.Addition.Descriptor = downgradeCraftingDescription(recipe.Addition.Descriptor, p.itemMapping)
-				}
+					return item
+				})
+				return types.ItemStackRequest{ItemStackRequest: item}
+			}),
+		}}, true
+	case *packet.ModalFormResponse:
+		var responseData []byte
+		if val, ok := pk.ResponseData.Value(); ok {
+			responseData = val
+		}
+		if _, cancelled := pk.CancelReason.Value(); cancelled {
+			if resp, err := json.Marshal(nil); err == nil {
+				responseData = resp
 			}
-			result[i] = pk
-		case *packet.InventoryTransaction:
-			pk.LegacySetItemSlots = lo.Map(pk.LegacySetItemSlots, func(item protocol.LegacySetItemSlot, _ int) protocol.LegacySetItemSlot {
-				if item.ContainerID > 21 { // RECIPE_BOOK
-					item.ContainerID -= 1
-				}
-				return item
-			})
-			result[i] = pk
-		case *packet.ItemStackResponse:
-			for i2, respons := range pk.Responses {
-				for i3, info := range respons.ContainerInfo {
-					if info.ContainerID > 21 { // RECIPE_BOOK
-						info.ContainerID -= 1
+		}
+		return []packet.Packet{&legacypacket.ModalFormResponse{
+			FormID:       pk.FormID,
+			ResponseData: responseData,
+		}}, true
+	case *packet.NetworkChunkPublisherUpdate:
+		return []packet.Packet{&legacypacket.NetworkChunkPublisherUpdate{
+			Position: pk.Position,
+			Radius:   pk.Radius,
+		}}, true
+	case *packet.PlayerAction:
+		return []packet.Packet{&legacypacket.PlayerAction{
+			EntityRuntimeID: pk.EntityRuntimeID,
+			ActionType:      pk.ActionType,
+			BlockPosition:   pk.BlockPosition,
+			BlockFace:       pk.BlockFace,
+		}}, true
+	case *v630packet.PlayerAuthInput:
+		return []packet.Packet{&legacypacket.PlayerAuthInput{
+			Pitch:         pk.Pitch,
+			Yaw:           pk.Yaw,
+			Position:      pk.Position,
+			MoveVector:    pk.MoveVector,
+			HeadYaw:       pk.HeadYaw,
+			InputData:     pk.InputData,
+			InputMode:     pk.InputMode,
+			PlayMode:      pk.PlayMode,
+			GazeDirection: pk.GazeDirection,
+			Tick:          pk.Tick,
+			Delta:         pk.Delta,
+			ItemInteractionData: func(data protocol.UseItemTransactionData) protocol.UseItemTransactionData {
+				data.LegacySetItemSlots = lo.Map(data.LegacySetItemSlots, func(item protocol.LegacySetItemSlot, _ int) protocol.LegacySetItemSlot {
+					if item.ContainerID > 21 { // RECIPE_BOOK
+						item.ContainerID -= 1
 					}
-					pk.Responses[i2].ContainerInfo[i3] = info
-				}
-			}
-			result[i] = pk
-		case *packet.ItemStackRequest:
-			result[i] = &legacypacket.ItemStackRequest{
-				Requests: lo.Map(pk.Requests, func(item protocol.ItemStackRequest, _ int) types.ItemStackRequest {
-					item.Actions = lo.Map(item.Actions, func(item protocol.StackRequestAction, _ int) protocol.StackRequestAction {
-						switch action := item.(type) {
-						case *protocol.TakeStackRequestAction:
-							return &types.TakeStackRequestAction{TakeStackRequestAction: *action}
-						case *protocol.PlaceStackRequestAction:
-							return &types.PlaceStackRequestAction{PlaceStackRequestAction: *action}
-						case *protocol.SwapStackRequestAction:
-							return &types.SwapStackRequestAction{SwapStackRequestAction: *action}
-						case *protocol.DropStackRequestAction:
-							return &types.DropStackRequestAction{DropStackRequestAction: *action}
-						case *protocol.DestroyStackRequestAction:
-							return &types.DestroyStackRequestAction{DestroyStackRequestAction: *action}
-						case *protocol.ConsumeStackRequestAction:
-							return &types.ConsumeStackRequestAction{DestroyStackRequestAction: action.DestroyStackRequestAction}
-						case *protocol.PlaceInContainerStackRequestAction:
-							return &types.PlaceInContainerStackRequestAction{PlaceInContainerStackRequestAction: *action}
-						case *protocol.TakeOutContainerStackRequestAction:
-							return &types.TakeOutContainerStackRequestAction{TakeOutContainerStackRequestAction: *action}
-						case *protocol.AutoCraftRecipeStackRequestAction:
-							return &types.AutoCraftRecipeStackRequestAction{AutoCraftRecipeStackRequestAction: *action}
-						}
-						return item
-					})
-					return types.ItemStackRequest{ItemStackRequest: item}
-				}),
-			}
-		case *packet.ModalFormResponse:
-			var responseData []byte
-			if val, ok := pk.ResponseData.Value(); ok {
-				responseData = val
+					return item
+				})
+				return data
+			}(pk.ItemInteractionData),
+			ItemStackRequest: types.ItemStackRequest{ItemStackRequest: pk.ItemStackRequest},
+			BlockActions:     pk.BlockActions,
+		}}, true
+	case *v630packet.PlayerList:
+		return []packet.Packet{&legacypacket.PlayerList{
+			ActionType: pk.ActionType,
+			Entries: lo.Map(pk.Entries, func(item v630types.PlayerListEntry, _ int) types.PlayerListEntry {
+				return types.PlayerListEntry{PlayerListEntry: protocol.PlayerListEntry{
+					UUID:           item.UUID,
+					EntityUniqueID: item.EntityUniqueID,
+					Username:       item.Username,
+					XUID:           item.XUID,
+					PlatformChatID: item.PlatformChatID,
+					BuildPlatform:  item.BuildPlatform,
+					Skin:           item.Skin,
+					Teacher:        item.Teacher,
+					Host:           item.Host,
+				}}
+			}),
+		}}, true
+	case *packet.PlayerSkin:
+		return []packet.Packet{&legacypacket.PlayerSkin{
+			UUID:        pk.UUID,
+			Skin:        types.Skin{Skin: pk.Skin},
+			NewSkinName: pk.NewSkinName,
+			OldSkinName: pk.OldSkinName,
+		}}, true
+	case *packet.RemoveVolumeEntity:
+		return []packet.Packet{&legacypacket.RemoveVolumeEntity{
+			EntityRuntimeID: pk.EntityRuntimeID,
+		}}, true
+	case *packet.RequestChunkRadius:
+		return []packet.Packet{&legacypacket.RequestChunkRadius{
+			ChunkRadius: pk.ChunkRadius,
+		}}, true
+	case *packet.SetActorData:
+		return []packet.Packet{&legacypacket.SetActorData{
+			EntityRuntimeID: pk.EntityRuntimeID,
+			EntityMetadata:  downgradeEntityMetadata(pk.EntityMetadata),
+			Tick:            pk.Tick,
+		}}, true
+	case *packet.SpawnParticleEffect:
+		return []packet.Packet{&legacypacket.SpawnParticleEffect{
+			Dimension:      pk.Dimension,
+			EntityUniqueID: pk.EntityUniqueID,
+			Position:       pk.Position,
+			ParticleName:   pk.ParticleName,
+		}}, true
+	case *v582packet.StartGame:
+		_, enabled := pk.ForceExperimentalGameplay.Value()
+		return []packet.Packet{&legacypacket.StartGame{
+			EntityUniqueID:                 pk.EntityUniqueID,
+			EntityRuntimeID:                pk.EntityRuntimeID,
+			PlayerGameMode:                 pk.PlayerGameMode,
+			PlayerPosition:                 pk.PlayerPosition,
+			Pitch:                          pk.Pitch,
+			Yaw:                            pk.Yaw,
+			WorldSeed:                      int32(pk.WorldSeed),
+			SpawnBiomeType:                 pk.SpawnBiomeType,
+			UserDefinedBiomeName:           pk.UserDefinedBiomeName,
+			Dimension:                      pk.Dimension,
+			Generator:                      pk.Generator,
+			WorldGameMode:                  pk.WorldGameMode,
+			Difficulty:                     pk.Difficulty,
+			WorldSpawn:                     pk.WorldSpawn,
+			AchievementsDisabled:           pk.AchievementsDisabled,
+			DayCycleLockTime:               pk.DayCycleLockTime,
+			EducationEditionOffer:          pk.EducationEditionOffer,
+			EducationFeaturesEnabled:       pk.EducationFeaturesEnabled,
+			EducationProductID:             pk.EducationProductID,
+			RainLevel:                      pk.RainLevel,
+			LightningLevel:                 pk.LightningLevel,
+			ConfirmedPlatformLockedContent: pk.ConfirmedPlatformLockedContent,
+			MultiPlayerGame:                pk.MultiPlayerGame,
+			LANBroadcastEnabled:            pk.LANBroadcastEnabled,
+			XBLBroadcastMode:               pk.XBLBroadcastMode,
+			PlatformBroadcastMode:          pk.PlatformBroadcastMode,
+			CommandsEnabled:                pk.CommandsEnabled,
+			TexturePackRequired:            pk.TexturePackRequired,
+			GameRules:                      pk.GameRules,
+			Experiments:                    pk.Experiments,
+			ExperimentsPreviouslyToggled:   pk.ExperimentsPreviouslyToggled,
+			BonusChestEnabled:              pk.BonusChestEnabled,
+			StartWithMapEnabled:            pk.StartWithMapEnabled,
+			PlayerPermissions:              pk.PlayerPermissions,
+			ServerChunkTickRadius:          pk.ServerChunkTickRadius,
+			HasLockedBehaviourPack:         pk.HasLockedBehaviourPack,
+			HasLockedTexturePack:           pk.HasLockedTexturePack,
+			FromLockedWorldTemplate:        pk.FromLockedWorldTemplate,
+			MSAGamerTagsOnly:               pk.MSAGamerTagsOnly,
+			FromWorldTemplate:              pk.FromWorldTemplate,
+			WorldTemplateSettingsLocked:    pk.WorldTemplateSettingsLocked,
+			OnlySpawnV1Villagers:           pk.OnlySpawnV1Villagers,
+			BaseGameVersion:                pk.BaseGameVersion,
+			LimitedWorldWidth:              pk.LimitedWorldWidth,
+			LimitedWorldDepth:              pk.LimitedWorldDepth,
+			NewNether:                      pk.NewNether,
+			EducationSharedResourceURI:     pk.EducationSharedResourceURI,
+			ForceExperimentalGameplay:      enabled,
+			LevelID:                        pk.LevelID,
+			WorldName:                      pk.WorldName,
+			TemplateContentIdentity:        pk.TemplateContentIdentity,
+			Trial:                          pk.Trial,
+			PlayerMovementSettings:         pk.PlayerMovementSettings,
+			Time:                           pk.Time,
+			EnchantmentSeed:                pk.EnchantmentSeed,
+			Blocks:                         pk.Blocks,
+			Items:                          pk.Items,
+			MultiPlayerCorrelationID:       pk.MultiPlayerCorrelationID,
+			ServerAuthoritativeInventory:   pk.ServerAuthoritativeInventory,
+			GameVersion:                    pk.GameVersion,
+			ServerBlockStateChecksum:       pk.ServerBlockStateChecksum,
+		}}, true
+	case *packet.UpdateAttributes:
+		return []packet.Packet{&legacypacket.UpdateAttributes{
+			EntityRuntimeID: pk.EntityRuntimeID,
+			Attributes: lo.Map(pk.Attributes, func(item protocol.Attribute, _ int) types.Attribute {
+				return types.Attribute{Attribute: item}
+			}),
+			Tick: pk.Tick,
+		}}, true
+	case *packet.StructureBlockUpdate:
+		return []packet.Packet{&legacypacket.StructureBlockUpdate{
+			Position:           pk.Position,
+			StructureName:      pk.StructureName,
+			DataField:          pk.DataField,
+			IncludePlayers:     pk.IncludePlayers,
+			ShowBoundingBox:    pk.ShowBoundingBox,
+			StructureBlockType: pk.StructureBlockType,
+			Settings:           types.StructureSettings{StructureSettings: pk.Settings},
+			RedstoneSaveMode:   pk.RedstoneSaveMode,
+			ShouldTrigger:      pk.ShouldTrigger,
+			Waterlogged:        pk.Waterlogged,
+		}}, true
+	case *packet.StructureTemplateDataRequest:
+		return []packet.Packet{&legacypacket.StructureTemplateDataRequest{
+			StructureName: pk.StructureName,
+			Position:      pk.Position,
+			Settings:      types.StructureSettings{StructureSettings: pk.Settings},
+			RequestType:   pk.RequestType,
+		}}, true
+	case *packet.UpdateAbilities:
+		handleFlag := func(layers []protocol.AbilityLayer, secondFlag bool) uint32 {
+			layerMapping := map[uint32]uint32{
+				protocol.AbilityAttackPlayers: packet.AdventureSettingsFlagsNoPvM,
+				protocol.AbilityMayFly:        packet.AdventureFlagAllowFlight,
+				protocol.AbilityNoClip:        packet.AdventureFlagNoClip,
+				protocol.AbilityWorldBuilder:  packet.AdventureFlagWorldBuilder,
+				protocol.AbilityFlying:        packet.AdventureFlagFlying,
+				protocol.AbilityMuted:         packet.AdventureFlagMuted,
 			}
-			if _, cancelled := pk.CancelReason.Value(); cancelled {
-				if resp, err := json.Marshal(nil); err == nil {
-					responseData = resp
+			if secondFlag {
+				layerMapping = map[uint32]uint32{
+					protocol.AbilityMine:             packet.ActionPermissionMine,
+					protocol.AbilityDoorsAndSwitches: packet.ActionPermissionDoorsAndSwitches,
+					protocol.AbilityOpenContainers:   packet.ActionPermissionOpenContainers,
+					protocol.AbilityAttackPlayers:    packet.ActionPermissionAttackPlayers,
+					protocol.AbilityAttackMobs:       packet.ActionPermissionAttackMobs,
+					protocol.AbilityOperatorCommands: packet.ActionPermissionOperator,
+					protocol.AbilityBuild:            packet.ActionPermissionBuild,
 				}
 			}
-			result[i] = &legacypacket.ModalFormResponse{
-				FormID:       pk.FormID,
-				ResponseData: responseData,
-			}
-		case *packet.NetworkChunkPublisherUpdate:
-			result[i] = &legacypacket.NetworkChunkPublisherUpdate{
-				Position: pk.Position,
-				Radius:   pk.Radius,
-			}
-		case *packet.PlayerAction:
-			result[i] = &legacypacket.PlayerAction{
-				EntityRuntimeID: pk.EntityRuntimeID,
-				ActionType:      pk.ActionType,
-				BlockPosition:   pk.BlockPosition,
-				BlockFace:       pk.BlockFace,
-			}
-		case *packet.PlayerAuthInput:
-			result[i] = &legacypacket.PlayerAuthInput{
-				Pitch:         pk.Pitch,
-				Yaw:           pk.Yaw,
-				Position:      pk.Position,
-				MoveVector:    pk.MoveVector,
-				HeadYaw:       pk.HeadYaw,
-				InputData:     pk.InputData,
-				InputMode:     pk.InputMode,
-				PlayMode:      pk.PlayMode,
-				GazeDirection: pk.GazeDirection,
-				Tick:          pk.Tick,
-				Delta:         pk.Delta,
-				ItemInteractionData: func(data protocol.UseItemTransactionData) protocol.UseItemTransactionData {
-					data.LegacySetItemSlots = lo.Map(data.LegacySetItemSlots, func(item protocol.LegacySetItemSlot, _ int) protocol.LegacySetItemSlot {
-						if item.ContainerID > 21 { // RECIPE_BOOK
-							item.ContainerID -= 1
-						}
-						return item
-					})
-					return data
-				}(pk.ItemInteractionData),
-				ItemStackRequest: types.ItemStackRequest{ItemStackRequest: pk.ItemStackRequest},
-				BlockActions:     pk.BlockActions,
-			}
-		case *packet.PlayerList:
-			result[i] = &legacypacket.PlayerList{
-				ActionType: pk.ActionType,
-				Entries: lo.Map(pk.Entries, func(item protocol.PlayerListEntry, _ int) types.PlayerListEntry {
-					return types.PlayerListEntry{PlayerListEntry: item}
-				}),
-			}
-		case *packet.PlayerSkin:
-			result[i] = &legacypacket.PlayerSkin{
-				UUID:        pk.UUID,
-				Skin:        types.Skin{Skin: pk.Skin},
-				NewSkinName: pk.NewSkinName,
-				OldSkinName: pk.OldSkinName,
-			}
-		case *packet.RemoveVolumeEntity:
-			result[i] = &legacypacket.RemoveVolumeEntity{
-				EntityRuntimeID: pk.EntityRuntimeID,
-			}
-		case *packet.RequestChunkRadius:
-			result[i] = &legacypacket.RequestChunkRadius{
-				ChunkRadius: pk.ChunkRadius,
-			}
-		case *packet.SetActorData:
-			result[i] = &legacypacket.SetActorData{
-				EntityRuntimeID: pk.EntityRuntimeID,
-				EntityMetadata:  downgradeEntityMetadata(pk.EntityMetadata),
-				Tick:            pk.Tick,
-			}
-		case *packet.SpawnParticleEffect:
-			result[i] = &legacypacket.SpawnParticleEffect{
-				Dimension:      pk.Dimension,
-				EntityUniqueID: pk.EntityUniqueID,
-				Position:       pk.Position,
-				ParticleName:   pk.ParticleName,
-			}
-		case *packet.StartGame:
-			_, enabled := pk.ForceExperimentalGameplay.Value()
-			result[i] = &legacypacket.StartGame{
-				EntityUniqueID:                 pk.EntityUniqueID,
-				EntityRuntimeID:                pk.EntityRuntimeID,
-				PlayerGameMode:                 pk.PlayerGameMode,
-				PlayerPosition:                 pk.PlayerPosition,
-				Pitch:                          pk.Pitch,
-				Yaw:                            pk.Yaw,
-				WorldSeed:                      int32(pk.WorldSeed),
-				SpawnBiomeType:                 pk.SpawnBiomeType,
-				UserDefinedBiomeName:           pk.UserDefinedBiomeName,
-				Dimension:                      pk.Dimension,
-				Generator:                      pk.Generator,
-				WorldGameMode:                  pk.WorldGameMode,
-				Difficulty:                     pk.Difficulty,
-				WorldSpawn:                     pk.WorldSpawn,
-				AchievementsDisabled:           pk.AchievementsDisabled,
-				DayCycleLockTime:               pk.DayCycleLockTime,
-				EducationEditionOffer:          pk.EducationEditionOffer,
-				EducationFeaturesEnabled:       pk.EducationFeaturesEnabled,
-				EducationProductID:             pk.EducationProductID,
-				RainLevel:                      pk.RainLevel,
-				LightningLevel:                 pk.LightningLevel,
-				ConfirmedPlatformLockedContent: pk.ConfirmedPlatformLockedContent,
-				MultiPlayerGame:                pk.MultiPlayerGame,
-				LANBroadcastEnabled:            pk.LANBroadcastEnabled,
-				XBLBroadcastMode:               pk.XBLBroadcastMode,
-				PlatformBroadcastMode:          pk.PlatformBroadcastMode,
-				CommandsEnabled:                pk.CommandsEnabled,
-				TexturePackRequired:            pk.TexturePackRequired,
-				GameRules:                      pk.GameRules,
-				Experiments:                    pk.Experiments,
-				ExperimentsPreviouslyToggled:   pk.ExperimentsPreviouslyToggled,
-				BonusChestEnabled:              pk.BonusChestEnabled,
-				StartWithMapEnabled:            pk.StartWithMapEnabled,
-				PlayerPermissions:              pk.PlayerPermissions,
-				ServerChunkTickRadius:          pk.ServerChunkTickRadius,
-				HasLockedBehaviourPack:         pk.HasLockedBehaviourPack,
-				HasLockedTexturePack:           pk.HasLockedTexturePack,
-				FromLockedWorldTemplate:        pk.FromLockedWorldTemplate,
-				MSAGamerTagsOnly:               pk.MSAGamerTagsOnly,
-				FromWorldTemplate:              pk.FromWorldTemplate,
-				WorldTemplateSettingsLocked:    pk.WorldTemplateSettingsLocked,
-				OnlySpawnV1Villagers:           pk.OnlySpawnV1Villagers,
-				BaseGameVersion:                pk.BaseGameVersion,
-				LimitedWorldWidth:              pk.LimitedWorldWidth,
-				LimitedWorldDepth:              pk.LimitedWorldDepth,
-				NewNether:                      pk.NewNether,
-				EducationSharedResourceURI:     pk.EducationSharedResourceURI,
-				ForceExperimentalGameplay:      enabled,
-				LevelID:                        pk.LevelID,
-				WorldName:                      pk.WorldName,
-				TemplateContentIdentity:        pk.TemplateContentIdentity,
-				Trial:                          pk.Trial,
-				PlayerMovementSettings:         pk.PlayerMovementSettings,
-				Time:                           pk.Time,
-				EnchantmentSeed:                pk.EnchantmentSeed,
-				Blocks:                         pk.Blocks,
-				Items:                          pk.Items,
-				MultiPlayerCorrelationID:       pk.MultiPlayerCorrelationID,
-				ServerAuthoritativeInventory:   pk.ServerAuthoritativeInventory,
-				GameVersion:                    pk.GameVersion,
-				ServerBlockStateChecksum:       pk.ServerBlockStateChecksum,
-			}
-		case *packet.UpdateAttributes:
-			result[i] = &legacypacket.UpdateAttributes{
-				EntityRuntimeID: pk.EntityRuntimeID,
-				Attributes: lo.Map(pk.Attributes, func(item protocol.Attribute, _ int) types.Attribute {
-					return types.Attribute{Attribute: item}
-				}),
-				Tick: pk.Tick,
-			}
-		case *packet.StructureBlockUpdate:
-			result[i] = &legacypacket.StructureBlockUpdate{
-				Position:           pk.Position,
-				StructureName:      pk.StructureName,
-				DataField:          pk.DataField,
-				IncludePlayers:     pk.IncludePlayers,
-				ShowBoundingBox:    pk.ShowBoundingBox,
-				StructureBlockType: pk.StructureBlockType,
-				Settings:           types.StructureSettings{StructureSettings: pk.Settings},
-				RedstoneSaveMode:   pk.RedstoneSaveMode,
-				ShouldTrigger:      pk.ShouldTrigger,
-				Waterlogged:        pk.Waterlogged,
-			}
-		case *packet.StructureTemplateDataRequest:
-			result[i] = &legacypacket.StructureTemplateDataRequest{
-				StructureName: pk.StructureName,
-				Position:      pk.Position,
-				Settings:      types.StructureSettings{StructureSettings: pk.Settings},
-				RequestType:   pk.RequestType,
-			}
-		case *packet.UpdateAbilities:
-			handleFlag := func(layers []protocol.AbilityLayer, secondFlag bool) uint32 {
-				layerMapping := map[uint32]uint32{
-					protocol.AbilityAttackPlayers: packet.AdventureSettingsFlagsNoPvM,
-					protocol.AbilityMayFly:        packet.AdventureFlagAllowFlight,
-					protocol.AbilityNoClip:        packet.AdventureFlagNoClip,
-					protocol.AbilityWorldBuilder:  packet.AdventureFlagWorldBuilder,
-					protocol.AbilityFlying:        packet.AdventureFlagFlying,
-					protocol.AbilityMuted:         packet.AdventureFlagMuted,
-				}
-				if secondFlag {
-					layerMapping = map[uint32]uint32{
-						protocol.AbilityMine:             packet.ActionPermissionMine,
-						protocol.AbilityDoorsAndSwitches: packet.ActionPermissionDoorsAndSwitches,
-						protocol.AbilityOpenContainers:   packet.ActionPermissionOpenContainers,
-						protocol.AbilityAttackPlayers:    packet.ActionPermissionAttackPlayers,
-						protocol.AbilityAttackMobs:       packet.ActionPermissionAttackMobs,
-						protocol.AbilityOperatorCommands: packet.ActionPermissionOperator,
-						protocol.AbilityBuild:            packet.ActionPermissionBuild,
-					}
-				}
 
-				out := uint32(0)
-				for _, layer := range layers {
-					for flag, mapped := range layerMapping {
-						if (layer.Values & flag) != 0 {
-							out |= mapped
-						}
+			out := uint32(0)
+			for _, layer := range layers {
+				for flag, mapped := range layerMapping {
+					if (layer.Values & flag) != 0 {
+						out |= mapped
 					}
 				}
-				return out
 			}
+			return out
+		}
 
-			result[i] = &packet.AdventureSettings{
-				Flags:                  handleFlag(pk.AbilityData.Layers, false),
-				CommandPermissionLevel: uint32(pk.AbilityData.CommandPermissions),
-				ActionPermissions:      handleFlag(pk.AbilityData.Layers, true),
-				PermissionLevel:        uint32(pk.AbilityData.PlayerPermissions),
-				PlayerUniqueID:         pk.AbilityData.EntityUniqueID,
-			}
-		case *packet.Emote:
-			result[i] = &legacypacket_v582.Emote{
-				EntityRuntimeID: pk.EntityRuntimeID,
-				EmoteID:         pk.EmoteID,
-				Flags:           pk.Flags,
-			}
+		return []packet.Packet{&packet.AdventureSettings{
+			Flags:                  handleFlag(pk.AbilityData.Layers, false),
+			CommandPermissionLevel: uint32(pk.AbilityData.CommandPermissions),
+			ActionPermissions:      handleFlag(pk.AbilityData.Layers, true),
+			PermissionLevel:        uint32(pk.AbilityData.PlayerPermissions),
+			PlayerUniqueID:         pk.AbilityData.EntityUniqueID,
+		}}, true
+	case *packet.RequestAbility:
+		// Sent by the client when it starts or stops flying, which legacy servers receive as AdventureSettings.
+		if pk.Ability != packet.AbilityFlying {
+			return nil, false
 		}
+		settings := &packet.AdventureSettings{PlayerUniqueID: conn.GameData().EntityUniqueID}
+		if flying, _ := pk.Value.(bool); flying {
+			settings.Flags |= packet.AdventureFlagFlying
+		}
+		return []packet.Packet{settings}, true
 	}
-
-	return result
+	return nil, false
 }
@@ -0,0 +1,460 @@
+package v486_test
+
+import (
+	"github.com/flonja/multiversion/protocols/latest"
+	"github.com/flonja/multiversion/protocols/protocoltest"
+	v486 "github.com/flonja/multiversion/protocols/v486"
+	legacypacket "github.com/flonja/multiversion/protocols/v486/packet"
+	"github.com/flonja/multiversion/protocols/v486/types"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"testing"
+)
+
+func TestGolden(t *testing.T) {
+	protocoltest.Run(t, v486.New(),
+		protocoltest.Case{
+			Name: "add_actor",
+			Packet: &legacypacket.AddActor{
+				EntityUniqueID:  5,
+				EntityRuntimeID: 5,
+				EntityType:      "minecraft:pig",
+				Position:        mgl32.Vec3{4.5, 64, -1.5},
+				Yaw:             90,
+				HeadYaw:         90,
+				EntityMetadata:  map[uint32]any{},
+			},
+		},
+		protocoltest.Case{
+			Name: "add_player",
+			Packet: &legacypacket.AddPlayer{
+				UUID:            playerUUID,
+				Username:        "Steve",
+				EntityUniqueID:  7,
+				EntityRuntimeID: 7,
+				Position:        mgl32.Vec3{0.5, 65.62, 0.5},
+				Yaw:             45,
+				HeadYaw:         45,
+				// The goat horn count has a different key in this version.
+				EntityMetadata: map[uint32]any{123: int32(2)},
+				AdventureSettings: packet.AdventureSettings{
+					CommandPermissionLevel: packet.CommandPermissionLevelNormal,
+					PermissionLevel:        packet.PermissionLevelMember,
+					PlayerUniqueID:         7,
+				},
+				DeviceID: "device",
+			},
+			Latest: []packet.Packet{&packet.AddPlayer{
+				UUID:            playerUUID,
+				Username:        "Steve",
+				EntityRuntimeID: 7,
+				Position:        mgl32.Vec3{0.5, 65.62, 0.5},
+				Yaw:             45,
+				HeadYaw:         45,
+				GameType:        packet.GameTypeSurvival,
+				EntityMetadata:  map[uint32]any{protocol.EntityDataKeyGoatHornCount: int32(2)},
+				AbilityData: protocol.AbilityData{
+					EntityUniqueID:     7,
+					PlayerPermissions:  packet.PermissionLevelMember,
+					CommandPermissions: packet.CommandPermissionLevelNormal,
+					Layers:             []protocol.AbilityLayer{{Type: protocol.AbilityLayerTypeBase, Abilities: protocol.AbilityCount - 1}},
+				},
+				DeviceID:      "device",
+				BuildPlatform: int32(protocol.DeviceAndroid),
+			}},
+		},
+		protocoltest.Case{
+			Name: "add_volume_entity",
+			Packet: &legacypacket.AddVolumeEntity{
+				EntityRuntimeID:    9,
+				EntityMetadata:     map[string]any{"components": map[string]any{}},
+				EncodingIdentifier: "minecraft:volume",
+				InstanceIdentifier: "volume",
+				EngineVersion:      "1.18.12",
+			},
+			Latest: []packet.Packet{&packet.AddVolumeEntity{
+				EntityRuntimeID:    9,
+				EntityMetadata:     map[string]any{"components": map[string]any{}},
+				EncodingIdentifier: "minecraft:volume",
+				InstanceIdentifier: "volume",
+				EngineVersion:      "1.18.12",
+			}},
+		},
+		protocoltest.Case{
+			Name: "command_request",
+			Packet: &legacypacket.CommandRequest{
+				CommandLine:   "/say hi",
+				CommandOrigin: protocol.CommandOrigin{Origin: protocol.CommandOriginPlayer, UUID: playerUUID},
+			},
+			Latest: []packet.Packet{&packet.CommandRequest{
+				CommandLine:   "/say hi",
+				CommandOrigin: protocol.CommandOrigin{Origin: protocol.CommandOriginPlayer, UUID: playerUUID},
+			}},
+		},
+		protocoltest.Case{
+			Name:   "item_stack_request",
+			Packet: itemStackRequest(),
+			Latest: []packet.Packet{latestItemStackRequest()},
+		},
+		protocoltest.Case{
+			Name:     "item_stack_request_external",
+			Packet:   itemStackRequest(),
+			Latest:   []packet.Packet{latestItemStackRequest()},
+			External: true,
+		},
+		protocoltest.Case{
+			Name: "modal_form_response",
+			Packet: &legacypacket.ModalFormResponse{
+				FormID:       3,
+				ResponseData: []byte("[true]"),
+			},
+			Latest: []packet.Packet{&packet.ModalFormResponse{
+				FormID:       3,
+				ResponseData: protocol.Option([]byte("[true]")),
+			}},
+		},
+		protocoltest.Case{
+			Name: "modal_form_response_closed",
+			Packet: &legacypacket.ModalFormResponse{
+				FormID:       3,
+				ResponseData: []byte("null"),
+			},
+			Latest: []packet.Packet{&packet.ModalFormResponse{
+				FormID:       3,
+				CancelReason: protocol.Option[uint8](packet.ModalFormCancelReasonUserClosed),
+			}},
+		},
+		protocoltest.Case{
+			Name: "network_chunk_publisher_update",
+			Packet: &legacypacket.NetworkChunkPublisherUpdate{
+				Position: protocol.BlockPos{8, 64, -8},
+				Radius:   128,
+			},
+		},
+		protocoltest.Case{
+			Name: "player_action",
+			Packet: &legacypacket.PlayerAction{
+				EntityRuntimeID: 1,
+				ActionType:      protocol.PlayerActionStartBreak,
+				BlockPosition:   protocol.BlockPos{4, 63, -2},
+				BlockFace:       1,
+			},
+		},
+		protocoltest.Case{
+			Name:   "player_auth_input",
+			Packet: playerAuthInput(),
+		},
+		protocoltest.Case{
+			Name:     "player_auth_input_external",
+			Packet:   playerAuthInput(),
+			External: true,
+		},
+		protocoltest.Case{
+			Name: "player_list",
+			Packet: &legacypacket.PlayerList{
+				ActionType: packet.PlayerListActionAdd,
+				Entries: []types.PlayerListEntry{{PlayerListEntry: protocol.PlayerListEntry{
+					UUID:           playerUUID,
+					EntityUniqueID: 7,
+					Username:       "Steve",
+					XUID:           "2535400000000000",
+					BuildPlatform:  int32(protocol.DeviceWin10),
+					Skin:           skin,
+				}}},
+			},
+			Latest: []packet.Packet{&packet.PlayerList{
+				ActionType: packet.PlayerListActionAdd,
+				Entries: []protocol.PlayerListEntry{{
+					UUID:           playerUUID,
+					EntityUniqueID: 7,
+					Username:       "Steve",
+					XUID:           "2535400000000000",
+					BuildPlatform:  int32(protocol.DeviceWin10),
+					Skin:           skin,
+				}},
+			}},
+		},
+		protocoltest.Case{
+			Name: "player_skin",
+			Packet: &legacypacket.PlayerSkin{
+				UUID:        playerUUID,
+				Skin:        types.Skin{Skin: skin},
+				NewSkinName: "new",
+				OldSkinName: "old",
+			},
+			Latest: []packet.Packet{&packet.PlayerSkin{
+				UUID:        playerUUID,
+				Skin:        skin,
+				NewSkinName: "new",
+				OldSkinName: "old",
+			}},
+		},
+		protocoltest.Case{
+			Name:   "remove_volume_entity",
+			Packet: &legacypacket.RemoveVolumeEntity{EntityRuntimeID: 9},
+			Latest: []packet.Packet{&packet.RemoveVolumeEntity{EntityRuntimeID: 9}},
+		},
+		protocoltest.Case{
+			Name:   "request_chunk_radius",
+			Packet: &legacypacket.RequestChunkRadius{ChunkRadius: 12},
+			Latest: []packet.Packet{&packet.RequestChunkRadius{ChunkRadius: 12, MaxChunkRadius: 12}},
+		},
+		protocoltest.Case{
+			Name: "set_actor_data",
+			Packet: &legacypacket.SetActorData{
+				EntityRuntimeID: 5,
+				EntityMetadata:  map[uint32]any{123: int32(2)},
+				Tick:            1200,
+			},
+			Latest: []packet.Packet{&packet.SetActorData{
+				EntityRuntimeID: 5,
+				EntityMetadata:  map[uint32]any{protocol.EntityDataKeyGoatHornCount: int32(2)},
+				Tick:            1200,
+			}},
+		},
+		protocoltest.Case{
+			Name: "spawn_particle_effect",
+			Packet: &legacypacket.SpawnParticleEffect{
+				Dimension:      packet.DimensionOverworld,
+				EntityUniqueID: -1,
+				Position:       mgl32.Vec3{4.5, 65, -1.5},
+				ParticleName:   "minecraft:heart_particle",
+			},
+		},
+		protocoltest.Case{
+			// Items missing in this version are added as custom items when converting back, so the conversion is
+			// lossy.
+			Name:   "start_game",
+			Packet: startGame(),
+			OneWay: true,
+		},
+		protocoltest.Case{
+			Name:     "start_game_external",
+			Packet:   startGame(),
+			OneWay:   true,
+			External: true,
+		},
+		protocoltest.Case{
+			Name: "structure_block_update",
+			Packet: &legacypacket.StructureBlockUpdate{
+				Position:           protocol.BlockPos{4, 64, -2},
+				StructureName:      "house",
+				IncludePlayers:     true,
+				ShowBoundingBox:    true,
+				StructureBlockType: packet.StructureBlockSave,
+				Settings:           types.StructureSettings{StructureSettings: structureSettings},
+				RedstoneSaveMode:   packet.StructureRedstoneSaveModeMemory,
+			},
+			Latest: []packet.Packet{&packet.StructureBlockUpdate{
+				Position:           protocol.BlockPos{4, 64, -2},
+				StructureName:      "house",
+				IncludePlayers:     true,
+				ShowBoundingBox:    true,
+				StructureBlockType: packet.StructureBlockSave,
+				Settings:           structureSettings,
+				RedstoneSaveMode:   packet.StructureRedstoneSaveModeMemory,
+			}},
+		},
+		protocoltest.Case{
+			Name: "structure_template_data_request",
+			Packet: &legacypacket.StructureTemplateDataRequest{
+				StructureName: "house",
+				Position:      protocol.BlockPos{4, 64, -2},
+				Settings:      types.StructureSettings{StructureSettings: structureSettings},
+				RequestType:   packet.StructureTemplateRequestExportFromSave,
+			},
+			Latest: []packet.Packet{&packet.StructureTemplateDataRequest{
+				StructureName: "house",
+				Position:      protocol.BlockPos{4, 64, -2},
+				Settings:      structureSettings,
+				RequestType:   packet.StructureTemplateRequestExportFromSave,
+			}},
+		},
+		protocoltest.Case{
+			Name: "update_attributes",
+			Packet: &legacypacket.UpdateAttributes{
+				EntityRuntimeID: 1,
+				Attributes:      []types.Attribute{{Attribute: health}},
+				Tick:            1200,
+			},
+			Latest: []packet.Packet{&packet.UpdateAttributes{
+				EntityRuntimeID: 1,
+				Attributes:      []protocol.Attribute{health},
+				Tick:            1200,
+			}},
+		},
+	)
+}
+
+var (
+	playerUUID = uuid.MustParse("0fba4063-dba1-4281-9b89-ff9390653530")
+	// skin is a skin of 1x1 pixels, as the size of the data is validated.
+	skin = protocol.Skin{
+		SkinID:          "skin",
+		SkinImageWidth:  1,
+		SkinImageHeight: 1,
+		SkinData:        []byte{0xff, 0, 0, 0xff},
+		FullID:          "skin_full",
+		ArmSize:         "wide",
+		SkinColour:      "#0",
+		Trusted:         true,
+	}
+	structureSettings = protocol.StructureSettings{
+		PaletteName:               "default",
+		Size:                      protocol.BlockPos{5, 4, 5},
+		Offset:                    protocol.BlockPos{0, 1, 0},
+		LastEditingPlayerUniqueID: 7,
+		Integrity:                 100,
+		Pivot:                     mgl32.Vec3{2.5, 0, 2.5},
+	}
+	health = protocol.Attribute{
+		AttributeValue: protocol.AttributeValue{Name: "minecraft:health", Value: 20, Max: 20},
+		Default:        20,
+	}
+	consumed = protocol.DestroyStackRequestAction{Count: 1, Source: protocol.StackRequestSlotInfo{ContainerID: protocol.ContainerCraftingInput, Slot: 32, StackNetworkID: 4}}
+)
+
+// itemStackRequest returns a request taking two items out of the first hotbar slot and consuming an item in the
+// crafting grid.
+func itemStackRequest() *legacypacket.ItemStackRequest {
+	return &legacypacket.ItemStackRequest{Requests: []types.ItemStackRequest{{ItemStackRequest: protocol.ItemStackRequest{
+		RequestID: -3,
+		Actions: []protocol.StackRequestAction{
+			&types.TakeStackRequestAction{TakeStackRequestAction: takeAction()},
+			&types.ConsumeStackRequestAction{DestroyStackRequestAction: consumed},
+		},
+	}}}}
+}
+
+// latestItemStackRequest returns the request of itemStackRequest in the latest version.
+func latestItemStackRequest() *packet.ItemStackRequest {
+	return &packet.ItemStackRequest{Requests: []protocol.ItemStackRequest{{
+		RequestID: -3,
+		Actions: []protocol.StackRequestAction{
+			ptr(takeAction()),
+			&protocol.ConsumeStackRequestAction{DestroyStackRequestAction: consumed},
+		},
+	}}}
+}
+
+// playerAuthInput returns the input of a player using mouse and keyboard walking forward while falling.
+func playerAuthInput() *legacypacket.PlayerAuthInput {
+	return &legacypacket.PlayerAuthInput{
+		Pitch:      12.5,
+		Yaw:        -90,
+		Position:   mgl32.Vec3{10.5, 71.62, 3.25},
+		MoveVector: mgl32.Vec2{0, 1},
+		HeadYaw:    -90,
+		InputMode:  packet.InputModeMouse,
+		PlayMode:   packet.PlayModeNormal,
+		Tick:       1200,
+		Delta:      mgl32.Vec3{0, -0.08, 0.2},
+	}
+}
+
+// startGame returns the StartGame packet of a survival world without custom blocks or items.
+func startGame() *legacypacket.StartGame {
+	return &legacypacket.StartGame{
+		EntityUniqueID:  1,
+		EntityRuntimeID: 1,
+		PlayerGameMode:  packet.GameTypeSurvival,
+		PlayerPosition:  mgl32.Vec3{0.5, 65.62, 0.5},
+		WorldSeed:       1234,
+		Dimension:       packet.DimensionOverworld,
+		Generator:       1,
+		WorldGameMode:   packet.GameTypeSurvival,
+		Difficulty:      2,
+		WorldSpawn:      protocol.BlockPos{0, 64, 0},
+		GameRules:       []protocol.GameRule{{Name: "dodaylightcycle", Value: true}},
+		BaseGameVersion: "*",
+		LevelID:         "level",
+		WorldName:       "world",
+		PlayerMovementSettings: protocol.PlayerMovementSettings{
+			MovementType:                     protocol.PlayerMovementModeServer,
+			RewindHistorySize:                40,
+			ServerAuthoritativeBlockBreaking: true,
+		},
+		Time:                         6000,
+		MultiPlayerCorrelationID:     "correlation",
+		ServerAuthoritativeInventory: true,
+		GameVersion:                  "1.18.12",
+	}
+}
+
+// takeAction returns an action taking two items out of the first hotbar slot into the cursor.
+func takeAction() protocol.TakeStackRequestAction {
+	var a protocol.TakeStackRequestAction
+	a.Count = 2
+	a.Source = protocol.StackRequestSlotInfo{ContainerID: protocol.ContainerHotBar, StackNetworkID: 3}
+	a.Destination = protocol.StackRequestSlotInfo{ContainerID: protocol.ContainerCursor}
+	return a
+}
+
+// ptr returns a pointer to the value passed.
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestBiomeFallbacks(t *testing.T) {
+	biomes, latestBiomes := v486.NewBiomeMapping(), latest.NewBiomeMapping()
+	blockTranslator := v486.New().BlockTranslator()
+	for name, nearest := range map[string]string{
+		"deep_dark":      "dripstone_caves",
+		"mangrove_swamp": "swampland",
+		"cherry_grove":   "meadow",
+	} {
+		if _, ok := biomes.BiomeNameToID(name); ok {
+			t.Errorf("%v: expected the biome to be unknown to v486", name)
+		}
+		expected, ok := biomes.BiomeNameToID(nearest)
+		if !ok {
+			t.Fatalf("%v: fallback %v is unknown to v486", name, nearest)
+		}
+		if id := biomes.Nearest(name); id != expected {
+			t.Errorf("%v: expected nearest biome %v, got %v", name, expected, id)
+		}
+		latestID, ok := latestBiomes.BiomeNameToID(name)
+		if !ok {
+			t.Fatalf("%v: biome is unknown to the latest version", name)
+		}
+		if id := blockTranslator.DowngradeBiomeID(latestID); id != expected {
+			t.Errorf("%v: expected biome %v to be downgraded to %v, got %v", name, latestID, expected, id)
+		}
+	}
+
+	plains, _ := biomes.BiomeNameToID("plains")
+	if id := biomes.Nearest("minecraft:unknown"); id != plains {
+		t.Errorf("expected unknown biomes to fall back to plains (%v), got %v", plains, id)
+	}
+}
+
+func TestCommandArgTypes(t *testing.T) {
+	availableCommands := func(argTypes ...uint32) *packet.AvailableCommands {
+		overload := protocol.CommandOverload{}
+		for _, argType := range argTypes {
+			overload.Parameters = append(overload.Parameters, protocol.CommandParameter{Type: argType | protocol.CommandArgValid})
+		}
+		return &packet.AvailableCommands{Commands: []protocol.Command{{Name: "test", Overloads: []protocol.CommandOverload{overload}}}}
+	}
+	argTypes := func(pk *packet.AvailableCommands) (types []uint32) {
+		for _, parameter := range pk.Commands[0].Overloads[0].Parameters {
+			types = append(types, parameter.Type&^protocol.CommandArgValid)
+		}
+		return types
+	}
+	commands := v486.Commands()
+
+	downgraded := availableCommands(protocol.CommandArgTypeOperator, protocol.CommandArgTypeCompareOperator, protocol.CommandArgTypePosition, protocol.CommandArgTypeBlockPosition)
+	commands.DowngradeCommandPackets([]packet.Packet{downgraded}, nil)
+	if diffs := protocoltest.Diff([]uint32{protocol.CommandArgTypeOperator, protocol.CommandArgTypeOperator, 40, 40}, argTypes(downgraded)); len(diffs) != 0 {
+		t.Errorf("downgraded argument types: %v", diffs)
+	}
+
+	upgraded := availableCommands(protocol.CommandArgTypeOperator, 40)
+	commands.UpgradeCommandPackets([]packet.Packet{upgraded}, nil)
+	if diffs := protocoltest.Diff([]uint32{protocol.CommandArgTypeOperator, protocol.CommandArgTypePosition}, argTypes(upgraded)); len(diffs) != 0 {
+		t.Errorf("upgraded argument types: %v", diffs)
+	}
+}
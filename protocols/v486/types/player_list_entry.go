@@ -18,7 +18,9 @@ func (x *PlayerListEntry) Marshal(r protocol.IO) {
 	r.String(&x.XUID)
 	r.String(&x.PlatformChatID)
 	r.Int32(&x.BuildPlatform)
-	protocol.Single(r, &Skin{x.Skin})
+	skin := Skin{x.Skin}
+	protocol.Single(r, &skin)
+	x.Skin = skin.Skin
 	r.Bool(&x.Teacher)
 	r.Bool(&x.Host)
 }
@@ -86,12 +86,18 @@ func (a *AutoCraftRecipeStackRequestAction) Marshal(r protocol.IO) {
 }
 
 func StackReqSlotInfo(r protocol.IO, x *protocol.StackRequestSlotInfo) {
-	if _, ok := r.(interface{ Reads() bool }); !ok && x.ContainerID > 21 {
-		x.ContainerID -= 1
-	}
-	r.Uint8(&x.ContainerID)
-	if _, ok := r.(interface{ Reads() bool }); ok && x.ContainerID >= 21 { // RECIPE_BOOK
-		x.ContainerID += 1
+	if rd, ok := r.(interface{ Reads() bool }); ok && rd.Reads() {
+		r.Uint8(&x.ContainerID)
+		if x.ContainerID >= 21 { // RECIPE_BOOK
+			x.ContainerID += 1
+		}
+	} else {
+		// The ID is shifted on a copy, so that writing the same action twice doesn't change it.
+		containerID := x.ContainerID
+		if containerID > 21 {
+			containerID -= 1
+		}
+		r.Uint8(&containerID)
 	}
 	r.Uint8(&x.Slot)
 	r.Varint32(&x.StackNetworkID)
@@ -5,24 +5,6 @@ import (
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
 )
 
-// upgradeBlockActorData upgrades a block actor from a legacy version to the latest version.
-func upgradeBlockActorData(data map[string]any) map[string]any {
-	switch data["id"] {
-	case "Sign":
-		textRaw, ok := data["Text"]
-		if !ok {
-			textRaw = ""
-		}
-		text, ok := textRaw.(string)
-		if !ok {
-			text = ""
-		}
-		data["FrontText"] = map[string]any{"Text": text}
-		data["BackText"] = map[string]any{"Text": ""}
-	}
-	return data
-}
-
 // upgradeEntityMetadata upgrades entity metadata from legacy version to latest version.
 func upgradeEntityMetadata(data map[uint32]any) map[uint32]any {
 	newData := make(map[uint32]any)
@@ -51,6 +33,7 @@ func upgradeEntityMetadata(data map[uint32]any) map[uint32]any {
 		}
 		newData[key] = value
 	}
+	entityFlags.Upgrade(newData)
 	return newData
 }
 
@@ -60,5 +43,3 @@ func upgradeCraftingDescription(descriptor *types.DefaultItemDescriptor) protoco
 		MetadataValue: int16(descriptor.MetadataValue),
 	}
 }
-
-// TODO: add upgrade entity flags
@@ -4,19 +4,15 @@ import (
 	_ "embed"
 	"github.com/df-mc/worldupgrader/itemupgrader"
 	"github.com/flonja/multiversion/mapping"
-	"github.com/flonja/multiversion/packbuilder"
+	"github.com/flonja/multiversion/protocols/chain"
 	"github.com/flonja/multiversion/protocols/latest"
 	"github.com/flonja/multiversion/protocols/v582/items"
 	legacypacket "github.com/flonja/multiversion/protocols/v582/packet"
-	legacypacket_v589 "github.com/flonja/multiversion/protocols/v589/packet"
-	"github.com/flonja/multiversion/protocols/v589/types"
+	v589 "github.com/flonja/multiversion/protocols/v589"
+	v594packet "github.com/flonja/multiversion/protocols/v594/packet"
 	"github.com/flonja/multiversion/translator"
-	"github.com/samber/lo"
 	"github.com/sandertv/gophertunnel/minecraft"
-	"github.com/sandertv/gophertunnel/minecraft/protocol"
 	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
-	"github.com/sandertv/gophertunnel/minecraft/resource"
-	"golang.org/x/exp/maps"
 )
 
 var (
@@ -24,81 +20,121 @@ var (
 	itemRuntimeIDData []byte
 	//go:embed block_states.nbt
 	blockStateData []byte
+	//go:embed biome_ids.nbt
+	biomeIDData []byte
+	//go:embed entity_identifiers.nbt
+	entityIdentifierData []byte
 )
 
-type Protocol struct {
-	itemMapping     mapping.Item
-	blockMapping    mapping.Block
-	itemTranslator  translator.ItemTranslator
-	blockTranslator translator.BlockTranslator
-}
-
-func New() *Protocol {
-	itemMapping := mapping.NewItemMapping(itemRuntimeIDData)
-	blockMapping := mapping.NewBlockMapping(blockStateData)
+// New returns a minecraft.Protocol for v582, composed out of the steps returned by Steps.
+func New() *chain.Protocol {
+	itemMapping := NewItemMapping()
+	blockMapping := NewBlockMapping()
 	latestBlockMapping := latest.NewBlockMapping()
+	biomeMapping := mapping.NewBiomeMapping(biomeIDData)
+	soundMapping := mapping.NewSoundMapping(469).
+		WithSoundNamePrefix("trial_spawner.", "").
+		WithSoundNamePrefix("wind_charge.", "").
+		WithSoundNamePrefix("breeze.", "").
+		WithSoundNamePrefix("crafter.", "")
 
 	itemTranslator := translator.NewItemTranslator(itemMapping, latest.NewItemMapping(), blockMapping, latestBlockMapping)
 	itemTranslator.Register(items.DiscRelic{}, itemupgrader.ItemMeta{Name: "minecraft:music_disc_relic"})
-	return &Protocol{itemMapping: itemMapping, blockMapping: blockMapping,
-		itemTranslator:  itemTranslator,
-		blockTranslator: translator.NewBlockTranslator(blockMapping, latestBlockMapping)}
+	return chain.Chain(582, "1.19.83",
+		itemTranslator,
+		translator.NewBlockTranslator(blockMapping, latestBlockMapping, biomeMapping, latest.NewBiomeMapping()),
+		translator.NewEntityTranslator(mapping.NewEntityMapping(entityIdentifierData), latest.NewEntityMapping(), nil),
+		Steps()...,
+	).WithSoundTranslator(translator.NewSoundTranslator(soundMapping)).
+		WithLevelEventTranslator(translator.NewLevelEventTranslator(LevelEvents())).
+		WithCommandTranslator(Commands()).
+		WithBlockFallbacks(BlockFallbacks()).
+		WithItemFallbacks(ItemFallbacks()).
+		WithItemNBTTransformers(ItemNBTTransformers())
 }
 
-func (p Protocol) ResourcePack(ver string) *resource.Pack {
-	resourcePack, ok := packbuilder.BuildResourcePack(maps.Values(p.itemTranslator.CustomItems()), ver)
-	if !ok {
-		panic("couldn't create resource pack")
-	}
-	return resourcePack
+// NewBlockMapping returns the block mapping of v582.
+func NewBlockMapping() *mapping.DefaultBlockMapping {
+	return mapping.NewBlockMapping(blockStateData).
+		WithBlockActorTransformers(mapping.DefaultBlockActorTransformers().ForProtocol(582))
 }
 
-func (Protocol) ID() int32 {
-	return 582
+// NewItemMapping returns the item mapping of v582.
+func NewItemMapping() *mapping.DefaultItemMapping {
+	return mapping.NewItemMapping(itemRuntimeIDData)
 }
 
-func (Protocol) Ver() string {
-	return "1.19.83"
+// Steps returns the steps required to convert between v582 and the latest protocol version, ordered from the oldest
+// to the newest step.
+func Steps() []chain.ProtocolStep {
+	return append([]chain.ProtocolStep{Step{}}, v589.Steps()...)
 }
 
-func (Protocol) Packets(_ bool) packet.Pool {
-	pool := packet.NewClientPool()
-	for k, v := range packet.NewServerPool() {
-		pool[k] = v
-	}
-	pool[packet.IDAvailableCommands] = func() packet.Packet { return &legacypacket_v589.AvailableCommands{} }
-	pool[packet.IDEmote] = func() packet.Packet { return &legacypacket.Emote{} }
-	pool[packet.IDStartGame] = func() packet.Packet { return &legacypacket.StartGame{} }
-	pool[packet.IDUnlockedRecipes] = func() packet.Packet { return &legacypacket.UnlockedRecipes{} }
-	return pool
+// BlockFallbacks returns the rules used to find the nearest block of v582 for blocks unknown to it, including those
+// unknown to the protocol versions that follow it.
+func BlockFallbacks() *mapping.BlockFallbacks {
+	return v589.BlockFallbacks().With(
+		mapping.BlockFallback{Name: "minecraft:*sculk_sensor", TargetProperties: map[string]any{"sculk_sensor_phase": int32(0)}},
+		mapping.BlockFallback{Name: "minecraft:pitcher_crop", Drop: []string{"upper_block_bit"}, Target: "minecraft:wheat"},
+		mapping.BlockFallback{Name: "minecraft:pitcher_plant", Target: "minecraft:double_plant", TargetProperties: map[string]any{"double_plant_type": "sunflower"}},
+		mapping.BlockFallback{Name: "minecraft:sniffer_egg", Target: "minecraft:turtle_egg", TargetProperties: map[string]any{"turtle_egg_count": "one_egg"}},
+	)
 }
 
-func (Protocol) Encryption(key [32]byte) packet.Encryption {
-	return packet.NewCTREncryption(key[:])
+// ItemFallbacks returns the rules used to find the nearest item of v582 for items unknown to it, including those
+// unknown to the protocol versions that follow it.
+func ItemFallbacks() *mapping.ItemFallbacks {
+	return v589.ItemFallbacks().With(
+		mapping.ItemFallback{Name: "minecraft:*_pottery_sherd", Target: "minecraft:brick"},
+		mapping.ItemFallback{Name: "minecraft:pitcher_pod", Target: "minecraft:wheat_seeds"},
+		mapping.ItemFallback{Name: "minecraft:pitcher_plant", Target: "minecraft:double_plant"},
+		mapping.ItemFallback{Name: "minecraft:sniffer_egg", Target: "minecraft:turtle_egg"},
+		mapping.ItemFallback{Name: "minecraft:music_disc_relic", Target: "minecraft:music_disc_13"},
+	)
 }
 
-func (Protocol) NewReader(r minecraft.ByteReader, shieldID int32, enableLimits bool) protocol.IO {
-	return protocol.NewReader(r, shieldID, enableLimits)
+// ItemNBTTransformers returns the transformers of the NBT of item stacks of which the format differs in v582, including
+// those of the protocol versions that follow it.
+func ItemNBTTransformers() *translator.ItemNBTTransformers {
+	return v589.ItemNBTTransformers()
 }
 
-func (Protocol) NewWriter(w minecraft.ByteWriter, shieldID int32) protocol.IO {
-	return protocol.NewWriter(w, shieldID)
+// LevelEvents returns the mapping of the level events and particles unknown to v582, including those unknown to the
+// protocol versions that follow it.
+func LevelEvents() *mapping.DefaultLevelEventMapping {
+	return v589.LevelEvents()
+}
+
+// Commands returns the translator of the commands of v582, including the changes of the protocol versions that
+// follow it.
+func Commands() *translator.DefaultCommandTranslator {
+	return v589.Commands()
+}
+
+// Step is the chain.ProtocolStep holding the packets changed in v582.
+type Step struct{}
+
+// Packets ...
+func (Step) Packets(pool packet.Pool) {
+	pool[packet.IDEmote] = func() packet.Packet { return &legacypacket.Emote{} }
+	pool[packet.IDStartGame] = func() packet.Packet { return &legacypacket.StartGame{} }
+	pool[packet.IDUnlockedRecipes] = func() packet.Packet { return &legacypacket.UnlockedRecipes{} }
 }
 
-func (p Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []packet.Packet {
-	var newPks []packet.Packet
+// Upgrade ...
+func (Step) Upgrade(pk packet.Packet, conn *minecraft.Conn) ([]packet.Packet, bool) {
 	switch pk := pk.(type) {
-	case *packet.ClientCacheStatus:
-		pk.Enabled = false
-		newPks = append(newPks, pk)
 	case *legacypacket.Emote:
-		newPks = append(newPks, &packet.Emote{
+		emote := &packet.Emote{
 			EntityRuntimeID: pk.EntityRuntimeID,
 			EmoteID:         pk.EmoteID,
-			XUID:            conn.IdentityData().XUID,
-			PlatformID:      conn.ClientData().PlatformOnlineID,
 			Flags:           pk.Flags,
-		})
+		}
+		if !translator.ClientSide(conn) {
+			// Emotes sent by a legacy server may be of any player, so the XUID is only known for legacy clients.
+			emote.XUID, emote.PlatformID = conn.IdentityData().XUID, conn.ClientData().PlatformOnlineID
+		}
+		return []packet.Packet{emote}, true
 	case *legacypacket.StartGame:
 		// todon't: figure out what to do when there are no custom items
 		//if len(lo.Filter(pk.Items, func(item protocol.ItemEntry, _ int) bool {
@@ -117,11 +153,7 @@ func (p Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 		//		}(),
 		//	})
 		//}
-		editorWorldType := int32(packet.EditorWorldTypeNotEditor)
-		if pk.EditorWorld {
-			editorWorldType = packet.EditorWorldTypeProject
-		}
-		newPks = append(newPks, &packet.StartGame{
+		return []packet.Packet{&v594packet.StartGame{
 			EntityUniqueID:                 pk.EntityUniqueID,
 			EntityRuntimeID:                pk.EntityRuntimeID,
 			PlayerGameMode:                 pk.PlayerGameMode,
@@ -137,7 +169,7 @@ func (p Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 			Difficulty:                     pk.Difficulty,
 			WorldSpawn:                     pk.WorldSpawn,
 			AchievementsDisabled:           pk.AchievementsDisabled,
-			EditorWorldType:                editorWorldType,
+			EditorWorld:                    pk.EditorWorld,
 			CreatedInEditor:                pk.CreatedInEditor,
 			ExportedFromEditor:             pk.ExportedFromEditor,
 			DayCycleLockTime:               pk.DayCycleLockTime,
@@ -196,190 +228,137 @@ func (p Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 			DisablePlayerInteractions:      pk.DisablePlayerInteractions,
 			UseBlockNetworkIDHashes:        pk.UseBlockNetworkIDHashes,
 			ServerAuthoritativeSound:       false,
-		})
+		}}, true
 	case *legacypacket.UnlockedRecipes:
 		unlockType := packet.UnlockedRecipesTypeInitiallyUnlocked
 		if pk.NewUnlocks {
 			unlockType = packet.UnlockedRecipesTypeNewlyUnlocked
 		}
 
-		newPks = append(newPks,
+		return []packet.Packet{
 			&packet.UnlockedRecipes{
 				UnlockType: packet.UnlockedRecipesTypeRemoveAllUnlocked,
 			},
 			&packet.UnlockedRecipes{
 				UnlockType: uint32(unlockType),
 				Recipes:    pk.Recipes,
-			})
-	case *legacypacket_v589.AvailableCommands:
-		newPks = append(newPks, &packet.AvailableCommands{
-			EnumValues: pk.EnumValues,
-			Suffixes:   pk.Suffixes,
-			Enums:      pk.Enums,
-			Commands: lo.Map(pk.Commands, func(item types.Command, _ int) protocol.Command {
-				return protocol.Command{
-					Name:            item.Name,
-					Description:     item.Description,
-					Flags:           item.Flags,
-					PermissionLevel: item.PermissionLevel,
-					AliasesOffset:   item.AliasesOffset,
-					Overloads: lo.Map(item.Overloads, func(item types.CommandOverload, _ int) protocol.CommandOverload {
-						return protocol.CommandOverload{
-							Parameters: item.Parameters,
-							Chaining:   false,
-						}
-					}),
-				}
-			}),
-			DynamicEnums: pk.DynamicEnums,
-			Constraints:  pk.Constraints,
-		})
-	default:
-		newPks = append(newPks, pk)
+			},
+		}, true
 	}
-	return p.blockTranslator.UpgradeBlockPackets(p.itemTranslator.UpgradeItemPackets(newPks, conn), conn)
+	return nil, false
 }
 
-func (p Protocol) ConvertFromLatest(pk packet.Packet, conn *minecraft.Conn) (result []packet.Packet) {
-	result = p.blockTranslator.DowngradeBlockPackets(p.itemTranslator.DowngradeItemPackets([]packet.Packet{pk}, conn), conn)
-
-	for i, pk := range result {
-		switch pk := pk.(type) {
-		case *packet.Emote:
-			result[i] = &legacypacket.Emote{
-				EntityRuntimeID: pk.EntityRuntimeID,
-				EmoteID:         pk.EmoteID,
-				Flags:           pk.Flags,
-			}
-		case *packet.StartGame:
-			// todon't: figure out what to do when there are no custom items
-			//if len(lo.Filter(pk.Items, func(item protocol.ItemEntry, _ int) bool {
-			//	return item.ComponentBased
-			//})) == 0 {
-			//pks = append(pks, &packet.ItemComponent{
-			//	Items: func() (entries []protocol.ItemComponentEntry) {
-			//		for _, item := range p.itemMapping.CustomItems() {
-			//			name, _ := item.EncodeItem()
-			//			entries = append(entries, protocol.ItemComponentEntry{
-			//				Name: name,
-			//				Data: packbuilder.Components(item),
-			//			})
-			//		}
-			//		return entries
-			//	}(),
-			//})
-			//}
-			editorWorld := false
-			if pk.EditorWorldType > packet.EditorWorldTypeNotEditor {
-				editorWorld = true
-			}
-			result[i] = &legacypacket.StartGame{
-				EntityUniqueID:                 pk.EntityUniqueID,
-				EntityRuntimeID:                pk.EntityRuntimeID,
-				PlayerGameMode:                 pk.PlayerGameMode,
-				PlayerPosition:                 pk.PlayerPosition,
-				Pitch:                          pk.Pitch,
-				Yaw:                            pk.Yaw,
-				WorldSeed:                      pk.WorldSeed,
-				SpawnBiomeType:                 pk.SpawnBiomeType,
-				UserDefinedBiomeName:           pk.UserDefinedBiomeName,
-				Dimension:                      pk.Dimension,
-				Generator:                      pk.Generator,
-				WorldGameMode:                  pk.WorldGameMode,
-				Difficulty:                     pk.Difficulty,
-				WorldSpawn:                     pk.WorldSpawn,
-				AchievementsDisabled:           pk.AchievementsDisabled,
-				EditorWorld:                    editorWorld,
-				CreatedInEditor:                pk.CreatedInEditor,
-				ExportedFromEditor:             pk.ExportedFromEditor,
-				DayCycleLockTime:               pk.DayCycleLockTime,
-				EducationEditionOffer:          pk.EducationEditionOffer,
-				EducationFeaturesEnabled:       pk.EducationFeaturesEnabled,
-				EducationProductID:             pk.EducationProductID,
-				RainLevel:                      pk.RainLevel,
-				LightningLevel:                 pk.LightningLevel,
-				ConfirmedPlatformLockedContent: pk.ConfirmedPlatformLockedContent,
-				MultiPlayerGame:                pk.MultiPlayerGame,
-				LANBroadcastEnabled:            pk.LANBroadcastEnabled,
-				XBLBroadcastMode:               pk.XBLBroadcastMode,
-				PlatformBroadcastMode:          pk.PlatformBroadcastMode,
-				CommandsEnabled:                pk.CommandsEnabled,
-				TexturePackRequired:            pk.TexturePackRequired,
-				GameRules:                      pk.GameRules,
-				Experiments:                    pk.Experiments,
-				ExperimentsPreviouslyToggled:   pk.ExperimentsPreviouslyToggled,
-				BonusChestEnabled:              pk.BonusChestEnabled,
-				StartWithMapEnabled:            pk.StartWithMapEnabled,
-				PlayerPermissions:              pk.PlayerPermissions,
-				ServerChunkTickRadius:          pk.ServerChunkTickRadius,
-				HasLockedBehaviourPack:         pk.HasLockedBehaviourPack,
-				HasLockedTexturePack:           pk.HasLockedTexturePack,
-				FromLockedWorldTemplate:        pk.FromLockedWorldTemplate,
-				MSAGamerTagsOnly:               pk.MSAGamerTagsOnly,
-				FromWorldTemplate:              pk.FromWorldTemplate,
-				WorldTemplateSettingsLocked:    pk.WorldTemplateSettingsLocked,
-				OnlySpawnV1Villagers:           pk.OnlySpawnV1Villagers,
-				PersonaDisabled:                pk.PersonaDisabled,
-				CustomSkinsDisabled:            pk.CustomSkinsDisabled,
-				EmoteChatMuted:                 pk.EmoteChatMuted,
-				BaseGameVersion:                pk.BaseGameVersion,
-				LimitedWorldWidth:              pk.LimitedWorldWidth,
-				LimitedWorldDepth:              pk.LimitedWorldDepth,
-				NewNether:                      pk.NewNether,
-				EducationSharedResourceURI:     pk.EducationSharedResourceURI,
-				ForceExperimentalGameplay:      pk.ForceExperimentalGameplay,
-				LevelID:                        pk.LevelID,
-				WorldName:                      pk.WorldName,
-				TemplateContentIdentity:        pk.TemplateContentIdentity,
-				Trial:                          pk.Trial,
-				PlayerMovementSettings:         pk.PlayerMovementSettings,
-				Time:                           pk.Time,
-				EnchantmentSeed:                pk.EnchantmentSeed,
-				Blocks:                         pk.Blocks,
-				Items:                          pk.Items,
-				MultiPlayerCorrelationID:       pk.MultiPlayerCorrelationID,
-				ServerAuthoritativeInventory:   pk.ServerAuthoritativeInventory,
-				GameVersion:                    pk.GameVersion,
-				PropertyData:                   pk.PropertyData,
-				ServerBlockStateChecksum:       pk.ServerBlockStateChecksum,
-				ClientSideGeneration:           pk.ClientSideGeneration,
-				WorldTemplateID:                pk.WorldTemplateID,
-				ChatRestrictionLevel:           pk.ChatRestrictionLevel,
-				DisablePlayerInteractions:      pk.DisablePlayerInteractions,
-				UseBlockNetworkIDHashes:        pk.UseBlockNetworkIDHashes,
-			}
-		case *packet.UnlockedRecipes:
-			newUnlocks := false
-			if pk.UnlockType == packet.UnlockedRecipesTypeInitiallyUnlocked || pk.UnlockType == packet.UnlockedRecipesTypeNewlyUnlocked {
-				newUnlocks = true
-			}
-			result[i] = &legacypacket.UnlockedRecipes{
-				NewUnlocks: newUnlocks,
-				Recipes:    pk.Recipes,
-			}
-		case *packet.AvailableCommands:
-			result[i] = &legacypacket_v589.AvailableCommands{
-				EnumValues: pk.EnumValues,
-				Suffixes:   pk.Suffixes,
-				Enums:      pk.Enums,
-				Commands: lo.Map(pk.Commands, func(item protocol.Command, _ int) types.Command {
-					return types.Command{
-						Name:            item.Name,
-						Description:     item.Description,
-						Flags:           item.Flags,
-						PermissionLevel: item.PermissionLevel,
-						AliasesOffset:   item.AliasesOffset,
-						Overloads: lo.Map(item.Overloads, func(item protocol.CommandOverload, _ int) types.CommandOverload {
-							return types.CommandOverload{
-								Parameters: item.Parameters,
-							}
-						}),
-					}
-				}),
-				DynamicEnums: pk.DynamicEnums,
-				Constraints:  pk.Constraints,
-			}
+// Downgrade ...
+func (Step) Downgrade(pk packet.Packet, _ *minecraft.Conn) ([]packet.Packet, bool) {
+	switch pk := pk.(type) {
+	case *packet.Emote:
+		return []packet.Packet{&legacypacket.Emote{
+			EntityRuntimeID: pk.EntityRuntimeID,
+			EmoteID:         pk.EmoteID,
+			Flags:           pk.Flags,
+		}}, true
+	case *v594packet.StartGame:
+		// todon't: figure out what to do when there are no custom items
+		//if len(lo.Filter(pk.Items, func(item protocol.ItemEntry, _ int) bool {
+		//	return item.ComponentBased
+		//})) == 0 {
+		//pks = append(pks, &packet.ItemComponent{
+		//	Items: func() (entries []protocol.ItemComponentEntry) {
+		//		for _, item := range p.itemMapping.CustomItems() {
+		//			name, _ := item.EncodeItem()
+		//			entries = append(entries, protocol.ItemComponentEntry{
+		//				Name: name,
+		//				Data: packbuilder.Components(item),
+		//			})
+		//		}
+		//		return entries
+		//	}(),
+		//})
+		//}
+		return []packet.Packet{&legacypacket.StartGame{
+			EntityUniqueID:                 pk.EntityUniqueID,
+			EntityRuntimeID:                pk.EntityRuntimeID,
+			PlayerGameMode:                 pk.PlayerGameMode,
+			PlayerPosition:                 pk.PlayerPosition,
+			Pitch:                          pk.Pitch,
+			Yaw:                            pk.Yaw,
+			WorldSeed:                      pk.WorldSeed,
+			SpawnBiomeType:                 pk.SpawnBiomeType,
+			UserDefinedBiomeName:           pk.UserDefinedBiomeName,
+			Dimension:                      pk.Dimension,
+			Generator:                      pk.Generator,
+			WorldGameMode:                  pk.WorldGameMode,
+			Difficulty:                     pk.Difficulty,
+			WorldSpawn:                     pk.WorldSpawn,
+			AchievementsDisabled:           pk.AchievementsDisabled,
+			EditorWorld:                    pk.EditorWorld,
+			CreatedInEditor:                pk.CreatedInEditor,
+			ExportedFromEditor:             pk.ExportedFromEditor,
+			DayCycleLockTime:               pk.DayCycleLockTime,
+			EducationEditionOffer:          pk.EducationEditionOffer,
+			EducationFeaturesEnabled:       pk.EducationFeaturesEnabled,
+			EducationProductID:             pk.EducationProductID,
+			RainLevel:                      pk.RainLevel,
+			LightningLevel:                 pk.LightningLevel,
+			ConfirmedPlatformLockedContent: pk.ConfirmedPlatformLockedContent,
+			MultiPlayerGame:                pk.MultiPlayerGame,
+			LANBroadcastEnabled:            pk.LANBroadcastEnabled,
+			XBLBroadcastMode:               pk.XBLBroadcastMode,
+			PlatformBroadcastMode:          pk.PlatformBroadcastMode,
+			CommandsEnabled:                pk.CommandsEnabled,
+			TexturePackRequired:            pk.TexturePackRequired,
+			GameRules:                      pk.GameRules,
+			Experiments:                    pk.Experiments,
+			ExperimentsPreviouslyToggled:   pk.ExperimentsPreviouslyToggled,
+			BonusChestEnabled:              pk.BonusChestEnabled,
+			StartWithMapEnabled:            pk.StartWithMapEnabled,
+			PlayerPermissions:              pk.PlayerPermissions,
+			ServerChunkTickRadius:          pk.ServerChunkTickRadius,
+			HasLockedBehaviourPack:         pk.HasLockedBehaviourPack,
+			HasLockedTexturePack:           pk.HasLockedTexturePack,
+			FromLockedWorldTemplate:        pk.FromLockedWorldTemplate,
+			MSAGamerTagsOnly:               pk.MSAGamerTagsOnly,
+			FromWorldTemplate:              pk.FromWorldTemplate,
+			WorldTemplateSettingsLocked:    pk.WorldTemplateSettingsLocked,
+			OnlySpawnV1Villagers:           pk.OnlySpawnV1Villagers,
+			PersonaDisabled:                pk.PersonaDisabled,
+			CustomSkinsDisabled:            pk.CustomSkinsDisabled,
+			EmoteChatMuted:                 pk.EmoteChatMuted,
+			BaseGameVersion:                pk.BaseGameVersion,
+			LimitedWorldWidth:              pk.LimitedWorldWidth,
+			LimitedWorldDepth:              pk.LimitedWorldDepth,
+			NewNether:                      pk.NewNether,
+			EducationSharedResourceURI:     pk.EducationSharedResourceURI,
+			ForceExperimentalGameplay:      pk.ForceExperimentalGameplay,
+			LevelID:                        pk.LevelID,
+			WorldName:                      pk.WorldName,
+			TemplateContentIdentity:        pk.TemplateContentIdentity,
+			Trial:                          pk.Trial,
+			PlayerMovementSettings:         pk.PlayerMovementSettings,
+			Time:                           pk.Time,
+			EnchantmentSeed:                pk.EnchantmentSeed,
+			Blocks:                         pk.Blocks,
+			Items:                          pk.Items,
+			MultiPlayerCorrelationID:       pk.MultiPlayerCorrelationID,
+			ServerAuthoritativeInventory:   pk.ServerAuthoritativeInventory,
+			GameVersion:                    pk.GameVersion,
+			PropertyData:                   pk.PropertyData,
+			ServerBlockStateChecksum:       pk.ServerBlockStateChecksum,
+			ClientSideGeneration:           pk.ClientSideGeneration,
+			WorldTemplateID:                pk.WorldTemplateID,
+			ChatRestrictionLevel:           pk.ChatRestrictionLevel,
+			DisablePlayerInteractions:      pk.DisablePlayerInteractions,
+			UseBlockNetworkIDHashes:        pk.UseBlockNetworkIDHashes,
+		}}, true
+	case *packet.UnlockedRecipes:
+		newUnlocks := false
+		if pk.UnlockType == packet.UnlockedRecipesTypeInitiallyUnlocked || pk.UnlockType == packet.UnlockedRecipesTypeNewlyUnlocked {
+			newUnlocks = true
 		}
+		return []packet.Packet{&legacypacket.UnlockedRecipes{
+			NewUnlocks: newUnlocks,
+			Recipes:    pk.Recipes,
+		}}, true
 	}
-	return result
+	return nil, false
 }
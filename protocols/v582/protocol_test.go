@@ -0,0 +1,69 @@
+package v582_test
+
+import (
+	"github.com/flonja/multiversion/protocols/protocoltest"
+	v582 "github.com/flonja/multiversion/protocols/v582"
+	legacypacket "github.com/flonja/multiversion/protocols/v582/packet"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"testing"
+)
+
+func TestGolden(t *testing.T) {
+	protocoltest.Run(t, v582.New(),
+		protocoltest.Case{
+			Name: "emote",
+			Packet: &legacypacket.Emote{
+				EntityRuntimeID: 1,
+				EmoteID:         "4c8ae710-df2e-47cd-814d-cc7bf21a3d67",
+				Flags:           packet.EmoteFlagServerSide,
+			},
+		},
+		protocoltest.Case{
+			// Items missing in this version are added as custom items when converting back, so the conversion is
+			// lossy.
+			Name: "start_game",
+			Packet: &legacypacket.StartGame{
+				EntityUniqueID:  1,
+				EntityRuntimeID: 1,
+				PlayerGameMode:  packet.GameTypeSurvival,
+				PlayerPosition:  mgl32.Vec3{0.5, 65.62, 0.5},
+				WorldSeed:       1234,
+				Dimension:       packet.DimensionOverworld,
+				Generator:       1,
+				WorldGameMode:   packet.GameTypeSurvival,
+				Difficulty:      2,
+				WorldSpawn:      protocol.BlockPos{0, 64, 0},
+				GameRules:       []protocol.GameRule{{Name: "dodaylightcycle", Value: true}},
+				BaseGameVersion: "*",
+				LevelID:         "level",
+				WorldName:       "world",
+				PlayerMovementSettings: protocol.PlayerMovementSettings{
+					MovementType:                     protocol.PlayerMovementModeServer,
+					RewindHistorySize:                40,
+					ServerAuthoritativeBlockBreaking: true,
+				},
+				Time:                         6000,
+				MultiPlayerCorrelationID:     "correlation",
+				ServerAuthoritativeInventory: true,
+				GameVersion:                  "1.20.0",
+				PropertyData:                 map[string]any{},
+			},
+			OneWay: true,
+		},
+		protocoltest.Case{
+			// The recipes replace all recipes unlocked before, which takes two packets in the latest protocol.
+			Name: "unlocked_recipes",
+			Packet: &legacypacket.UnlockedRecipes{
+				NewUnlocks: true,
+				Recipes:    []string{"minecraft:crafting_table"},
+			},
+			Latest: []packet.Packet{
+				&packet.UnlockedRecipes{UnlockType: packet.UnlockedRecipesTypeRemoveAllUnlocked},
+				&packet.UnlockedRecipes{UnlockType: packet.UnlockedRecipesTypeNewlyUnlocked, Recipes: []string{"minecraft:crafting_table"}},
+			},
+			OneWay: true,
+		},
+	)
+}
@@ -3,9 +3,11 @@ package v589
 import (
 	_ "embed"
 	"github.com/flonja/multiversion/mapping"
+	"github.com/flonja/multiversion/protocols/chain"
 	"github.com/flonja/multiversion/protocols/latest"
 	legacypacket "github.com/flonja/multiversion/protocols/v589/packet"
 	"github.com/flonja/multiversion/protocols/v589/types"
+	v594 "github.com/flonja/multiversion/protocols/v594"
 	"github.com/flonja/multiversion/translator"
 	"github.com/samber/lo"
 	"github.com/sandertv/gophertunnel/minecraft"
@@ -18,59 +20,98 @@ var (
 	itemRuntimeIDData []byte
 	//go:embed block_states.nbt
 	blockStateData []byte
+	//go:embed biome_ids.nbt
+	biomeIDData []byte
+	//go:embed entity_identifiers.nbt
+	entityIdentifierData []byte
 )
 
-// Protocol Deprecated due to Mojang not supporting <1.20
-type Protocol struct {
-	itemMapping     mapping.Item
-	blockMapping    mapping.Block
-	itemTranslator  translator.ItemTranslator
-	blockTranslator translator.BlockTranslator
+// New returns a minecraft.Protocol for v589, composed out of the steps returned by Steps.
+//
+// Deprecated: Mojang does not support versions older than 1.20.
+func New() *chain.Protocol {
+	itemMapping := NewItemMapping()
+	blockMapping := NewBlockMapping()
+	latestBlockMapping := latest.NewBlockMapping()
+	biomeMapping := mapping.NewBiomeMapping(biomeIDData)
+	soundMapping := mapping.NewSoundMapping(470).
+		WithSoundNamePrefix("trial_spawner.", "").
+		WithSoundNamePrefix("wind_charge.", "").
+		WithSoundNamePrefix("breeze.", "").
+		WithSoundNamePrefix("crafter.", "")
+	return chain.Chain(589, "1.20.1",
+		translator.NewItemTranslator(itemMapping, latest.NewItemMapping(), blockMapping, latestBlockMapping),
+		translator.NewBlockTranslator(blockMapping, latestBlockMapping, biomeMapping, latest.NewBiomeMapping()),
+		translator.NewEntityTranslator(mapping.NewEntityMapping(entityIdentifierData), latest.NewEntityMapping(), nil),
+		Steps()...,
+	).WithSoundTranslator(translator.NewSoundTranslator(soundMapping)).
+		WithLevelEventTranslator(translator.NewLevelEventTranslator(LevelEvents())).
+		WithCommandTranslator(Commands()).
+		WithBlockFallbacks(BlockFallbacks()).
+		WithItemFallbacks(ItemFallbacks()).
+		WithItemNBTTransformers(ItemNBTTransformers())
 }
 
-func New() *Protocol {
-	itemMapping := mapping.NewItemMapping(itemRuntimeIDData)
-	blockMapping := mapping.NewBlockMapping(blockStateData)
-	latestBlockMapping := latest.NewBlockMapping()
-	return &Protocol{itemMapping: itemMapping, blockMapping: blockMapping,
-		itemTranslator:  translator.NewItemTranslator(itemMapping, latest.NewItemMapping(), blockMapping, latestBlockMapping),
-		blockTranslator: translator.NewBlockTranslator(blockMapping, latestBlockMapping)}
+// NewBlockMapping returns the block mapping of v589.
+func NewBlockMapping() *mapping.DefaultBlockMapping {
+	return mapping.NewBlockMapping(blockStateData).
+		WithBlockActorTransformers(mapping.DefaultBlockActorTransformers().ForProtocol(589))
 }
 
-func (p Protocol) ID() int32 {
-	return 589
+// NewItemMapping returns the item mapping of v589.
+func NewItemMapping() *mapping.DefaultItemMapping {
+	return mapping.NewItemMapping(itemRuntimeIDData)
 }
 
-func (p Protocol) Ver() string {
-	return "1.20.1"
+// Steps returns the steps required to convert between v589 and the latest protocol version, ordered from the oldest
+// to the newest step.
+func Steps() []chain.ProtocolStep {
+	return append([]chain.ProtocolStep{Step{}}, v594.Steps()...)
 }
 
-func (Protocol) Packets(_ bool) packet.Pool {
-	pool := packet.NewClientPool()
-	for k, v := range packet.NewServerPool() {
-		pool[k] = v
-	}
-	pool[packet.IDAvailableCommands] = func() packet.Packet { return &legacypacket.AvailableCommands{} }
-	return pool
+// BlockFallbacks returns the rules used to find the nearest block of v589 for blocks unknown to it, including those
+// unknown to the protocol versions that follow it.
+func BlockFallbacks() *mapping.BlockFallbacks {
+	return v594.BlockFallbacks()
 }
 
-func (Protocol) Encryption(key [32]byte) packet.Encryption {
-	return packet.NewCTREncryption(key[:])
+// ItemFallbacks returns the rules used to find the nearest item of v589 for items unknown to it, including those
+// unknown to the protocol versions that follow it.
+func ItemFallbacks() *mapping.ItemFallbacks {
+	return v594.ItemFallbacks()
 }
 
-func (Protocol) NewReader(r minecraft.ByteReader, shieldID int32, enableLimits bool) protocol.IO {
-	return protocol.NewReader(r, shieldID, enableLimits)
+// ItemNBTTransformers returns the transformers of the NBT of item stacks of which the format differs in v589, including
+// those of the protocol versions that follow it.
+func ItemNBTTransformers() *translator.ItemNBTTransformers {
+	return v594.ItemNBTTransformers()
 }
 
-func (Protocol) NewWriter(w minecraft.ByteWriter, shieldID int32) protocol.IO {
-	return protocol.NewWriter(w, shieldID)
+// LevelEvents returns the mapping of the level events and particles unknown to v589, including those unknown to the
+// protocol versions that follow it.
+func LevelEvents() *mapping.DefaultLevelEventMapping {
+	return v594.LevelEvents()
 }
 
-func (p Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []packet.Packet {
-	var newPks []packet.Packet
+// Commands returns the translator of the commands of v589, including the changes of the protocol versions that
+// follow it. Commands of v589 cannot be chained.
+func Commands() *translator.DefaultCommandTranslator {
+	return v594.Commands().WithoutChaining()
+}
+
+// Step is the chain.ProtocolStep holding the packets changed in v589.
+type Step struct{}
+
+// Packets ...
+func (Step) Packets(pool packet.Pool) {
+	pool[packet.IDAvailableCommands] = func() packet.Packet { return &legacypacket.AvailableCommands{} }
+}
+
+// Upgrade ...
+func (Step) Upgrade(pk packet.Packet, _ *minecraft.Conn) ([]packet.Packet, bool) {
 	switch pk := pk.(type) {
 	case *legacypacket.AvailableCommands:
-		newPks = append(newPks, &packet.AvailableCommands{
+		return []packet.Packet{&packet.AvailableCommands{
 			EnumValues: pk.EnumValues,
 			Suffixes:   pk.Suffixes,
 			Enums:      pk.Enums,
@@ -91,43 +132,36 @@ func (p Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 			}),
 			DynamicEnums: pk.DynamicEnums,
 			Constraints:  pk.Constraints,
-		})
-	default:
-		newPks = append(newPks, pk)
+		}}, true
 	}
-
-	return p.blockTranslator.UpgradeBlockPackets(p.itemTranslator.UpgradeItemPackets(newPks, conn), conn)
+	return nil, false
 }
 
-func (p Protocol) ConvertFromLatest(pk packet.Packet, conn *minecraft.Conn) (result []packet.Packet) {
-	result = p.blockTranslator.DowngradeBlockPackets(p.itemTranslator.DowngradeItemPackets([]packet.Packet{pk}, conn), conn)
-
-	for i, pk := range result {
-		switch pk := pk.(type) {
-		case *packet.AvailableCommands:
-			result[i] = &legacypacket.AvailableCommands{
-				EnumValues: pk.EnumValues,
-				Suffixes:   pk.Suffixes,
-				Enums:      pk.Enums,
-				Commands: lo.Map(pk.Commands, func(item protocol.Command, _ int) types.Command {
-					return types.Command{
-						Name:            item.Name,
-						Description:     item.Description,
-						Flags:           item.Flags,
-						PermissionLevel: item.PermissionLevel,
-						AliasesOffset:   item.AliasesOffset,
-						Overloads: lo.Map(item.Overloads, func(item protocol.CommandOverload, _ int) types.CommandOverload {
-							return types.CommandOverload{
-								Parameters: item.Parameters,
-							}
-						}),
-					}
-				}),
-				DynamicEnums: pk.DynamicEnums,
-				Constraints:  pk.Constraints,
-			}
-		}
+// Downgrade ...
+func (Step) Downgrade(pk packet.Packet, _ *minecraft.Conn) ([]packet.Packet, bool) {
+	switch pk := pk.(type) {
+	case *packet.AvailableCommands:
+		return []packet.Packet{&legacypacket.AvailableCommands{
+			EnumValues: pk.EnumValues,
+			Suffixes:   pk.Suffixes,
+			Enums:      pk.Enums,
+			Commands: lo.Map(pk.Commands, func(item protocol.Command, _ int) types.Command {
+				return types.Command{
+					Name:            item.Name,
+					Description:     item.Description,
+					Flags:           item.Flags,
+					PermissionLevel: item.PermissionLevel,
+					AliasesOffset:   item.AliasesOffset,
+					Overloads: lo.Map(item.Overloads, func(item protocol.CommandOverload, _ int) types.CommandOverload {
+						return types.CommandOverload{
+							Parameters: item.Parameters,
+						}
+					}),
+				}
+			}),
+			DynamicEnums: pk.DynamicEnums,
+			Constraints:  pk.Constraints,
+		}}, true
 	}
-
-	return result
+	return nil, false
 }
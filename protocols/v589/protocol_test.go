@@ -0,0 +1,33 @@
+package v589_test
+
+import (
+	"github.com/flonja/multiversion/protocols/protocoltest"
+	v589 "github.com/flonja/multiversion/protocols/v589"
+	legacypacket "github.com/flonja/multiversion/protocols/v589/packet"
+	"github.com/flonja/multiversion/protocols/v589/types"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"testing"
+)
+
+func TestGolden(t *testing.T) {
+	protocoltest.Run(t, v589.New(),
+		protocoltest.Case{
+			Name: "available_commands",
+			Packet: &legacypacket.AvailableCommands{
+				EnumValues: []string{"survival", "creative"},
+				Enums:      []protocol.CommandEnum{{Type: "GameMode", ValueIndices: []uint{0, 1}}},
+				Commands: []types.Command{{
+					Name:          "gamemode",
+					Description:   "Sets a player's game mode.",
+					AliasesOffset: 0xffffffff,
+					Overloads: []types.CommandOverload{{
+						Parameters: []protocol.CommandParameter{
+							{Name: "gameMode", Type: protocol.CommandArgValid | protocol.CommandArgEnum},
+							{Name: "player", Type: protocol.CommandArgValid | protocol.CommandArgTypeTarget, Optional: true},
+						},
+					}},
+				}},
+			},
+		},
+	)
+}
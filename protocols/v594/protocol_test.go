@@ -0,0 +1,128 @@
+package v594_test
+
+import (
+	"github.com/flonja/multiversion/protocols/protocoltest"
+	v594 "github.com/flonja/multiversion/protocols/v594"
+	legacypacket "github.com/flonja/multiversion/protocols/v594/packet"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"image/color"
+	"testing"
+)
+
+func TestGolden(t *testing.T) {
+	protocoltest.Run(t, v594.New(),
+		protocoltest.Case{
+			Name: "camera_instruction_clear",
+			Packet: &legacypacket.CameraInstruction{
+				Data: map[string]any{"clear": byte(1)},
+			},
+			Latest: []packet.Packet{&packet.CameraInstruction{
+				Clear: protocol.Option(true),
+			}},
+		},
+		protocoltest.Case{
+			Name: "camera_instruction_fade",
+			Packet: &legacypacket.CameraInstruction{
+				Data: map[string]any{"fade": map[string]any{
+					"time":  map[string]any{"fadeIn": float32(0.5), "hold": float32(1), "fadeOut": float32(0.5)},
+					"color": map[string]any{"red": float32(1), "green": float32(0), "blue": float32(0)},
+				}},
+			},
+			Latest: []packet.Packet{&packet.CameraInstruction{
+				Fade: protocol.Option(protocol.CameraInstructionFade{
+					TimeData: protocol.Option(protocol.CameraFadeTimeData{FadeInDuration: 0.5, WaitDuration: 1, FadeOutDuration: 0.5}),
+					Colour:   protocol.Option(color.RGBA{R: 0xff}),
+				}),
+			}},
+		},
+		protocoltest.Case{
+			Name: "camera_instruction_set",
+			Packet: &legacypacket.CameraInstruction{
+				Data: map[string]any{"set": map[string]any{
+					"preset":  int32(1),
+					"ease":    map[string]any{"type": "linear", "time": float32(2)},
+					"pos":     map[string]any{"pos": []any{float32(0.5), float32(80), float32(-3)}},
+					"rot":     map[string]any{"x": float32(30), "y": float32(90)},
+					"facing":  map[string]any{"facing": []any{float32(2), float32(64), float32(2)}},
+					"default": byte(1),
+				}},
+			},
+			Latest: []packet.Packet{&packet.CameraInstruction{
+				Set: protocol.Option(protocol.CameraInstructionSet{
+					Preset:   1,
+					Ease:     protocol.Option(protocol.CameraEase{Type: protocol.EasingTypeLinear, Duration: 2}),
+					Position: protocol.Option(mgl32.Vec3{0.5, 80, -3}),
+					Rotation: protocol.Option(mgl32.Vec2{30, 90}),
+					Facing:   protocol.Option(mgl32.Vec3{2, 64, 2}),
+					Default:  protocol.Option(true),
+				}),
+			}},
+		},
+		protocoltest.Case{
+			Name: "camera_presets",
+			Packet: &legacypacket.CameraPresets{
+				Data: map[string]any{"presets": []any{map[string]any{
+					"identifier":   "minecraft:free",
+					"inherit_from": "",
+					"pos_y":        float32(80),
+				}}},
+			},
+			Latest: []packet.Packet{&packet.CameraPresets{
+				Presets: []protocol.CameraPreset{{
+					Name: "minecraft:free",
+					PosY: protocol.Option[float32](80),
+				}},
+			}},
+		},
+		protocoltest.Case{
+			Name: "camera_presets_not_compound",
+			Packet: &legacypacket.CameraPresets{
+				Data: map[string]any{"presets": []any{float32(1)}},
+			},
+			Latest: []packet.Packet{&packet.CameraPresets{}},
+			OneWay: true,
+		},
+		protocoltest.Case{
+			Name: "resource_packs_info",
+			Packet: &legacypacket.ResourcePacksInfo{
+				TexturePackRequired: true,
+				TexturePacks: []protocol.TexturePackInfo{{
+					UUID:    "0fba4063-dba1-4281-9b89-ff9390653530",
+					Version: "1.0.0",
+					Size:    1024,
+				}},
+			},
+		},
+		protocoltest.Case{
+			Name: "start_game",
+			Packet: &legacypacket.StartGame{
+				EntityUniqueID:  1,
+				EntityRuntimeID: 1,
+				PlayerGameMode:  packet.GameTypeSurvival,
+				PlayerPosition:  mgl32.Vec3{0.5, 65.62, 0.5},
+				WorldSeed:       1234,
+				Dimension:       packet.DimensionOverworld,
+				Generator:       1,
+				WorldGameMode:   packet.GameTypeSurvival,
+				Difficulty:      2,
+				WorldSpawn:      protocol.BlockPos{0, 64, 0},
+				GameRules:       []protocol.GameRule{{Name: "dodaylightcycle", Value: true}},
+				BaseGameVersion: "*",
+				LevelID:         "level",
+				WorldName:       "world",
+				PlayerMovementSettings: protocol.PlayerMovementSettings{
+					MovementType:                     protocol.PlayerMovementModeServer,
+					RewindHistorySize:                40,
+					ServerAuthoritativeBlockBreaking: true,
+				},
+				Time:                         6000,
+				MultiPlayerCorrelationID:     "correlation",
+				ServerAuthoritativeInventory: true,
+				GameVersion:                  "1.20.30",
+				PropertyData:                 map[string]any{},
+			},
+		},
+	)
+}
@@ -0,0 +1,32 @@
+package v618_test
+
+import (
+	"github.com/flonja/multiversion/protocols/protocoltest"
+	v618 "github.com/flonja/multiversion/protocols/v618"
+	legacypacket "github.com/flonja/multiversion/protocols/v618/packet"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"testing"
+)
+
+func TestGolden(t *testing.T) {
+	protocoltest.Run(t, v618.New(),
+		protocoltest.Case{
+			Name: "disconnect",
+			Packet: &legacypacket.Disconnect{
+				Message: "Server closed",
+			},
+			Latest: []packet.Packet{&packet.Disconnect{
+				Message: "Server closed",
+			}},
+		},
+		protocoltest.Case{
+			Name: "disconnect_hidden",
+			Packet: &legacypacket.Disconnect{
+				HideDisconnectionScreen: true,
+			},
+			Latest: []packet.Packet{&packet.Disconnect{
+				HideDisconnectionScreen: true,
+			}},
+		},
+	)
+}
@@ -0,0 +1,35 @@
+package v622_test
+
+import (
+	"github.com/flonja/multiversion/protocols/protocoltest"
+	v622 "github.com/flonja/multiversion/protocols/v622"
+	legacypacket "github.com/flonja/multiversion/protocols/v622/packet"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"testing"
+)
+
+func TestGolden(t *testing.T) {
+	protocoltest.Run(t, v622.New(),
+		protocoltest.Case{
+			Name: "show_store_offer",
+			Packet: &legacypacket.ShowStoreOffer{
+				OfferID: "offer",
+			},
+			Latest: []packet.Packet{&packet.ShowStoreOffer{
+				OfferID: "offer",
+				Type:    packet.StoreOfferTypeMarketplace,
+			}},
+		},
+		protocoltest.Case{
+			Name: "show_store_offer_show_all",
+			Packet: &legacypacket.ShowStoreOffer{
+				OfferID: "offer",
+				ShowAll: true,
+			},
+			Latest: []packet.Packet{&packet.ShowStoreOffer{
+				OfferID: "offer",
+				Type:    packet.StoreOfferTypeServerPage,
+			}},
+		},
+	)
+}
@@ -0,0 +1,101 @@
+package v630_test
+
+import (
+	"github.com/flonja/multiversion/protocols/protocoltest"
+	v630 "github.com/flonja/multiversion/protocols/v630"
+	legacypacket "github.com/flonja/multiversion/protocols/v630/packet"
+	"github.com/flonja/multiversion/protocols/v630/types"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"testing"
+)
+
+func TestGolden(t *testing.T) {
+	protocoltest.Run(t, v630.New(),
+		protocoltest.Case{
+			Name: "correct_player_move_prediction",
+			Packet: &legacypacket.CorrectPlayerMovePrediction{
+				Position: mgl32.Vec3{10.5, 70, 3.25},
+				Delta:    mgl32.Vec3{0, -0.08, 0},
+				OnGround: true,
+				Tick:     1200,
+			},
+		},
+		protocoltest.Case{
+			// The sub chunks of the chunk are requested separately, so the payload only holds the border blocks.
+			Name: "level_chunk",
+			Packet: &legacypacket.LevelChunk{
+				Position:        protocol.ChunkPos{-2, 5},
+				HighestSubChunk: 8,
+				SubChunkCount:   protocol.SubChunkRequestModeLimited,
+				RawPayload:      []byte{0},
+			},
+		},
+		protocoltest.Case{
+			Name: "player_auth_input",
+			Packet: &legacypacket.PlayerAuthInput{
+				Pitch:              12.5,
+				Yaw:                -90,
+				Position:           mgl32.Vec3{10.5, 71.62, 3.25},
+				MoveVector:         mgl32.Vec2{0, 1},
+				HeadYaw:            -90,
+				InputMode:          packet.InputModeMouse,
+				PlayMode:           packet.PlayModeNormal,
+				Tick:               1200,
+				Delta:              mgl32.Vec3{0, -0.08, 0.2},
+				AnalogueMoveVector: mgl32.Vec2{0, 1},
+			},
+		},
+		protocoltest.Case{
+			Name: "player_list_add",
+			Packet: &legacypacket.PlayerList{
+				ActionType: packet.PlayerListActionAdd,
+				Entries: []types.PlayerListEntry{{
+					UUID:           playerUUID,
+					EntityUniqueID: 7,
+					Username:       "Steve",
+					XUID:           "2535400000000000",
+					BuildPlatform:  int32(protocol.DeviceWin10),
+					Skin:           skin,
+					Host:           true,
+				}},
+			},
+			Latest: []packet.Packet{&packet.PlayerList{
+				ActionType: packet.PlayerListActionAdd,
+				Entries: []protocol.PlayerListEntry{{
+					UUID:           playerUUID,
+					EntityUniqueID: 7,
+					Username:       "Steve",
+					XUID:           "2535400000000000",
+					BuildPlatform:  int32(protocol.DeviceWin10),
+					Skin:           skin,
+					Host:           true,
+				}},
+			}},
+		},
+		protocoltest.Case{
+			Name: "player_list_remove",
+			Packet: &legacypacket.PlayerList{
+				ActionType: packet.PlayerListActionRemove,
+				Entries:    []types.PlayerListEntry{{UUID: playerUUID}},
+			},
+		},
+	)
+}
+
+var (
+	playerUUID = uuid.MustParse("0fba4063-dba1-4281-9b89-ff9390653530")
+	skin       = protocol.Skin{
+		SkinID:          "skin",
+		PlayFabID:       "playfab",
+		SkinImageWidth:  1,
+		SkinImageHeight: 1,
+		SkinData:        []byte{0xff, 0, 0, 0xff},
+		FullID:          "skin_full",
+		ArmSize:         "wide",
+		SkinColour:      "#0",
+		Trusted:         true,
+	}
+)
@@ -0,0 +1,96 @@
+package v649_test
+
+import (
+	"github.com/flonja/multiversion/protocols/protocoltest"
+	v649 "github.com/flonja/multiversion/protocols/v649"
+	legacypacket "github.com/flonja/multiversion/protocols/v649/packet"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"testing"
+)
+
+func TestGolden(t *testing.T) {
+	protocoltest.Run(t, v649.New(),
+		protocoltest.Case{
+			Name: "lectern_update",
+			Packet: &legacypacket.LecternUpdate{
+				Page:      2,
+				PageCount: 5,
+				Position:  protocol.BlockPos{3, 64, -8},
+			},
+			Latest: []packet.Packet{&packet.LecternUpdate{
+				Page:      2,
+				PageCount: 5,
+				Position:  protocol.BlockPos{3, 64, -8},
+			}},
+		},
+		protocoltest.Case{
+			Name: "lectern_update_drop_book",
+			Packet: &legacypacket.LecternUpdate{
+				Position: protocol.BlockPos{3, 64, -8},
+				DropBook: true,
+			},
+			Latest: []packet.Packet{},
+			OneWay: true,
+		},
+		protocoltest.Case{
+			Name: "mob_effect",
+			Packet: &legacypacket.MobEffect{
+				EntityRuntimeID: 1,
+				Operation:       packet.MobEffectAdd,
+				EffectType:      packet.EffectSpeed,
+				Amplifier:       1,
+				Particles:       true,
+				Duration:        600,
+			},
+			Latest: []packet.Packet{&packet.MobEffect{
+				EntityRuntimeID: 1,
+				Operation:       packet.MobEffectAdd,
+				EffectType:      packet.EffectSpeed,
+				Amplifier:       1,
+				Particles:       true,
+				Duration:        600,
+			}},
+		},
+		protocoltest.Case{
+			Name: "player_auth_input",
+			Packet: &legacypacket.PlayerAuthInput{
+				Pitch:                  12.5,
+				Yaw:                    -90,
+				Position:               mgl32.Vec3{10.5, 71.62, 3.25},
+				MoveVector:             mgl32.Vec2{0, 1},
+				HeadYaw:                -90,
+				InputMode:              packet.InputModeMouse,
+				PlayMode:               packet.PlayModeNormal,
+				Tick:                   1200,
+				Delta:                  mgl32.Vec3{0, -0.08, 0.2},
+				ClientPredictedVehicle: 0,
+				AnalogueMoveVector:     mgl32.Vec2{0, 1},
+			},
+		},
+		protocoltest.Case{
+			Name: "resource_packs_info",
+			Packet: &legacypacket.ResourcePacksInfo{
+				TexturePackRequired: true,
+				TexturePacks: []protocol.TexturePackInfo{{
+					UUID:    "0fba4063-dba1-4281-9b89-ff9390653530",
+					Version: "1.0.0",
+					Size:    1024,
+				}},
+				PackURLs: []protocol.PackURL{{UUIDVersion: "0fba4063-dba1-4281-9b89-ff9390653530_1.0.0", URL: "https://example.com/pack.zip"}},
+			},
+		},
+		protocoltest.Case{
+			Name: "set_actor_motion",
+			Packet: &legacypacket.SetActorMotion{
+				EntityRuntimeID: 4,
+				Velocity:        mgl32.Vec3{0.1, 0.4, -0.1},
+			},
+			Latest: []packet.Packet{&packet.SetActorMotion{
+				EntityRuntimeID: 4,
+				Velocity:        mgl32.Vec3{0.1, 0.4, -0.1},
+			}},
+		},
+	)
+}
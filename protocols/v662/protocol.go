@@ -3,6 +3,7 @@ package v662
 import (
 	_ "embed"
 	"github.com/flonja/multiversion/mapping"
+	"github.com/flonja/multiversion/protocols/chain"
 	"github.com/flonja/multiversion/protocols/latest"
 	legacypacket "github.com/flonja/multiversion/protocols/v662/packet"
 	"github.com/flonja/multiversion/translator"
@@ -16,37 +17,90 @@ var (
 	itemRuntimeIDData []byte
 	//go:embed block_states.nbt
 	blockStateData []byte
+	//go:embed biome_ids.nbt
+	biomeIDData []byte
+	//go:embed entity_identifiers.nbt
+	entityIdentifierData []byte
 )
 
-type Protocol struct {
-	itemMapping     mapping.Item
-	blockMapping    mapping.Block
-	itemTranslator  translator.ItemTranslator
-	blockTranslator translator.BlockTranslator
+// New returns a minecraft.Protocol for v662, composed out of the steps returned by Steps.
+func New() *chain.Protocol {
+	itemMapping := NewItemMapping()
+	blockMapping := NewBlockMapping()
+	latestBlockMapping := latest.NewBlockMapping()
+	biomeMapping := mapping.NewBiomeMapping(biomeIDData)
+	return chain.Chain(662, "1.20.73",
+		translator.NewItemTranslator(itemMapping, latest.NewItemMapping(), blockMapping, latestBlockMapping),
+		translator.NewBlockTranslator(blockMapping, latestBlockMapping, biomeMapping, latest.NewBiomeMapping()),
+		translator.NewEntityTranslator(mapping.NewEntityMapping(entityIdentifierData), latest.NewEntityMapping(), nil),
+		Steps()...,
+	).WithLevelEventTranslator(translator.NewLevelEventTranslator(LevelEvents())).
+		WithBlockFallbacks(BlockFallbacks()).
+		WithItemFallbacks(ItemFallbacks()).
+		WithItemNBTTransformers(ItemNBTTransformers())
 }
 
-func New() *Protocol {
-	itemMapping := mapping.NewItemMapping(itemRuntimeIDData)
-	blockMapping := mapping.NewBlockMapping(blockStateData)
-	latestBlockMapping := latest.NewBlockMapping()
-	return &Protocol{itemMapping: itemMapping, blockMapping: blockMapping,
-		itemTranslator:  translator.NewItemTranslator(itemMapping, latest.NewItemMapping(), blockMapping, latestBlockMapping),
-		blockTranslator: translator.NewBlockTranslator(blockMapping, latestBlockMapping)}
+// NewBlockMapping returns the block mapping of v662.
+func NewBlockMapping() *mapping.DefaultBlockMapping {
+	return mapping.NewBlockMapping(blockStateData).
+		WithBlockActorTransformers(mapping.DefaultBlockActorTransformers().ForProtocol(662))
 }
 
-func (p Protocol) ID() int32 {
-	return 662
+// NewItemMapping returns the item mapping of v662.
+func NewItemMapping() *mapping.DefaultItemMapping {
+	return mapping.NewItemMapping(itemRuntimeIDData)
 }
 
-func (p Protocol) Ver() string {
-	return "1.20.73"
+// Steps returns the steps required to convert between v662 and the latest protocol version, ordered from the oldest
+// to the newest step.
+func Steps() []chain.ProtocolStep {
+	return []chain.ProtocolStep{Step{}}
 }
 
-func (Protocol) Packets(_ bool) packet.Pool {
-	pool := packet.NewClientPool()
-	for k, v := range packet.NewServerPool() {
-		pool[k] = v
-	}
+// BlockFallbacks returns the rules used to find the nearest block of v662 for blocks unknown to it, including those
+// unknown to the protocol versions that follow it.
+func BlockFallbacks() *mapping.BlockFallbacks {
+	return mapping.NewBlockFallbacks(
+		mapping.BlockFallback{Name: "minecraft:heavy_core", Target: "minecraft:iron_block"},
+	)
+}
+
+// ItemFallbacks returns the rules used to find the nearest item of v662 for items unknown to it, including those
+// unknown to the protocol versions that follow it.
+func ItemFallbacks() *mapping.ItemFallbacks {
+	return mapping.NewItemFallbacks(
+		mapping.ItemFallback{Name: "minecraft:mace", Target: "minecraft:iron_sword"},
+		mapping.ItemFallback{Name: "minecraft:heavy_core", Target: "minecraft:iron_block"},
+		mapping.ItemFallback{Name: "minecraft:breeze_rod", Target: "minecraft:blaze_rod"},
+		mapping.ItemFallback{Name: "minecraft:flow_pottery_sherd", Target: "minecraft:brick"},
+		mapping.ItemFallback{Name: "minecraft:guster_pottery_sherd", Target: "minecraft:brick"},
+		mapping.ItemFallback{Name: "minecraft:scrape_pottery_sherd", Target: "minecraft:brick"},
+		mapping.ItemFallback{Name: "minecraft:flow_banner_pattern", Target: "minecraft:paper"},
+		mapping.ItemFallback{Name: "minecraft:guster_banner_pattern", Target: "minecraft:paper"},
+		mapping.ItemFallback{Name: "minecraft:*_armor_trim_smithing_template", Target: "minecraft:netherite_upgrade_smithing_template"},
+	)
+}
+
+// ItemNBTTransformers returns the transformers of the NBT of item stacks of which the format differs in v662, including
+// those of the protocol versions that follow it.
+func ItemNBTTransformers() *translator.ItemNBTTransformers {
+	return translator.NewItemNBTTransformers().
+		With(translator.NewEnchantmentRemover(38, 39, 40)). // wind burst, density and breach
+		With(translator.NewTrimRemover("flow", "bolt"))
+}
+
+// LevelEvents returns the mapping of the level events and particles unknown to v662, including those unknown to the
+// protocol versions that follow it.
+func LevelEvents() *mapping.DefaultLevelEventMapping {
+	return mapping.NewLevelEventMapping().
+		WithMissingLevelEvents(packet.LevelEventAnimationVaultActivate, packet.LevelEventAnimationVaultDeactivate, packet.LevelEventAnimationVaultEjectItem)
+}
+
+// Step is the chain.ProtocolStep holding the packets changed in v662.
+type Step struct{}
+
+// Packets ...
+func (Step) Packets(pool packet.Pool) {
 	pool[packet.IDClientBoundDebugRenderer] = func() packet.Packet { return &legacypacket.ClientBoundDebugRenderer{} }
 	pool[packet.IDCorrectPlayerMovePrediction] = func() packet.Packet { return &legacypacket.CorrectPlayerMovePrediction{} }
 	pool[packet.IDPlayerAuthInput] = func() packet.Packet { return &legacypacket.PlayerAuthInput{} }
@@ -54,27 +108,23 @@ func (Protocol) Packets(_ bool) packet.Pool {
 	pool[packet.IDStartGame] = func() packet.Packet { return &legacypacket.StartGame{} }
 	pool[packet.IDUpdateBlockSynced] = func() packet.Packet { return &legacypacket.UpdateBlockSynced{} }
 	pool[packet.IDUpdatePlayerGameType] = func() packet.Packet { return &legacypacket.UpdatePlayerGameType{} }
-	return pool
-}
-
-func (Protocol) Encryption(key [32]byte) packet.Encryption {
-	return packet.NewCTREncryption(key[:])
 }
 
-func (Protocol) NewReader(r minecraft.ByteReader, shieldID int32, enableLimits bool) protocol.IO {
-	return NewReader(protocol.NewReader(r, shieldID, enableLimits))
+// NewReader ...
+func (Step) NewReader(r *protocol.Reader) protocol.IO {
+	return NewReader(r)
 }
 
-func (Protocol) NewWriter(w minecraft.ByteWriter, shieldID int32) protocol.IO {
-	return NewWriter(protocol.NewWriter(w, shieldID))
+// NewWriter ...
+func (Step) NewWriter(w *protocol.Writer) protocol.IO {
+	return NewWriter(w)
 }
 
-func (p Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []packet.Packet {
-	var newPks []packet.Packet
-
+// Upgrade ...
+func (Step) Upgrade(pk packet.Packet, _ *minecraft.Conn) ([]packet.Packet, bool) {
 	switch pk := pk.(type) {
 	case *legacypacket.ClientBoundDebugRenderer:
-		newPks = append(newPks, &packet.ClientBoundDebugRenderer{
+		return []packet.Packet{&packet.ClientBoundDebugRenderer{
 			Type:     pk.Type,
 			Text:     pk.Text,
 			Position: pk.Position,
@@ -83,17 +133,17 @@ func (p Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 			Blue:     pk.Blue,
 			Alpha:    pk.Alpha,
 			Duration: pk.Duration,
-		})
+		}}, true
 	case *legacypacket.CorrectPlayerMovePrediction:
-		newPks = append(newPks, &packet.CorrectPlayerMovePrediction{
+		return []packet.Packet{&packet.CorrectPlayerMovePrediction{
 			PredictionType: pk.PredictionType,
 			Position:       pk.Position,
 			Delta:          pk.Delta,
 			OnGround:       pk.OnGround,
 			Tick:           pk.Tick,
-		})
+		}}, true
 	case *legacypacket.PlayerAuthInput:
-		newPks = append(newPks, &packet.PlayerAuthInput{
+		return []packet.Packet{&packet.PlayerAuthInput{
 			Pitch:                  pk.Pitch,
 			Yaw:                    pk.Yaw,
 			Position:               pk.Position,
@@ -112,18 +162,18 @@ func (p Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 			ClientPredictedVehicle: pk.ClientPredictedVehicle,
 			VehicleRotation:        pk.VehicleRotation,
 			AnalogueMoveVector:     pk.AnalogueMoveVector,
-		})
+		}}, true
 	case *legacypacket.ResourcePackStack:
-		newPks = append(newPks, &packet.ResourcePackStack{
+		return []packet.Packet{&packet.ResourcePackStack{
 			TexturePackRequired:          pk.TexturePackRequired,
 			BehaviourPacks:               pk.BehaviourPacks,
 			TexturePacks:                 pk.TexturePacks,
 			BaseGameVersion:              pk.BaseGameVersion,
 			Experiments:                  pk.Experiments,
 			ExperimentsPreviouslyToggled: pk.ExperimentsPreviouslyToggled,
-		})
+		}}, true
 	case *legacypacket.StartGame:
-		newPks = append(newPks, &packet.StartGame{
+		return []packet.Packet{&packet.StartGame{
 			EntityUniqueID:                 pk.EntityUniqueID,
 			EntityRuntimeID:                pk.EntityRuntimeID,
 			PlayerGameMode:                 pk.PlayerGameMode,
@@ -198,177 +248,168 @@ func (p Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 			DisablePlayerInteractions:      pk.DisablePlayerInteractions,
 			UseBlockNetworkIDHashes:        pk.UseBlockNetworkIDHashes,
 			ServerAuthoritativeSound:       pk.ServerAuthoritativeSound,
-		})
+		}}, true
 	case *legacypacket.UpdateBlockSynced:
-		newPks = append(newPks, &packet.UpdateBlockSynced{
+		return []packet.Packet{&packet.UpdateBlockSynced{
 			Position:          pk.Position,
 			NewBlockRuntimeID: pk.NewBlockRuntimeID,
 			Flags:             pk.Flags,
 			Layer:             pk.Layer,
 			EntityUniqueID:    uint64(pk.EntityUniqueID),
 			TransitionType:    pk.TransitionType,
-		})
+		}}, true
 	case *legacypacket.UpdatePlayerGameType:
-		newPks = append(newPks, &packet.UpdatePlayerGameType{
+		return []packet.Packet{&packet.UpdatePlayerGameType{
 			GameType:       pk.GameType,
 			PlayerUniqueID: pk.PlayerUniqueID,
-		})
-	case *packet.ClientCacheStatus:
-		pk.Enabled = false
-	default:
-		newPks = append(newPks, pk)
+		}}, true
 	}
-
-	return p.blockTranslator.UpgradeBlockPackets(p.itemTranslator.UpgradeItemPackets(newPks, conn), conn)
+	return nil, false
 }
 
-func (p Protocol) ConvertFromLatest(pk packet.Packet, conn *minecraft.Conn) (result []packet.Packet) {
-	result = p.blockTranslator.DowngradeBlockPackets(p.itemTranslator.DowngradeItemPackets([]packet.Packet{pk}, conn), conn)
-
-	for i, pk := range result {
-		switch pk := pk.(type) {
-		case *packet.ClientBoundDebugRenderer:
-			result[i] = &legacypacket.ClientBoundDebugRenderer{
-				Type:     pk.Type,
-				Text:     pk.Text,
-				Position: pk.Position,
-				Red:      pk.Red,
-				Green:    pk.Green,
-				Blue:     pk.Blue,
-				Alpha:    pk.Alpha,
-				Duration: pk.Duration,
-			}
-		case *packet.CorrectPlayerMovePrediction:
-			result[i] = &legacypacket.CorrectPlayerMovePrediction{
-				PredictionType: pk.PredictionType,
-				Position:       pk.Position,
-				Delta:          pk.Delta,
-				OnGround:       pk.OnGround,
-				Tick:           pk.Tick,
-			}
-		case *packet.PlayerAuthInput:
-			result[i] = &legacypacket.PlayerAuthInput{
-				Pitch:                  pk.Pitch,
-				Yaw:                    pk.Yaw,
-				Position:               pk.Position,
-				MoveVector:             pk.MoveVector,
-				HeadYaw:                pk.HeadYaw,
-				InputData:              pk.InputData,
-				InputMode:              pk.InputMode,
-				PlayMode:               pk.PlayMode,
-				InteractionModel:       int32(pk.InteractionModel),
-				GazeDirection:          pk.GazeDirection,
-				Tick:                   pk.Tick,
-				Delta:                  pk.Delta,
-				ItemInteractionData:    pk.ItemInteractionData,
-				ItemStackRequest:       pk.ItemStackRequest,
-				BlockActions:           pk.BlockActions,
-				ClientPredictedVehicle: pk.ClientPredictedVehicle,
-				VehicleRotation:        pk.VehicleRotation,
-				AnalogueMoveVector:     pk.AnalogueMoveVector,
-			}
-		case *packet.ResourcePackStack:
-			result[i] = &legacypacket.ResourcePackStack{
-				TexturePackRequired:          pk.TexturePackRequired,
-				BehaviourPacks:               pk.BehaviourPacks,
-				TexturePacks:                 pk.TexturePacks,
-				BaseGameVersion:              pk.BaseGameVersion,
-				Experiments:                  pk.Experiments,
-				ExperimentsPreviouslyToggled: pk.ExperimentsPreviouslyToggled,
-			}
-		case *packet.StartGame:
-			result[i] = &legacypacket.StartGame{
-				EntityUniqueID:                 pk.EntityUniqueID,
-				EntityRuntimeID:                pk.EntityRuntimeID,
-				PlayerGameMode:                 pk.PlayerGameMode,
-				PlayerPosition:                 pk.PlayerPosition,
-				Pitch:                          pk.Pitch,
-				Yaw:                            pk.Yaw,
-				WorldSeed:                      pk.WorldSeed,
-				SpawnBiomeType:                 pk.SpawnBiomeType,
-				UserDefinedBiomeName:           pk.UserDefinedBiomeName,
-				Dimension:                      pk.Dimension,
-				Generator:                      pk.Generator,
-				WorldGameMode:                  pk.WorldGameMode,
-				Difficulty:                     pk.Difficulty,
-				WorldSpawn:                     pk.WorldSpawn,
-				AchievementsDisabled:           pk.AchievementsDisabled,
-				EditorWorldType:                pk.EditorWorldType,
-				CreatedInEditor:                pk.CreatedInEditor,
-				ExportedFromEditor:             pk.ExportedFromEditor,
-				DayCycleLockTime:               pk.DayCycleLockTime,
-				EducationEditionOffer:          pk.EducationEditionOffer,
-				EducationFeaturesEnabled:       pk.EducationFeaturesEnabled,
-				EducationProductID:             pk.EducationProductID,
-				RainLevel:                      pk.RainLevel,
-				LightningLevel:                 pk.LightningLevel,
-				ConfirmedPlatformLockedContent: pk.ConfirmedPlatformLockedContent,
-				MultiPlayerGame:                pk.MultiPlayerGame,
-				LANBroadcastEnabled:            pk.LANBroadcastEnabled,
-				XBLBroadcastMode:               pk.XBLBroadcastMode,
-				PlatformBroadcastMode:          pk.PlatformBroadcastMode,
-				CommandsEnabled:                pk.CommandsEnabled,
-				TexturePackRequired:            pk.TexturePackRequired,
-				GameRules:                      pk.GameRules,
-				Experiments:                    pk.Experiments,
-				ExperimentsPreviouslyToggled:   pk.ExperimentsPreviouslyToggled,
-				BonusChestEnabled:              pk.BonusChestEnabled,
-				StartWithMapEnabled:            pk.StartWithMapEnabled,
-				PlayerPermissions:              pk.PlayerPermissions,
-				ServerChunkTickRadius:          pk.ServerChunkTickRadius,
-				HasLockedBehaviourPack:         pk.HasLockedBehaviourPack,
-				HasLockedTexturePack:           pk.HasLockedTexturePack,
-				FromLockedWorldTemplate:        pk.FromLockedWorldTemplate,
-				MSAGamerTagsOnly:               pk.MSAGamerTagsOnly,
-				FromWorldTemplate:              pk.FromWorldTemplate,
-				WorldTemplateSettingsLocked:    pk.WorldTemplateSettingsLocked,
-				OnlySpawnV1Villagers:           pk.OnlySpawnV1Villagers,
-				PersonaDisabled:                pk.PersonaDisabled,
-				CustomSkinsDisabled:            pk.CustomSkinsDisabled,
-				EmoteChatMuted:                 pk.EmoteChatMuted,
-				BaseGameVersion:                pk.BaseGameVersion,
-				LimitedWorldWidth:              pk.LimitedWorldWidth,
-				LimitedWorldDepth:              pk.LimitedWorldDepth,
-				NewNether:                      pk.NewNether,
-				EducationSharedResourceURI:     pk.EducationSharedResourceURI,
-				ForceExperimentalGameplay:      pk.ForceExperimentalGameplay,
-				LevelID:                        pk.LevelID,
-				WorldName:                      pk.WorldName,
-				TemplateContentIdentity:        pk.TemplateContentIdentity,
-				Trial:                          pk.Trial,
-				PlayerMovementSettings:         pk.PlayerMovementSettings,
-				Time:                           pk.Time,
-				EnchantmentSeed:                pk.EnchantmentSeed,
-				Blocks:                         pk.Blocks,
-				Items:                          pk.Items,
-				MultiPlayerCorrelationID:       pk.MultiPlayerCorrelationID,
-				ServerAuthoritativeInventory:   pk.ServerAuthoritativeInventory,
-				GameVersion:                    pk.GameVersion,
-				PropertyData:                   pk.PropertyData,
-				ServerBlockStateChecksum:       pk.ServerBlockStateChecksum,
-				ClientSideGeneration:           pk.ClientSideGeneration,
-				WorldTemplateID:                pk.WorldTemplateID,
-				ChatRestrictionLevel:           pk.ChatRestrictionLevel,
-				DisablePlayerInteractions:      pk.DisablePlayerInteractions,
-				UseBlockNetworkIDHashes:        pk.UseBlockNetworkIDHashes,
-				ServerAuthoritativeSound:       pk.ServerAuthoritativeSound,
-			}
-		case *packet.UpdateBlockSynced:
-			result[i] = &legacypacket.UpdateBlockSynced{
-				Position:          pk.Position,
-				NewBlockRuntimeID: pk.NewBlockRuntimeID,
-				Flags:             pk.Flags,
-				Layer:             pk.Layer,
-				EntityUniqueID:    int64(pk.EntityUniqueID),
-				TransitionType:    pk.TransitionType,
-			}
-		case *packet.UpdatePlayerGameType:
-			result[i] = &legacypacket.UpdatePlayerGameType{
-				GameType:       pk.GameType,
-				PlayerUniqueID: pk.PlayerUniqueID,
-			}
-		}
+// Downgrade ...
+func (Step) Downgrade(pk packet.Packet, _ *minecraft.Conn) ([]packet.Packet, bool) {
+	switch pk := pk.(type) {
+	case *packet.ClientBoundDebugRenderer:
+		return []packet.Packet{&legacypacket.ClientBoundDebugRenderer{
+			Type:     pk.Type,
+			Text:     pk.Text,
+			Position: pk.Position,
+			Red:      pk.Red,
+			Green:    pk.Green,
+			Blue:     pk.Blue,
+			Alpha:    pk.Alpha,
+			Duration: pk.Duration,
+		}}, true
+	case *packet.CorrectPlayerMovePrediction:
+		return []packet.Packet{&legacypacket.CorrectPlayerMovePrediction{
+			PredictionType: pk.PredictionType,
+			Position:       pk.Position,
+			Delta:          pk.Delta,
+			OnGround:       pk.OnGround,
+			Tick:           pk.Tick,
+		}}, true
+	case *packet.PlayerAuthInput:
+		return []packet.Packet{&legacypacket.PlayerAuthInput{
+			Pitch:                  pk.Pitch,
+			Yaw:                    pk.Yaw,
+			Position:               pk.Position,
+			MoveVector:             pk.MoveVector,
+			HeadYaw:                pk.HeadYaw,
+			InputData:              pk.InputData,
+			InputMode:              pk.InputMode,
+			PlayMode:               pk.PlayMode,
+			InteractionModel:       int32(pk.InteractionModel),
+			GazeDirection:          pk.GazeDirection,
+			Tick:                   pk.Tick,
+			Delta:                  pk.Delta,
+			ItemInteractionData:    pk.ItemInteractionData,
+			ItemStackRequest:       pk.ItemStackRequest,
+			BlockActions:           pk.BlockActions,
+			ClientPredictedVehicle: pk.ClientPredictedVehicle,
+			VehicleRotation:        pk.VehicleRotation,
+			AnalogueMoveVector:     pk.AnalogueMoveVector,
+		}}, true
+	case *packet.ResourcePackStack:
+		return []packet.Packet{&legacypacket.ResourcePackStack{
+			TexturePackRequired:          pk.TexturePackRequired,
+			BehaviourPacks:               pk.BehaviourPacks,
+			TexturePacks:                 pk.TexturePacks,
+			BaseGameVersion:              pk.BaseGameVersion,
+			Experiments:                  pk.Experiments,
+			ExperimentsPreviouslyToggled: pk.ExperimentsPreviouslyToggled,
+		}}, true
+	case *packet.StartGame:
+		return []packet.Packet{&legacypacket.StartGame{
+			EntityUniqueID:                 pk.EntityUniqueID,
+			EntityRuntimeID:                pk.EntityRuntimeID,
+			PlayerGameMode:                 pk.PlayerGameMode,
+			PlayerPosition:                 pk.PlayerPosition,
+			Pitch:                          pk.Pitch,
+			Yaw:                            pk.Yaw,
+			WorldSeed:                      pk.WorldSeed,
+			SpawnBiomeType:                 pk.SpawnBiomeType,
+			UserDefinedBiomeName:           pk.UserDefinedBiomeName,
+			Dimension:                      pk.Dimension,
+			Generator:                      pk.Generator,
+			WorldGameMode:                  pk.WorldGameMode,
+			Difficulty:                     pk.Difficulty,
+			WorldSpawn:                     pk.WorldSpawn,
+			AchievementsDisabled:           pk.AchievementsDisabled,
+			EditorWorldType:                pk.EditorWorldType,
+			CreatedInEditor:                pk.CreatedInEditor,
+			ExportedFromEditor:             pk.ExportedFromEditor,
+			DayCycleLockTime:               pk.DayCycleLockTime,
+			EducationEditionOffer:          pk.EducationEditionOffer,
+			EducationFeaturesEnabled:       pk.EducationFeaturesEnabled,
+			EducationProductID:             pk.EducationProductID,
+			RainLevel:                      pk.RainLevel,
+			LightningLevel:                 pk.LightningLevel,
+			ConfirmedPlatformLockedContent: pk.ConfirmedPlatformLockedContent,
+			MultiPlayerGame:                pk.MultiPlayerGame,
+			LANBroadcastEnabled:            pk.LANBroadcastEnabled,
+			XBLBroadcastMode:               pk.XBLBroadcastMode,
+			PlatformBroadcastMode:          pk.PlatformBroadcastMode,
+			CommandsEnabled:                pk.CommandsEnabled,
+			TexturePackRequired:            pk.TexturePackRequired,
+			GameRules:                      pk.GameRules,
+			Experiments:                    pk.Experiments,
+			ExperimentsPreviouslyToggled:   pk.ExperimentsPreviouslyToggled,
+			BonusChestEnabled:              pk.BonusChestEnabled,
+			StartWithMapEnabled:            pk.StartWithMapEnabled,
+			PlayerPermissions:              pk.PlayerPermissions,
+			ServerChunkTickRadius:          pk.ServerChunkTickRadius,
+			HasLockedBehaviourPack:         pk.HasLockedBehaviourPack,
+			HasLockedTexturePack:           pk.HasLockedTexturePack,
+			FromLockedWorldTemplate:        pk.FromLockedWorldTemplate,
+			MSAGamerTagsOnly:               pk.MSAGamerTagsOnly,
+			FromWorldTemplate:              pk.FromWorldTemplate,
+			WorldTemplateSettingsLocked:    pk.WorldTemplateSettingsLocked,
+			OnlySpawnV1Villagers:           pk.OnlySpawnV1Villagers,
+			PersonaDisabled:                pk.PersonaDisabled,
+			CustomSkinsDisabled:            pk.CustomSkinsDisabled,
+			EmoteChatMuted:                 pk.EmoteChatMuted,
+			BaseGameVersion:                pk.BaseGameVersion,
+			LimitedWorldWidth:              pk.LimitedWorldWidth,
+			LimitedWorldDepth:              pk.LimitedWorldDepth,
+			NewNether:                      pk.NewNether,
+			EducationSharedResourceURI:     pk.EducationSharedResourceURI,
+			ForceExperimentalGameplay:      pk.ForceExperimentalGameplay,
+			LevelID:                        pk.LevelID,
+			WorldName:                      pk.WorldName,
+			TemplateContentIdentity:        pk.TemplateContentIdentity,
+			Trial:                          pk.Trial,
+			PlayerMovementSettings:         pk.PlayerMovementSettings,
+			Time:                           pk.Time,
+			EnchantmentSeed:                pk.EnchantmentSeed,
+			Blocks:                         pk.Blocks,
+			Items:                          pk.Items,
+			MultiPlayerCorrelationID:       pk.MultiPlayerCorrelationID,
+			ServerAuthoritativeInventory:   pk.ServerAuthoritativeInventory,
+			GameVersion:                    pk.GameVersion,
+			PropertyData:                   pk.PropertyData,
+			ServerBlockStateChecksum:       pk.ServerBlockStateChecksum,
+			ClientSideGeneration:           pk.ClientSideGeneration,
+			WorldTemplateID:                pk.WorldTemplateID,
+			ChatRestrictionLevel:           pk.ChatRestrictionLevel,
+			DisablePlayerInteractions:      pk.DisablePlayerInteractions,
+			UseBlockNetworkIDHashes:        pk.UseBlockNetworkIDHashes,
+			ServerAuthoritativeSound:       pk.ServerAuthoritativeSound,
+		}}, true
+	case *packet.UpdateBlockSynced:
+		return []packet.Packet{&legacypacket.UpdateBlockSynced{
+			Position:          pk.Position,
+			NewBlockRuntimeID: pk.NewBlockRuntimeID,
+			Flags:             pk.Flags,
+			Layer:             pk.Layer,
+			EntityUniqueID:    int64(pk.EntityUniqueID),
+			TransitionType:    pk.TransitionType,
+		}}, true
+	case *packet.UpdatePlayerGameType:
+		return []packet.Packet{&legacypacket.UpdatePlayerGameType{
+			GameType:       pk.GameType,
+			PlayerUniqueID: pk.PlayerUniqueID,
+		}}, true
 	}
-
-	return result
+	return nil, false
 }
@@ -0,0 +1,119 @@
+package v662_test
+
+import (
+	"github.com/flonja/multiversion/protocols/protocoltest"
+	v662 "github.com/flonja/multiversion/protocols/v662"
+	legacypacket "github.com/flonja/multiversion/protocols/v662/packet"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"testing"
+)
+
+func TestGolden(t *testing.T) {
+	protocoltest.Run(t, v662.New(),
+		protocoltest.Case{
+			Name: "client_bound_debug_renderer",
+			Packet: &legacypacket.ClientBoundDebugRenderer{
+				Type:     packet.ClientBoundDebugRendererAddCube,
+				Text:     "marker",
+				Position: mgl32.Vec3{1, 64, -3},
+				Red:      1,
+				Green:    0.5,
+				Blue:     0.25,
+				Alpha:    1,
+				Duration: 2000,
+			},
+			Latest: []packet.Packet{&packet.ClientBoundDebugRenderer{
+				Type:     packet.ClientBoundDebugRendererAddCube,
+				Text:     "marker",
+				Position: mgl32.Vec3{1, 64, -3},
+				Red:      1,
+				Green:    0.5,
+				Blue:     0.25,
+				Alpha:    1,
+				Duration: 2000,
+			}},
+		},
+		protocoltest.Case{
+			Name: "correct_player_move_prediction",
+			Packet: &legacypacket.CorrectPlayerMovePrediction{
+				Position:       mgl32.Vec3{10.5, 70, 3.25},
+				Delta:          mgl32.Vec3{0, -0.08, 0},
+				OnGround:       true,
+				Tick:           1200,
+				PredictionType: packet.PredictionTypePlayer,
+			},
+		},
+		protocoltest.Case{
+			Name: "player_auth_input",
+			Packet: &legacypacket.PlayerAuthInput{
+				Pitch:         12.5,
+				Yaw:           -90,
+				Position:      mgl32.Vec3{10.5, 71.62, 3.25},
+				MoveVector:    mgl32.Vec2{0, 1},
+				HeadYaw:       -90,
+				InputMode:     packet.InputModeMouse,
+				PlayMode:      packet.PlayModeNormal,
+				GazeDirection: mgl32.Vec3{},
+				Tick:          1200,
+				Delta:         mgl32.Vec3{0, -0.08, 0.2},
+			},
+		},
+		protocoltest.Case{
+			Name: "resource_pack_stack",
+			Packet: &legacypacket.ResourcePackStack{
+				TexturePackRequired: true,
+				TexturePacks:        []protocol.StackResourcePack{{UUID: "0fba4063-dba1-4281-9b89-ff9390653530", Version: "1.0.0"}},
+				BaseGameVersion:     "1.20.70",
+				Experiments:         []protocol.ExperimentData{{Name: "data_driven_items", Enabled: true}},
+			},
+		},
+		protocoltest.Case{
+			Name: "start_game",
+			Packet: &legacypacket.StartGame{
+				EntityUniqueID:  1,
+				EntityRuntimeID: 1,
+				PlayerGameMode:  packet.GameTypeSurvival,
+				PlayerPosition:  mgl32.Vec3{0.5, 65.62, 0.5},
+				WorldSeed:       1234,
+				Dimension:       packet.DimensionOverworld,
+				Generator:       1,
+				WorldGameMode:   packet.GameTypeSurvival,
+				Difficulty:      2,
+				WorldSpawn:      protocol.BlockPos{0, 64, 0},
+				GameRules:       []protocol.GameRule{{Name: "dodaylightcycle", Value: true}},
+				BaseGameVersion: "*",
+				LevelID:         "level",
+				WorldName:       "world",
+				PlayerMovementSettings: protocol.PlayerMovementSettings{
+					MovementType:                     protocol.PlayerMovementModeServer,
+					RewindHistorySize:                40,
+					ServerAuthoritativeBlockBreaking: true,
+				},
+				Time:                         6000,
+				MultiPlayerCorrelationID:     "correlation",
+				ServerAuthoritativeInventory: true,
+				GameVersion:                  "1.20.73",
+				PropertyData:                 map[string]any{},
+			},
+		},
+		protocoltest.Case{
+			Name: "update_block_synced",
+			Packet: &legacypacket.UpdateBlockSynced{
+				Position:          protocol.BlockPos{4, 65, -2},
+				NewBlockRuntimeID: 0,
+				Flags:             packet.BlockUpdateNetwork,
+				EntityUniqueID:    7,
+				TransitionType:    packet.BlockToEntityTransition,
+			},
+		},
+		protocoltest.Case{
+			Name: "update_player_game_type",
+			Packet: &legacypacket.UpdatePlayerGameType{
+				GameType:       packet.GameTypeCreative,
+				PlayerUniqueID: 1,
+			},
+		},
+	)
+}
@@ -0,0 +1,150 @@
+package translator
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/segmentio/fasthash/fnv1"
+	"sync"
+)
+
+// maxBlobHashes is the maximum amount of blob hashes tracked for a connection. Clients report the blobs they hit or
+// miss right after receiving their hashes, so only the most recently used hashes have to be tracked.
+const maxBlobHashes = 1 << 16
+
+// errUnknownBlob is the error reported for blob hashes missed by a client that were never sent to it, or that were
+// sent so long ago that they are no longer tracked.
+var errUnknownBlob = errors.New("blob hash is not tracked")
+
+// blobKind is the kind of content held by a blob of the client blob cache.
+type blobKind uint8
+
+const (
+	blobSubChunk blobKind = iota
+	blobBiomes
+)
+
+// blob is a blob of the client blob cache of which the hash was sent to a client.
+type blob struct {
+	// hash is the hash of the blob as sent by the server.
+	hash uint64
+	// clientHash is the hash of the translated blob as sent to the client.
+	clientHash uint64
+	kind       blobKind
+	oldFormat  bool
+}
+
+// blobHashes tracks the hashes of the blobs sent to a connection. Translated blobs are sent under a hash derived from
+// the hash of the original blob, so that the hashes of blobs the client misses can be translated back before the
+// blobs themselves were ever seen.
+type blobHashes struct {
+	mu       sync.Mutex
+	byServer map[uint64]*list.Element
+	byClient map[uint64]*list.Element
+	// lru holds the tracked blobs, with the most recently used blob at the front. Once full, the least recently used
+	// blobs are evicted.
+	lru *list.List
+}
+
+// newBlobHashes returns an empty blobHashes.
+func newBlobHashes() *blobHashes {
+	return &blobHashes{byServer: make(map[uint64]*list.Element), byClient: make(map[uint64]*list.Element), lru: list.New()}
+}
+
+// track returns the hash the blob with the hash and kind passed is sent under, and remembers it until it is no longer
+// used by the client. The salt identifies the translation done for the connection.
+func (b *blobHashes) track(hash uint64, kind blobKind, oldFormat bool, salt uint64) uint64 {
+	clientHash := fnv1.AddUint64(fnv1.AddUint64(fnv1.HashUint64(hash), salt), uint64(kind))
+	if oldFormat {
+		clientHash = fnv1.AddUint64(clientHash, 1)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if e, ok := b.byServer[hash]; ok {
+		if e.Value.(blob).clientHash == clientHash {
+			b.lru.MoveToFront(e)
+			return clientHash
+		}
+		b.remove(e)
+	}
+	if e, ok := b.byClient[clientHash]; ok {
+		b.remove(e)
+	}
+	e := b.lru.PushFront(blob{hash: hash, clientHash: clientHash, kind: kind, oldFormat: oldFormat})
+	b.byServer[hash], b.byClient[clientHash] = e, e
+	for b.lru.Len() > maxBlobHashes {
+		b.remove(b.lru.Back())
+	}
+	return clientHash
+}
+
+// byServerHash returns the tracked blob with the original hash passed.
+func (b *blobHashes) byServerHash(hash uint64) (blob, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.use(b.byServer[hash])
+}
+
+// byClientHash returns the tracked blob that was sent to the client under the hash passed.
+func (b *blobHashes) byClientHash(clientHash uint64) (blob, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.use(b.byClient[clientHash])
+}
+
+// original returns the hashes of the tracked blobs sent to the client under the hashes passed. Hashes that are not
+// tracked are left out and returned separately.
+func (b *blobHashes) original(hashes []uint64) (original, unknown []uint64) {
+	original = make([]uint64, 0, len(hashes))
+	for _, hash := range hashes {
+		if tracked, ok := b.byClientHash(hash); ok {
+			original = append(original, tracked.hash)
+		} else {
+			unknown = append(unknown, hash)
+		}
+	}
+	return original, unknown
+}
+
+// use marks the blob of the element passed as the most recently used blob and returns it. False is returned if the
+// element is nil.
+func (b *blobHashes) use(e *list.Element) (blob, bool) {
+	if e == nil {
+		return blob{}, false
+	}
+	b.lru.MoveToFront(e)
+	return e.Value.(blob), true
+}
+
+// remove stops tracking the blob of the element passed.
+func (b *blobHashes) remove(e *list.Element) {
+	tracked := b.lru.Remove(e).(blob)
+	delete(b.byServer, tracked.hash)
+	delete(b.byClient, tracked.clientHash)
+}
+
+// blobSalt returns the salt of the hashes of client blob cache blobs translated for a connection. A salt identifies the
+// translation done, so that blobs translated differently never share a hash in the cache of a client, which is kept
+// between sessions. Connections of the same protocol and side only translate blocks differently if they were sent
+// different custom blocks in their StartGame packet, so the salt is derived from the protocol ID, the side and the
+// block entries passed.
+func blobSalt(protocolID int32, client bool, entries []protocol.BlockEntry) uint64 {
+	salt := fnv1.AddUint64(fnv1.Init64, uint64(uint32(protocolID)))
+	if client {
+		salt = fnv1.AddUint64(salt, 1)
+	}
+	for _, entry := range entries {
+		salt = fnv1.AddString64(salt, entry.Name)
+		// Only the properties of custom blocks change the runtime IDs of blocks, not their components.
+		properties, _ := entry.Properties["properties"].([]any)
+		for _, property := range properties {
+			if property, ok := property.(map[string]any); ok {
+				salt = fnv1.AddString64(salt, fmt.Sprint(property["name"], property["enum"]))
+			}
+		}
+		salt = fnv1.AddUint64(salt, 0)
+	}
+	return salt
+}
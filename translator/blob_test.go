@@ -0,0 +1,51 @@
+package translator_test
+
+import (
+	v630 "github.com/flonja/multiversion/protocols/v630"
+	"github.com/flonja/multiversion/translator"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"testing"
+)
+
+func TestBlobHashSalt(t *testing.T) {
+	blocks := v630.New().BlockTranslator()
+	customBlock := func(name string) protocol.BlockEntry {
+		return protocol.BlockEntry{Name: name, Properties: map[string]any{"properties": []any{
+			map[string]any{"name": "test:rotation", "enum": []any{int32(0), int32(1)}},
+		}}}
+	}
+	// blobHash returns the hash a blob is sent under to a new connection that received the custom blocks passed.
+	blobHash := func(protocolID int32, entries ...protocol.BlockEntry) uint64 {
+		conn := new(minecraft.Conn)
+		defer translator.ReleaseConn(conn)
+		translator.TrackConn(conn, protocolID, false)
+
+		blocks.DowngradeBlockPackets([]packet.Packet{&packet.StartGame{Blocks: entries}}, conn)
+		pk := &packet.LevelChunk{SubChunkCount: protocol.SubChunkRequestModeLimitless, CacheEnabled: true, BlobHashes: []uint64{1}}
+		blocks.DowngradeBlockPackets([]packet.Packet{pk}, conn)
+		return pk.BlobHashes[0]
+	}
+
+	vanilla := blobHash(630)
+	if vanilla == 1 {
+		t.Errorf("expected the blob hash to be salted")
+	}
+	if hash := blobHash(630); hash != vanilla {
+		t.Errorf("expected connections without custom blocks to share blob hashes, got %x and %x", vanilla, hash)
+	}
+	if hash := blobHash(649); hash == vanilla {
+		t.Errorf("expected connections of different protocols not to share blob hashes")
+	}
+	custom := blobHash(630, customBlock("test:a"))
+	if custom == vanilla {
+		t.Errorf("expected connections with custom blocks not to share blob hashes with those without")
+	}
+	if hash := blobHash(630, customBlock("test:a")); hash != custom {
+		t.Errorf("expected connections with the same custom blocks to share blob hashes, got %x and %x", custom, hash)
+	}
+	if hash := blobHash(630, customBlock("test:b")); hash == custom {
+		t.Errorf("expected connections with different custom blocks not to share blob hashes")
+	}
+}
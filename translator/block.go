@@ -2,14 +2,20 @@ package translator
 
 import (
 	"bytes"
+	"fmt"
 	"github.com/df-mc/dragonfly/server/block/cube"
 	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/worldupgrader/blockupgrader"
+	"github.com/flonja/multiversion/internal"
 	"github.com/flonja/multiversion/internal/chunk"
 	"github.com/flonja/multiversion/mapping"
+	"github.com/flonja/multiversion/packbuilder"
 	"github.com/sandertv/gophertunnel/minecraft"
 	"github.com/sandertv/gophertunnel/minecraft/nbt"
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
 	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"golang.org/x/exp/maps"
+	"slices"
 )
 
 type BlockTranslator interface {
@@ -19,6 +25,8 @@ type BlockTranslator interface {
 	DowngradeChunk(*chunk.Chunk, bool) *chunk.Chunk
 	// DowngradeSubChunk downgrades the input sub chunk to a legacy sub chunk.
 	DowngradeSubChunk(*chunk.SubChunk)
+	// DowngradeBiomeID downgrades the input biome ID to a legacy biome ID.
+	DowngradeBiomeID(uint32) uint32
 	// DowngradeBlockPackets downgrades the input block packets to legacy block packets.
 	DowngradeBlockPackets([]packet.Packet, *minecraft.Conn) (result []packet.Packet)
 	// UpgradeBlockRuntimeID upgrades the input block runtime IDs to the latest block runtime ID.
@@ -27,17 +35,46 @@ type BlockTranslator interface {
 	UpgradeChunk(*chunk.Chunk, bool) *chunk.Chunk
 	// UpgradeSubChunk upgrades the input sub chunk to the latest sub chunk.
 	UpgradeSubChunk(*chunk.SubChunk)
+	// UpgradeBiomeID upgrades the input biome ID to the latest biome ID.
+	UpgradeBiomeID(uint32) uint32
 	// UpgradeBlockPackets upgrades the input block packets to the latest block packets.
 	UpgradeBlockPackets([]packet.Packet, *minecraft.Conn) (result []packet.Packet)
+	// Register registers a custom block entry.
+	Register(block world.CustomBlock, replacement blockupgrader.BlockState)
+	// CustomBlocks lists all custom blocks used as substitutes, with the block name as the key
+	CustomBlocks() map[string]world.CustomBlock
+	// SetObserver sets the observer notified of every block and biome that could not be translated.
+	SetObserver(observer TranslationObserver)
+	// SetChunkCache sets the cache translated chunk payloads of the protocol with the ID passed are cached in.
+	SetChunkCache(cache *ChunkCache, protocolID int32)
+	// SetChunkErrorHandler sets the handler of the chunks that could not be translated.
+	SetChunkErrorHandler(handler ChunkErrorHandler)
+	// SetBlockFallbacks sets the rules used to find the nearest legacy block of blocks that could not be translated.
+	SetBlockFallbacks(fallbacks *mapping.BlockFallbacks)
 }
 
 type DefaultBlockTranslator struct {
-	mapping mapping.Block
-	latest  mapping.Block
+	mapping      mapping.Block
+	latest       mapping.Block
+	biomeMapping mapping.Biome
+	biomeLatest  mapping.Biome
+
+	customBlocks     map[string]world.CustomBlock
+	originalToCustom map[internal.StateHash]blockupgrader.BlockState
+	customToOriginal map[internal.StateHash]blockupgrader.BlockState
+	observing
+	chunkCaching
+	// chunkErrors handles the chunks that could not be translated. If nil, these chunks are sent untranslated.
+	chunkErrors ChunkErrorHandler
+	// fallbacks holds the rules used to find the nearest legacy block of blocks that could not be translated. If nil,
+	// these blocks are translated to air.
+	fallbacks *mapping.BlockFallbacks
 }
 
-func NewBlockTranslator(mapping mapping.Block, latestMapping mapping.Block) *DefaultBlockTranslator {
-	return &DefaultBlockTranslator{mapping: mapping, latest: latestMapping}
+func NewBlockTranslator(mapping mapping.Block, latestMapping mapping.Block, biomeMapping mapping.Biome, latestBiomeMapping mapping.Biome) *DefaultBlockTranslator {
+	return &DefaultBlockTranslator{mapping: mapping, latest: latestMapping, biomeMapping: biomeMapping, biomeLatest: latestBiomeMapping,
+		customBlocks: make(map[string]world.CustomBlock), originalToCustom: make(map[internal.StateHash]blockupgrader.BlockState),
+		customToOriginal: make(map[internal.StateHash]blockupgrader.BlockState)}
 }
 
 func (t *DefaultBlockTranslator) DowngradeBlockRuntimeID(input uint32) uint32 {
@@ -46,15 +83,31 @@ func (t *DefaultBlockTranslator) DowngradeBlockRuntimeID(input uint32) uint32 {
 	}
 	state, ok := t.latest.RuntimeIDToState(input)
 	if !ok {
+		t.observe(Fallback{Direction: DirectionDowngrade, Kind: FallbackBlock, ID: int32(input)})
 		return t.mapping.Air()
 	}
+	if custom, ok := t.originalToCustom[internal.HashState(state)]; ok {
+		state = custom
+	}
 	runtimeID, ok := t.mapping.StateToRuntimeID(state)
 	if !ok {
+		t.observe(Fallback{Direction: DirectionDowngrade, Kind: FallbackBlock, Name: state.Name, Properties: state.Properties, ID: int32(input)})
+		if t.fallbacks != nil {
+			if runtimeID, ok := t.fallbacks.Nearest(state, t.mapping); ok {
+				return runtimeID
+			}
+		}
 		return t.mapping.Air()
 	}
 	return runtimeID
 }
 
+// SetBlockFallbacks sets the mapping.BlockFallbacks used to find the nearest legacy block of blocks that could not be
+// translated. It should be set before the translator is used.
+func (t *DefaultBlockTranslator) SetBlockFallbacks(fallbacks *mapping.BlockFallbacks) {
+	t.fallbacks = fallbacks
+}
+
 func (t *DefaultBlockTranslator) DowngradeChunk(input *chunk.Chunk, oldFormat bool) *chunk.Chunk {
 	if t.latest == t.mapping {
 		return input
@@ -77,10 +130,7 @@ func (t *DefaultBlockTranslator) DowngradeChunk(input *chunk.Chunk, oldFormat bo
 	i = 0
 	// Then downgrade the biome ids.
 	for _, sub := range input.BiomeSub()[start : len(input.BiomeSub())-start] {
-		// todo
-		sub.Palette().Replace(func(v uint32) uint32 {
-			return 0 // at least the client doesn't crash now
-		})
+		sub.Palette().Replace(t.DowngradeBiomeID)
 		downgraded.BiomeSub()[i] = sub
 		i += 1
 	}
@@ -96,6 +146,19 @@ func (t *DefaultBlockTranslator) DowngradeSubChunk(input *chunk.SubChunk) {
 	}
 }
 
+func (t *DefaultBlockTranslator) DowngradeBiomeID(input uint32) uint32 {
+	name, ok := t.biomeLatest.BiomeIDToName(input)
+	if !ok {
+		// The biome is unknown, so the default fallback biome is used.
+		t.observe(Fallback{Direction: DirectionDowngrade, Kind: FallbackBiome, ID: int32(input)})
+		return t.biomeMapping.Nearest("")
+	}
+	if _, ok := t.biomeMapping.BiomeNameToID(name); !ok {
+		t.observe(Fallback{Direction: DirectionDowngrade, Kind: FallbackBiome, Name: name, ID: int32(input)})
+	}
+	return t.biomeMapping.Nearest(name)
+}
+
 func (t *DefaultBlockTranslator) downgradeEntityMetadata(metadata map[uint32]any) map[uint32]any {
 	if t.latest == t.mapping {
 		return metadata
@@ -112,10 +175,15 @@ func (t *DefaultBlockTranslator) UpgradeBlockRuntimeID(input uint32) uint32 {
 	}
 	state, ok := t.mapping.RuntimeIDToState(input)
 	if !ok {
+		t.observe(Fallback{Direction: DirectionUpgrade, Kind: FallbackBlock, ID: int32(input)})
 		return t.latest.Air()
 	}
+	if original, ok := t.customToOriginal[internal.HashState(state)]; ok {
+		state = original
+	}
 	runtimeID, ok := t.latest.StateToRuntimeID(state)
 	if !ok {
+		t.observe(Fallback{Direction: DirectionUpgrade, Kind: FallbackBlock, Name: state.Name, Properties: state.Properties, ID: int32(input)})
 		return t.latest.Air()
 	}
 	return runtimeID
@@ -143,7 +211,7 @@ func (t *DefaultBlockTranslator) UpgradeChunk(input *chunk.Chunk, oldFormat bool
 	i = 0
 	// Then upgrade the biome ids.
 	for _, sub := range input.BiomeSub()[start : len(input.BiomeSub())-start] {
-		// todo
+		sub.Palette().Replace(t.UpgradeBiomeID)
 		upgraded.BiomeSub()[i] = sub
 		i += 1
 	}
@@ -159,6 +227,19 @@ func (t *DefaultBlockTranslator) UpgradeSubChunk(input *chunk.SubChunk) {
 	}
 }
 
+func (t *DefaultBlockTranslator) UpgradeBiomeID(input uint32) uint32 {
+	name, ok := t.biomeMapping.BiomeIDToName(input)
+	if !ok {
+		// The biome is unknown, so the default fallback biome is used.
+		t.observe(Fallback{Direction: DirectionUpgrade, Kind: FallbackBiome, ID: int32(input)})
+		return t.biomeLatest.Nearest("")
+	}
+	if _, ok := t.biomeLatest.BiomeNameToID(name); !ok {
+		t.observe(Fallback{Direction: DirectionUpgrade, Kind: FallbackBiome, Name: name, ID: int32(input)})
+	}
+	return t.biomeLatest.Nearest(name)
+}
+
 func (t *DefaultBlockTranslator) upgradeEntityMetadata(metadata map[uint32]any) map[uint32]any {
 	if t.latest == t.mapping {
 		return metadata
@@ -170,124 +251,80 @@ func (t *DefaultBlockTranslator) upgradeEntityMetadata(metadata map[uint32]any)
 }
 
 func (t *DefaultBlockTranslator) DowngradeBlockPackets(pks []packet.Packet, conn *minecraft.Conn) (result []packet.Packet) {
-	oldFormat := conn.GameData().BaseGameVersion == "1.17.40"
+	state := stateOf(conn)
+	t = t.forConn(state)
+	oldFormat := state.isOldFormat()
 	for _, pk := range pks {
+		t := forPacket(t, pk)
 		switch pk := pk.(type) {
 		case *packet.LevelChunk:
 			count := int(pk.SubChunkCount)
-			if count == protocol.SubChunkRequestModeLimitless || count == protocol.SubChunkRequestModeLimited {
-				break
-			}
-			buf := bytes.NewBuffer(pk.RawPayload)
-			writeBuf := bytes.NewBuffer(nil)
-			if !pk.CacheEnabled && !conn.ClientCacheEnabled() {
-				r := world.Overworld.Range()
-				if oldFormat {
-					r = cube.Range{0, 255}
-				}
-
-				c, err := chunk.NetworkDecode(t.latest.Air(), buf, count, oldFormat, r)
-				if err != nil {
-					//fmt.Println(err)
-					break
-				}
-				t.DowngradeChunk(c, oldFormat)
-
-				payload, err := chunk.NetworkEncode(t.mapping.Air(), c, oldFormat)
-				if err != nil {
-					//fmt.Println(err)
-					break
-				}
-				writeBuf.Write(payload)
-				pk.SubChunkCount = uint32(len(c.Sub()))
-			}
-			safeBytes := buf.Bytes()
-
-			countBorder, err := buf.ReadByte()
-			if err != nil {
-				pk.RawPayload = append(writeBuf.Bytes(), safeBytes...)
-				break
+			requestMode := count == protocol.SubChunkRequestModeLimitless || count == protocol.SubChunkRequestModeLimited
+			if pk.CacheEnabled {
+				trackLevelChunkBlobs(state.blobHashes(), pk, state.blobSalt(), oldFormat)
 			}
-			borderBytes := make([]byte, countBorder)
-			if _, err = buf.Read(borderBytes); err != nil {
-				pk.RawPayload = append(writeBuf.Bytes(), safeBytes...)
+			if requestMode {
 				break
 			}
-			writeBuf.WriteByte(countBorder)
-			writeBuf.Write(borderBytes)
-
-			enc := nbt.NewEncoderWithEncoding(writeBuf, nbt.NetworkLittleEndian)
-			dec := nbt.NewDecoderWithEncoding(buf, nbt.NetworkLittleEndian)
-			for {
-				var decNbt map[string]any
-				if err = dec.Decode(&decNbt); err != nil {
-					break
-				}
-				t.mapping.DowngradeBlockActorData(decNbt)
-
-				if err = enc.Encode(decNbt); err != nil {
-					break
+			// The blocks are sent in separate blobs if the blob cache is used for the chunk.
+			payload, subChunkCount, err := t.cachedLevelChunkPayload(pk.RawPayload, pk.SubChunkCount, !pk.CacheEnabled, oldFormat)
+			if err != nil {
+				switch t.handleChunkError(ChunkError{Conn: conn, Packet: pk, Direction: DirectionDowngrade, Position: pk.Position, Err: err}) {
+				case ChunkErrorEmpty:
+					payload, subChunkCount = emptyLevelChunkPayload(oldFormat)
+				case ChunkErrorDisconnect:
+					return append(result, disconnect(conn))
 				}
 			}
-			pk.RawPayload = append(writeBuf.Bytes(), buf.Bytes()...)
+			pk.RawPayload, pk.SubChunkCount = payload, subChunkCount
 		case *packet.SubChunk:
-			r := world.Overworld.Range()
-			if oldFormat {
-				r = cube.Range{0, 255}
-			}
-
+			translateBlocks := !pk.CacheEnabled
 			for i, entry := range pk.SubChunkEntries {
-				if entry.Result == protocol.SubChunkResultSuccess {
-					buf := bytes.NewBuffer(entry.RawPayload)
-					writeBuf := bytes.NewBuffer(nil)
-					if !pk.CacheEnabled && !conn.ClientCacheEnabled() {
-						ind := byte(i)
-						subChunk, err := chunk.DecodeSubChunk(t.latest.Air(), r, buf, &ind, chunk.NetworkEncoding)
-						if err != nil {
-							//fmt.Println(err)
-							continue
-						}
-						t.DowngradeSubChunk(subChunk)
-						writeBuf.Write(chunk.EncodeSubChunk(subChunk, chunk.NetworkEncoding, chunk.SubChunkVersion9, r, int(ind)))
-					}
-
-					enc := nbt.NewEncoderWithEncoding(writeBuf, nbt.NetworkLittleEndian)
-					dec := nbt.NewDecoderWithEncoding(buf, nbt.NetworkLittleEndian)
-					for {
-						var decNbt map[string]any
-						if err := dec.Decode(&decNbt); err != nil {
-							break
-						}
-						t.mapping.DowngradeBlockActorData(decNbt)
-
-						if err := enc.Encode(decNbt); err != nil {
-							break
-						}
+				if entry.Result != protocol.SubChunkResultSuccess {
+					continue
+				}
+				if pk.CacheEnabled {
+					entry.BlobHash = state.blobHashes().track(entry.BlobHash, blobSubChunk, oldFormat, state.blobSalt())
+				}
+				payload, err := t.cachedSubChunkPayload(entry.RawPayload, byte(i), translateBlocks, oldFormat)
+				if err != nil {
+					switch t.handleChunkError(ChunkError{Conn: conn, Packet: pk, Direction: DirectionDowngrade, Position: subChunkPosition(pk, entry), SubChunkY: pk.Position.Y() + int32(entry.Offset[1]), Err: err}) {
+					case ChunkErrorEmpty:
+						entry.Result, payload = protocol.SubChunkResultSuccessAllAir, nil
+					case ChunkErrorDisconnect:
+						return append(result, disconnect(conn))
 					}
-
-					entry.RawPayload = append(writeBuf.Bytes(), buf.Bytes()...)
-					pk.SubChunkEntries[i] = entry
 				}
+				entry.RawPayload = payload
+				pk.SubChunkEntries[i] = entry
 			}
 		case *packet.ClientCacheMissResponse:
-			r := world.Overworld.Range()
-			if oldFormat {
-				r = cube.Range{0, 255}
-			}
-
 			for i, blob := range pk.Blobs {
-				buf := bytes.NewBuffer(blob.Payload)
-				ind := byte(0)
-				subChunk, err := chunk.DecodeSubChunk(t.latest.Air(), r, buf, &ind, chunk.NetworkEncoding)
-				if err != nil {
-					// Has a possibility to be a biome, ignore then
+				tracked, ok := state.blobHashes().byServerHash(blob.Hash)
+				if !ok {
+					// The hash of the blob was never sent to the client, so it can't have asked for it.
 					continue
 				}
-				t.DowngradeSubChunk(subChunk)
-
-				blob.Payload = append(chunk.EncodeSubChunk(subChunk, chunk.NetworkEncoding, chunk.SubChunkVersion9, r, int(ind)), buf.Bytes()...)
+				payload, err := t.downgradeBlob(blob.Payload, tracked)
+				if err != nil {
+					payload = blob.Payload
+					switch t.handleChunkError(ChunkError{Conn: conn, Packet: pk, Direction: DirectionDowngrade, BlobHash: blob.Hash, Err: err}) {
+					case ChunkErrorEmpty:
+						payload = emptyBiomes(tracked.oldFormat)
+						if tracked.kind == blobSubChunk {
+							payload = emptySubChunk(t.mapping.Air(), tracked.oldFormat)
+						}
+					case ChunkErrorDisconnect:
+						return append(result, disconnect(conn))
+					}
+				}
+				blob.Payload, blob.Hash = payload, tracked.clientHash
 				pk.Blobs[i] = blob
 			}
+		case *packet.ClientCacheBlobStatus:
+			if !t.originalBlobHashes(conn, state.blobHashes(), pk, DirectionDowngrade) {
+				return append(result, disconnect(conn))
+			}
 		case *packet.UpdateSubChunkBlocks:
 			for i, block := range pk.Blocks {
 				block.BlockRuntimeID = t.DowngradeBlockRuntimeID(block.BlockRuntimeID)
@@ -334,14 +371,24 @@ func (t *DefaultBlockTranslator) DowngradeBlockPackets(pks []packet.Packet, conn
 			}
 		case *packet.AddActor:
 			if pk.EntityType != "minecraft:falling_block" {
-				continue
+				break
 			}
 			pk.EntityMetadata = t.downgradeEntityMetadata(pk.EntityMetadata)
 		case *packet.SetActorData:
 			pk.EntityMetadata = t.downgradeEntityMetadata(pk.EntityMetadata)
+		case *packet.BlockActorData:
+			if pk.NBTData = t.mapping.DowngradeBlockActorData(pk.NBTData); pk.NBTData == nil {
+				// The block actor does not exist in the legacy version.
+				continue
+			}
+		case *packet.StructureTemplateDataResponse:
+			t.downgradeStructureTemplate(pk.StructureTemplate)
 		case *packet.StartGame:
-			t.latest.Adjust(pk.Blocks)
-			t.mapping.Adjust(pk.Blocks)
+			trackStartGame(conn, pk)
+			state.adjustBlockMapping(t.latest, pk.Blocks)
+			pk.Blocks = append(pk.Blocks, t.customBlockEntries(len(pk.Blocks))...)
+			state.adjustBlockMapping(t.mapping, pk.Blocks)
+			state.adjustBlobSalt(pk.Blocks)
 		}
 		result = append(result, pk)
 	}
@@ -349,124 +396,80 @@ func (t *DefaultBlockTranslator) DowngradeBlockPackets(pks []packet.Packet, conn
 }
 
 func (t *DefaultBlockTranslator) UpgradeBlockPackets(pks []packet.Packet, conn *minecraft.Conn) (result []packet.Packet) {
-	oldFormat := conn.GameData().BaseGameVersion == "1.17.40"
+	state := stateOf(conn)
+	t = t.forConn(state)
+	oldFormat := state.isOldFormat()
 	for _, pk := range pks {
+		t := forPacket(t, pk)
 		switch pk := pk.(type) {
 		case *packet.LevelChunk:
 			count := int(pk.SubChunkCount)
-			if count == protocol.SubChunkRequestModeLimitless || count == protocol.SubChunkRequestModeLimited {
-				break
-			}
-			buf := bytes.NewBuffer(pk.RawPayload)
-			writeBuf := bytes.NewBuffer(nil)
-			if !pk.CacheEnabled && !conn.ClientCacheEnabled() {
-				r := world.Overworld.Range()
-				if oldFormat {
-					r = cube.Range{0, 255}
-				}
-
-				c, err := chunk.NetworkDecode(t.mapping.Air(), buf, count, oldFormat, r)
-				if err != nil {
-					//fmt.Println(err)
-					break
-				}
-				t.UpgradeChunk(c, oldFormat)
-
-				payload, err := chunk.NetworkEncode(t.latest.Air(), c, oldFormat)
-				if err != nil {
-					//fmt.Println(err)
-					break
-				}
-				writeBuf.Write(payload)
-				pk.SubChunkCount = uint32(len(c.Sub()))
+			requestMode := count == protocol.SubChunkRequestModeLimitless || count == protocol.SubChunkRequestModeLimited
+			if pk.CacheEnabled {
+				trackLevelChunkBlobs(state.blobHashes(), pk, state.blobSalt(), oldFormat)
 			}
-			safeBytes := buf.Bytes()
-
-			countBorder, err := buf.ReadByte()
-			if err != nil {
-				pk.RawPayload = append(writeBuf.Bytes(), safeBytes...)
-				break
-			}
-			borderBytes := make([]byte, countBorder)
-			if _, err = buf.Read(borderBytes); err != nil {
-				pk.RawPayload = append(writeBuf.Bytes(), safeBytes...)
+			if requestMode {
 				break
 			}
-			writeBuf.WriteByte(countBorder)
-			writeBuf.Write(borderBytes)
-
-			enc := nbt.NewEncoderWithEncoding(writeBuf, nbt.NetworkLittleEndian)
-			dec := nbt.NewDecoderWithEncoding(buf, nbt.NetworkLittleEndian)
-			for {
-				var decNbt map[string]any
-				if err = dec.Decode(&decNbt); err != nil {
-					break
-				}
-				t.mapping.UpgradeBlockActorData(decNbt)
-
-				if err = enc.Encode(decNbt); err != nil {
-					break
+			// The blocks are sent in separate blobs if the blob cache is used for the chunk.
+			payload, subChunkCount, err := t.upgradeLevelChunkPayload(pk.RawPayload, pk.SubChunkCount, !pk.CacheEnabled, oldFormat)
+			if err != nil {
+				switch t.handleChunkError(ChunkError{Conn: conn, Packet: pk, Direction: DirectionUpgrade, Position: pk.Position, Err: err}) {
+				case ChunkErrorEmpty:
+					payload, subChunkCount = emptyLevelChunkPayload(oldFormat)
+				case ChunkErrorDisconnect:
+					return append(result, disconnect(conn))
 				}
 			}
-			pk.RawPayload = append(writeBuf.Bytes(), buf.Bytes()...)
+			pk.RawPayload, pk.SubChunkCount = payload, subChunkCount
 		case *packet.SubChunk:
-			r := world.Overworld.Range()
-			if oldFormat {
-				r = cube.Range{0, 255}
-			}
-
+			translateBlocks := !pk.CacheEnabled
 			for i, entry := range pk.SubChunkEntries {
-				if entry.Result == protocol.SubChunkResultSuccess {
-					buf := bytes.NewBuffer(entry.RawPayload)
-					writeBuf := bytes.NewBuffer(nil)
-					if !pk.CacheEnabled && !conn.ClientCacheEnabled() {
-						ind := byte(i)
-						subChunk, err := chunk.DecodeSubChunk(t.mapping.Air(), r, buf, &ind, chunk.NetworkEncoding)
-						if err != nil {
-							// Has a possibility to be a biome, ignore then
-							continue
-						}
-						t.UpgradeSubChunk(subChunk)
-						writeBuf.Write(chunk.EncodeSubChunk(subChunk, chunk.NetworkEncoding, chunk.SubChunkVersion9, r, int(ind)))
-					}
-
-					enc := nbt.NewEncoderWithEncoding(writeBuf, nbt.NetworkLittleEndian)
-					dec := nbt.NewDecoderWithEncoding(buf, nbt.NetworkLittleEndian)
-					for {
-						var decNbt map[string]any
-						if err := dec.Decode(&decNbt); err != nil {
-							break
-						}
-						t.mapping.UpgradeBlockActorData(decNbt)
-
-						if err := enc.Encode(decNbt); err != nil {
-							break
-						}
+				if entry.Result != protocol.SubChunkResultSuccess {
+					continue
+				}
+				if pk.CacheEnabled {
+					entry.BlobHash = state.blobHashes().track(entry.BlobHash, blobSubChunk, oldFormat, state.blobSalt())
+				}
+				payload, err := t.upgradeSubChunkPayload(entry.RawPayload, byte(i), translateBlocks, oldFormat)
+				if err != nil {
+					switch t.handleChunkError(ChunkError{Conn: conn, Packet: pk, Direction: DirectionUpgrade, Position: subChunkPosition(pk, entry), SubChunkY: pk.Position.Y() + int32(entry.Offset[1]), Err: err}) {
+					case ChunkErrorEmpty:
+						entry.Result, payload = protocol.SubChunkResultSuccessAllAir, nil
+					case ChunkErrorDisconnect:
+						return append(result, disconnect(conn))
 					}
-
-					entry.RawPayload = append(writeBuf.Bytes(), buf.Bytes()...)
-					pk.SubChunkEntries[i] = entry
 				}
+				entry.RawPayload = payload
+				pk.SubChunkEntries[i] = entry
 			}
 		case *packet.ClientCacheMissResponse:
-			r := world.Overworld.Range()
-			if oldFormat {
-				r = cube.Range{0, 255}
-			}
-
 			for i, blob := range pk.Blobs {
-				buf := bytes.NewBuffer(blob.Payload)
-				ind := byte(0)
-				subChunk, err := chunk.DecodeSubChunk(t.mapping.Air(), r, buf, &ind, chunk.NetworkEncoding)
-				if err != nil {
-					//fmt.Println(err)
+				tracked, ok := state.blobHashes().byServerHash(blob.Hash)
+				if !ok {
+					// The hash of the blob was never sent to the client, so it can't have asked for it.
 					continue
 				}
-				t.UpgradeSubChunk(subChunk)
-
-				blob.Payload = chunk.EncodeSubChunk(subChunk, chunk.NetworkEncoding, chunk.SubChunkVersion9, r, int(ind))
+				payload, err := t.upgradeBlob(blob.Payload, tracked)
+				if err != nil {
+					payload = blob.Payload
+					switch t.handleChunkError(ChunkError{Conn: conn, Packet: pk, Direction: DirectionUpgrade, BlobHash: blob.Hash, Err: err}) {
+					case ChunkErrorEmpty:
+						payload = emptyBiomes(tracked.oldFormat)
+						if tracked.kind == blobSubChunk {
+							payload = emptySubChunk(t.latest.Air(), tracked.oldFormat)
+						}
+					case ChunkErrorDisconnect:
+						return append(result, disconnect(conn))
+					}
+				}
+				blob.Payload, blob.Hash = payload, tracked.clientHash
 				pk.Blobs[i] = blob
 			}
+		case *packet.ClientCacheBlobStatus:
+			if !t.originalBlobHashes(conn, state.blobHashes(), pk, DirectionUpgrade) {
+				return append(result, disconnect(conn))
+			}
 		case *packet.UpdateSubChunkBlocks:
 			for i, block := range pk.Blocks {
 				block.BlockRuntimeID = t.UpgradeBlockRuntimeID(block.BlockRuntimeID)
@@ -513,16 +516,419 @@ func (t *DefaultBlockTranslator) UpgradeBlockPackets(pks []packet.Packet, conn *
 			}
 		case *packet.AddActor:
 			if pk.EntityType != "minecraft:falling_block" {
-				continue
+				break
 			}
 			pk.EntityMetadata = t.upgradeEntityMetadata(pk.EntityMetadata)
 		case *packet.SetActorData:
 			pk.EntityMetadata = t.upgradeEntityMetadata(pk.EntityMetadata)
+		case *packet.BlockActorData:
+			// Sent by a legacy client when it edits a sign, or by a legacy server if the protocol is used client-side.
+			if pk.NBTData = t.mapping.UpgradeBlockActorData(pk.NBTData); pk.NBTData == nil {
+				continue
+			}
+		case *packet.StructureTemplateDataResponse:
+			t.upgradeStructureTemplate(pk.StructureTemplate)
 		case *packet.StartGame:
-			t.mapping.Adjust(pk.Blocks)
-			t.latest.Adjust(pk.Blocks)
+			// The StartGame packet is only upgraded if it was sent by a legacy server, to a client dialing it.
+			trackStartGame(conn, pk)
+			state.adjustBlockMapping(t.mapping, pk.Blocks)
+			state.adjustBlockMapping(t.latest, pk.Blocks)
+			state.adjustBlobSalt(pk.Blocks)
 		}
 		result = append(result, pk)
 	}
 	return result
 }
+
+func (t *DefaultBlockTranslator) Register(block world.CustomBlock, replacement blockupgrader.BlockState) {
+	name, properties := block.EncodeBlock()
+	if _, ok := t.latest.StateToRuntimeID(replacement); !ok {
+		panic(fmt.Errorf("%v not found in latest blocks", replacement))
+	}
+	replacement = blockupgrader.Upgrade(replacement)
+	if _, ok := t.originalToCustom[internal.HashState(replacement)]; ok {
+		panic(fmt.Errorf("%v is already mapped", replacement))
+	}
+
+	custom := blockupgrader.BlockState{Name: name, Properties: properties}
+	t.customBlocks[name] = block
+	t.originalToCustom[internal.HashState(replacement)] = custom
+	t.customToOriginal[internal.HashState(custom)] = replacement
+}
+
+// trackLevelChunkBlobs tracks the hashes of the blobs of the LevelChunk packet passed, replacing them with the hashes
+// the translated blobs are sent under.
+func trackLevelChunkBlobs(blobs *blobHashes, pk *packet.LevelChunk, salt uint64, oldFormat bool) {
+	count := int(pk.SubChunkCount)
+	requestMode := count == protocol.SubChunkRequestModeLimitless || count == protocol.SubChunkRequestModeLimited
+	for i, hash := range pk.BlobHashes {
+		// The last blob holds the biomes, unless the sub chunks are requested separately, in which case all blobs
+		// hold biomes.
+		kind := blobSubChunk
+		if requestMode || i == len(pk.BlobHashes)-1 {
+			kind = blobBiomes
+		}
+		pk.BlobHashes[i] = blobs.track(hash, kind, oldFormat, salt)
+	}
+}
+
+// downgradeBlob downgrades the payload of the tracked blob of the client blob cache passed.
+func (t *DefaultBlockTranslator) downgradeBlob(payload []byte, tracked blob) ([]byte, error) {
+	if tracked.kind == blobBiomes {
+		return translateBiomeBlob(payload, tracked.oldFormat, t.DowngradeBiomeID)
+	}
+	return translateSubChunkBlob(payload, tracked.oldFormat, t.latest.Air(), t.DowngradeBlockRuntimeID, t.DowngradeSubChunk)
+}
+
+// upgradeBlob upgrades the payload of the tracked blob of the client blob cache passed.
+func (t *DefaultBlockTranslator) upgradeBlob(payload []byte, tracked blob) ([]byte, error) {
+	if tracked.kind == blobBiomes {
+		return translateBiomeBlob(payload, tracked.oldFormat, t.UpgradeBiomeID)
+	}
+	return translateSubChunkBlob(payload, tracked.oldFormat, t.mapping.Air(), t.UpgradeBlockRuntimeID, t.UpgradeSubChunk)
+}
+
+// translateSubChunkBlob translates the payload of a sub chunk blob of the client blob cache. The palettes are
+// rewritten using translateRuntimeID if possible, and the sub chunk is decoded with the air runtime ID passed and
+// translated using translateSubChunk otherwise.
+func translateSubChunkBlob(payload []byte, oldFormat bool, air uint32, translateRuntimeID func(uint32) uint32, translateSubChunk func(*chunk.SubChunk)) ([]byte, error) {
+	if translated, n, err := chunk.TranslateSubChunk(payload, translateRuntimeID); err == nil {
+		return append(translated, payload[n:]...), nil
+	}
+	r := world.Overworld.Range()
+	if oldFormat {
+		r = cube.Range{0, 255}
+	}
+	buf := bytes.NewBuffer(payload)
+	ind := byte(0)
+	subChunk, err := chunk.DecodeSubChunk(air, r, buf, &ind, chunk.NetworkEncoding)
+	if err != nil {
+		return nil, err
+	}
+	translateSubChunk(subChunk)
+	return append(chunk.EncodeSubChunk(subChunk, chunk.NetworkEncoding, chunk.SubChunkVersion9, r, int(ind)), buf.Bytes()...), nil
+}
+
+// translateBiomeBlob translates the payload of a biome blob of the client blob cache using the function passed.
+func translateBiomeBlob(payload []byte, oldFormat bool, translate func(uint32) uint32) ([]byte, error) {
+	if oldFormat {
+		// The old format holds a single byte biome ID for every column.
+		biomes := slices.Clone(payload)
+		for i, id := range biomes {
+			biomes[i] = byte(translate(uint32(id)))
+		}
+		return biomes, nil
+	}
+	if translated, _, err := chunk.TranslateBiomes(payload, -1, translate); err == nil {
+		return translated, nil
+	}
+	biomes, err := chunk.DecodeBiomes(bytes.NewBuffer(payload), chunk.NetworkEncoding)
+	if err != nil {
+		return nil, err
+	}
+	for i, b := range biomes {
+		// Storages pointing to the previous storage share it, so it must only be translated once.
+		if i == 0 || b != biomes[i-1] {
+			b.Palette().Replace(translate)
+		}
+	}
+	return chunk.EncodeBiomeStorages(biomes, chunk.NetworkEncoding), nil
+}
+
+// originalBlobHashes translates the hit and miss hashes of the ClientCacheBlobStatus packet passed back to the
+// hashes of the blobs before translation. Missed hashes that are not tracked are reported to the ChunkErrorHandler.
+// False is returned if the connection should be disconnected.
+func (t *DefaultBlockTranslator) originalBlobHashes(conn *minecraft.Conn, blobs *blobHashes, pk *packet.ClientCacheBlobStatus, direction Direction) bool {
+	pk.HitHashes, _ = blobs.original(pk.HitHashes)
+	var unknown []uint64
+	pk.MissHashes, unknown = blobs.original(pk.MissHashes)
+	for _, hash := range unknown {
+		switch t.handleChunkError(ChunkError{Conn: conn, Packet: pk, Direction: direction, BlobHash: hash, Err: errUnknownBlob}) {
+		case ChunkErrorPassThrough:
+			pk.MissHashes = append(pk.MissHashes, hash)
+		case ChunkErrorDisconnect:
+			return false
+		}
+	}
+	return true
+}
+
+// cachedLevelChunkPayload downgrades the raw payload of a LevelChunk packet like downgradeLevelChunkPayload, using
+// the chunk cache of the translator if it has one.
+func (t *DefaultBlockTranslator) cachedLevelChunkPayload(payload []byte, subChunkCount uint32, translateBlocks, oldFormat bool) ([]byte, uint32, error) {
+	if t.chunkCache == nil {
+		return t.downgradeLevelChunkPayload(payload, subChunkCount, translateBlocks, oldFormat)
+	}
+	key := t.chunkCacheKey(t.mapping, t.latest, payload)
+	key.subChunkCount, key.translateBlock, key.oldFormat = subChunkCount, translateBlocks, oldFormat
+	if entry, ok := t.chunkCache.get(key); ok {
+		return entry.payload, entry.subChunkCount, nil
+	}
+	downgraded, subChunkCount, err := t.downgradeLevelChunkPayload(payload, subChunkCount, translateBlocks, oldFormat)
+	if err != nil {
+		return downgraded, subChunkCount, err
+	}
+	// The payload is shared with other connections, so it must not be appended to in place.
+	downgraded = slices.Clip(downgraded)
+	t.chunkCache.put(&chunkCacheEntry{key: key, payload: downgraded, subChunkCount: subChunkCount})
+	return downgraded, subChunkCount, nil
+}
+
+// cachedSubChunkPayload downgrades the raw payload of a SubChunk entry like downgradeSubChunkPayload, using the
+// chunk cache of the translator if it has one.
+func (t *DefaultBlockTranslator) cachedSubChunkPayload(payload []byte, index byte, translateBlocks, oldFormat bool) ([]byte, error) {
+	if t.chunkCache == nil {
+		return t.downgradeSubChunkPayload(payload, index, translateBlocks, oldFormat)
+	}
+	key := t.chunkCacheKey(t.mapping, t.latest, payload)
+	key.index, key.subChunk, key.translateBlock, key.oldFormat = index, true, translateBlocks, oldFormat
+	if entry, ok := t.chunkCache.get(key); ok {
+		return entry.payload, nil
+	}
+	downgraded, err := t.downgradeSubChunkPayload(payload, index, translateBlocks, oldFormat)
+	if err != nil {
+		return downgraded, err
+	}
+	downgraded = slices.Clip(downgraded)
+	t.chunkCache.put(&chunkCacheEntry{key: key, payload: downgraded})
+	return downgraded, nil
+}
+
+// downgradeLevelChunkPayload downgrades the raw payload of a LevelChunk packet with the sub chunk count passed. The
+// blocks are only downgraded if translateBlocks is true, as they are sent separately if the client blob cache is
+// used. The downgraded payload and sub chunk count are returned. If the chunk could not be decoded, the original
+// payload and sub chunk count are returned together with the error.
+func (t *DefaultBlockTranslator) downgradeLevelChunkPayload(payload []byte, subChunkCount uint32, translateBlocks, oldFormat bool) ([]byte, uint32, error) {
+	buf := bytes.NewBuffer(payload)
+	writeBuf := bytes.NewBuffer(nil)
+	if translateBlocks && !oldFormat {
+		// Rewriting the palettes only is far cheaper than decoding the chunk, so it is tried first.
+		if translated, n, err := t.streamChunk(payload, int(subChunkCount)); err == nil {
+			buf.Next(n)
+			writeBuf.Write(translated)
+			translateBlocks = false
+		}
+	}
+	if translateBlocks {
+		r := world.Overworld.Range()
+		if oldFormat {
+			r = cube.Range{0, 255}
+		}
+
+		c, err := chunk.NetworkDecode(t.latest.Air(), buf, int(subChunkCount), oldFormat, r)
+		if err != nil {
+			return payload, subChunkCount, err
+		}
+		t.DowngradeChunk(c, oldFormat)
+
+		encoded, err := chunk.NetworkEncode(t.mapping.Air(), c, oldFormat)
+		if err != nil {
+			return payload, subChunkCount, err
+		}
+		writeBuf.Write(encoded)
+		subChunkCount = uint32(len(c.Sub()))
+	}
+	return t.translateBlockActors(buf, writeBuf, t.mapping.DowngradeBlockActorData), subChunkCount, nil
+}
+
+// upgradeLevelChunkPayload works like downgradeLevelChunkPayload, but upgrades the payload instead.
+func (t *DefaultBlockTranslator) upgradeLevelChunkPayload(payload []byte, subChunkCount uint32, translateBlocks, oldFormat bool) ([]byte, uint32, error) {
+	buf := bytes.NewBuffer(payload)
+	writeBuf := bytes.NewBuffer(nil)
+	if translateBlocks {
+		r := world.Overworld.Range()
+		if oldFormat {
+			r = cube.Range{0, 255}
+		}
+
+		c, err := chunk.NetworkDecode(t.mapping.Air(), buf, int(subChunkCount), oldFormat, r)
+		if err != nil {
+			return payload, subChunkCount, err
+		}
+		t.UpgradeChunk(c, oldFormat)
+
+		encoded, err := chunk.NetworkEncode(t.latest.Air(), c, oldFormat)
+		if err != nil {
+			return payload, subChunkCount, err
+		}
+		writeBuf.Write(encoded)
+		subChunkCount = uint32(len(c.Sub()))
+	}
+	return t.translateBlockActors(buf, writeBuf, t.mapping.UpgradeBlockActorData), subChunkCount, nil
+}
+
+// translateBlockActors copies the border blocks read from buf to writeBuf, followed by the block actors translated
+// using the function passed. The translated payload is returned.
+func (t *DefaultBlockTranslator) translateBlockActors(buf, writeBuf *bytes.Buffer, translate func(map[string]any) map[string]any) []byte {
+	safeBytes := buf.Bytes()
+
+	countBorder, err := buf.ReadByte()
+	if err != nil {
+		return append(writeBuf.Bytes(), safeBytes...)
+	}
+	borderBytes := make([]byte, countBorder)
+	if _, err = buf.Read(borderBytes); err != nil {
+		return append(writeBuf.Bytes(), safeBytes...)
+	}
+	writeBuf.WriteByte(countBorder)
+	writeBuf.Write(borderBytes)
+
+	t.translateNBT(buf, writeBuf, translate)
+	return append(writeBuf.Bytes(), buf.Bytes()...)
+}
+
+// streamChunk downgrades the sub chunks and biomes at the start of a LevelChunk payload, by rewriting their palettes
+// only. The downgraded sub chunks and biomes are returned, together with the amount of bytes read from the payload.
+func (t *DefaultBlockTranslator) streamChunk(payload []byte, subChunkCount int) ([]byte, int, error) {
+	var translated []byte
+	off := 0
+	for i := 0; i < subChunkCount; i++ {
+		sub, n, err := chunk.TranslateSubChunk(payload[off:], t.DowngradeBlockRuntimeID)
+		if err != nil {
+			return nil, 0, err
+		}
+		translated = append(translated, sub...)
+		off += n
+	}
+	biomes, n, err := chunk.TranslateBiomes(payload[off:], (world.Overworld.Range().Height()>>4)+1, t.DowngradeBiomeID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return append(translated, biomes...), off + n, nil
+}
+
+// downgradeSubChunkPayload downgrades the raw payload of a SubChunk entry at the index passed. The blocks are only
+// downgraded if translateBlocks is true, as they are sent separately if the client blob cache is used. If the sub
+// chunk could not be decoded, the original payload is returned together with the error.
+func (t *DefaultBlockTranslator) downgradeSubChunkPayload(payload []byte, index byte, translateBlocks, oldFormat bool) ([]byte, error) {
+	buf := bytes.NewBuffer(payload)
+	writeBuf := bytes.NewBuffer(nil)
+	if translateBlocks {
+		if translated, n, err := chunk.TranslateSubChunk(payload, t.DowngradeBlockRuntimeID); err == nil {
+			buf.Next(n)
+			writeBuf.Write(translated)
+			translateBlocks = false
+		}
+	}
+	if translateBlocks {
+		r := world.Overworld.Range()
+		if oldFormat {
+			r = cube.Range{0, 255}
+		}
+		subChunk, err := chunk.DecodeSubChunk(t.latest.Air(), r, buf, &index, chunk.NetworkEncoding)
+		if err != nil {
+			return payload, err
+		}
+		t.DowngradeSubChunk(subChunk)
+		writeBuf.Write(chunk.EncodeSubChunk(subChunk, chunk.NetworkEncoding, chunk.SubChunkVersion9, r, int(index)))
+	}
+	t.translateNBT(buf, writeBuf, t.mapping.DowngradeBlockActorData)
+	return append(writeBuf.Bytes(), buf.Bytes()...), nil
+}
+
+// upgradeSubChunkPayload works like downgradeSubChunkPayload, but upgrades the payload instead.
+func (t *DefaultBlockTranslator) upgradeSubChunkPayload(payload []byte, index byte, translateBlocks, oldFormat bool) ([]byte, error) {
+	buf := bytes.NewBuffer(payload)
+	writeBuf := bytes.NewBuffer(nil)
+	if translateBlocks {
+		r := world.Overworld.Range()
+		if oldFormat {
+			r = cube.Range{0, 255}
+		}
+		subChunk, err := chunk.DecodeSubChunk(t.mapping.Air(), r, buf, &index, chunk.NetworkEncoding)
+		if err != nil {
+			return payload, err
+		}
+		t.UpgradeSubChunk(subChunk)
+		writeBuf.Write(chunk.EncodeSubChunk(subChunk, chunk.NetworkEncoding, chunk.SubChunkVersion9, r, int(index)))
+	}
+	t.translateNBT(buf, writeBuf, t.mapping.UpgradeBlockActorData)
+	return append(writeBuf.Bytes(), buf.Bytes()...), nil
+}
+
+// translateNBT translates the block actor NBT read from buf using the function passed and writes it to writeBuf. Block
+// actors for which the function returns nil are left out.
+func (t *DefaultBlockTranslator) translateNBT(buf, writeBuf *bytes.Buffer, translate func(map[string]any) map[string]any) {
+	enc := nbt.NewEncoderWithEncoding(writeBuf, nbt.NetworkLittleEndian)
+	dec := nbt.NewDecoderWithEncoding(buf, nbt.NetworkLittleEndian)
+	for {
+		var decNbt map[string]any
+		if err := dec.Decode(&decNbt); err != nil {
+			break
+		}
+		if decNbt = translate(decNbt); decNbt == nil {
+			continue
+		}
+		if err := enc.Encode(decNbt); err != nil {
+			break
+		}
+	}
+}
+
+// downgradeStructureTemplate downgrades the block actors held by the structure template passed.
+func (t *DefaultBlockTranslator) downgradeStructureTemplate(template map[string]any) {
+	translateStructureTemplate(template, t.mapping.DowngradeBlockActorData)
+}
+
+// upgradeStructureTemplate upgrades the block actors held by the structure template passed.
+func (t *DefaultBlockTranslator) upgradeStructureTemplate(template map[string]any) {
+	translateStructureTemplate(template, t.mapping.UpgradeBlockActorData)
+}
+
+// translateStructureTemplate translates the block actors held by the structure template passed using the function
+// passed. Block actors for which the function returns nil are removed.
+func translateStructureTemplate(template map[string]any, translate func(map[string]any) map[string]any) {
+	structure, _ := template["structure"].(map[string]any)
+	palette, _ := structure["palette"].(map[string]any)
+	defaultPalette, _ := palette["default"].(map[string]any)
+	positionData, _ := defaultPalette["block_position_data"].(map[string]any)
+	for _, data := range positionData {
+		data, ok := data.(map[string]any)
+		if !ok {
+			continue
+		}
+		blockActor, ok := data["block_entity_data"].(map[string]any)
+		if !ok {
+			continue
+		}
+		if blockActor = translate(blockActor); blockActor == nil {
+			delete(data, "block_entity_data")
+		} else {
+			data["block_entity_data"] = blockActor
+		}
+	}
+}
+
+// subChunkPosition returns the position of the chunk holding the sub chunk entry passed.
+func subChunkPosition(pk *packet.SubChunk, entry protocol.SubChunkEntry) protocol.ChunkPos {
+	return protocol.ChunkPos{pk.Position.X() + int32(entry.Offset[0]), pk.Position.Z() + int32(entry.Offset[2])}
+}
+
+// forConn returns a copy of the translator that uses the block mappings of the connection with the state passed.
+func (t *DefaultBlockTranslator) forConn(state *connState) *DefaultBlockTranslator {
+	mapping, latest := state.blockMapping(t.mapping), state.blockMapping(t.latest)
+	if mapping == t.mapping && latest == t.latest {
+		return t
+	}
+	c := *t
+	c.mapping, c.latest = mapping, latest
+	return &c
+}
+
+func (t *DefaultBlockTranslator) CustomBlocks() map[string]world.CustomBlock {
+	return t.customBlocks
+}
+
+// customBlockEntries returns the block entries of all custom blocks, which are sent in the StartGame packet. The
+// offset is the amount of custom blocks already sent by the server, so that the block IDs don't overlap.
+func (t *DefaultBlockTranslator) customBlockEntries(offset int) (entries []protocol.BlockEntry) {
+	names := maps.Keys(t.customBlocks)
+	slices.Sort(names)
+	for i, name := range names {
+		entries = append(entries, protocol.BlockEntry{
+			Name:       name,
+			Properties: packbuilder.BlockComponents(name, t.customBlocks[name], int32(10000+offset+i)),
+		})
+	}
+	return entries
+}
@@ -0,0 +1,124 @@
+package translator
+
+import (
+	"container/list"
+	"github.com/flonja/multiversion/mapping"
+	"github.com/segmentio/fasthash/fnv1"
+	"sync"
+)
+
+// ChunkCache is a bounded cache of translated LevelChunk and SubChunk payloads. A single cache may be shared by the
+// block translators of all protocols, so that a chunk sent to many players of the same protocol is only translated
+// once. Payloads are evicted least recently used first, once the total size of the cached payloads exceeds the
+// maximum size of the cache.
+type ChunkCache struct {
+	mu      sync.Mutex
+	maxSize int
+	size    int
+	entries map[chunkCacheKey]*list.Element
+	lru     *list.List
+}
+
+// NewChunkCache creates a ChunkCache holding translated payloads of up to maxSize bytes in total.
+func NewChunkCache(maxSize int) *ChunkCache {
+	return &ChunkCache{maxSize: maxSize, entries: make(map[chunkCacheKey]*list.Element), lru: list.New()}
+}
+
+// chunkCacheKey identifies a translated payload. Besides the hash of the original payload, it holds everything the
+// translation depends on: the target protocol and the (per connection) block mappings used.
+type chunkCacheKey struct {
+	protocolID     int32
+	mapping        mapping.Block
+	latest         mapping.Block
+	hash           uint64
+	length         int
+	subChunkCount  uint32
+	index          byte
+	subChunk       bool
+	translateBlock bool
+	oldFormat      bool
+}
+
+// chunkCacheEntry is a translated payload held by a ChunkCache.
+type chunkCacheEntry struct {
+	key           chunkCacheKey
+	payload       []byte
+	subChunkCount uint32
+}
+
+// size returns the size accounted for the entry.
+func (e *chunkCacheEntry) size() int {
+	return len(e.payload)
+}
+
+// Len returns the amount of payloads currently cached.
+func (c *ChunkCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Size returns the total size in bytes of the payloads currently cached.
+func (c *ChunkCache) Size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// get returns the entry cached for the key passed. The payload of the entry must not be modified.
+func (c *ChunkCache) get(key chunkCacheKey) (*chunkCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(e)
+	return e.Value.(*chunkCacheEntry), true
+}
+
+// put caches the entry passed, evicting the least recently used entries until the cache fits within its maximum
+// size. Entries larger than the maximum size are not cached at all.
+func (c *ChunkCache) put(entry *chunkCacheEntry) {
+	if entry.size() > c.maxSize {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[entry.key]; ok {
+		// Another connection translated the same payload in the meantime.
+		c.lru.MoveToFront(e)
+		return
+	}
+	c.entries[entry.key] = c.lru.PushFront(entry)
+	c.size += entry.size()
+	for c.size > c.maxSize {
+		oldest := c.lru.Back()
+		evicted := c.lru.Remove(oldest).(*chunkCacheEntry)
+		delete(c.entries, evicted.key)
+		c.size -= evicted.size()
+	}
+}
+
+// chunkCaching is embedded in block translators to cache their translated chunk payloads in a ChunkCache.
+type chunkCaching struct {
+	chunkCache *ChunkCache
+	protocolID int32
+}
+
+// SetChunkCache sets the ChunkCache translated chunk payloads of the protocol with the ID passed are cached in. It
+// should be set before the translator is used.
+func (c *chunkCaching) SetChunkCache(cache *ChunkCache, protocolID int32) {
+	c.chunkCache, c.protocolID = cache, protocolID
+}
+
+// chunkCacheKey returns the key of the payload passed, translated using the block mappings passed.
+func (c *chunkCaching) chunkCacheKey(legacy, latest mapping.Block, payload []byte) chunkCacheKey {
+	return chunkCacheKey{
+		protocolID: c.protocolID,
+		mapping:    legacy,
+		latest:     latest,
+		hash:       fnv1.HashBytes64(payload),
+		length:     len(payload),
+	}
+}
@@ -0,0 +1,121 @@
+package translator
+
+import (
+	"fmt"
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/flonja/multiversion/internal/chunk"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// ChunkError is an error that occurred while decoding or encoding the chunk data of a packet.
+type ChunkError struct {
+	// Conn is the connection the packet was translated for.
+	Conn *minecraft.Conn
+	// Packet is the packet holding the chunk data. It is a LevelChunk, SubChunk or ClientCacheMissResponse packet, or a
+	// ClientCacheBlobStatus packet if a client missed a blob of which the hash was not tracked. Such hashes are passed
+	// on untranslated by ChunkErrorPassThrough, and left out by ChunkErrorEmpty.
+	Packet packet.Packet
+	// Direction is the direction of the translation.
+	Direction Direction
+	// Position is the position of the chunk. It is zero for blobs of the client blob cache, as their position is
+	// not known.
+	Position protocol.ChunkPos
+	// SubChunkY is the Y position of the sub chunk. It is only set for SubChunk packets.
+	SubChunkY int32
+	// BlobHash is the hash of the blob. It is only set for ClientCacheMissResponse and ClientCacheBlobStatus packets.
+	BlobHash uint64
+	// Err is the error that occurred.
+	Err error
+}
+
+// Error ...
+func (e ChunkError) Error() string {
+	switch e.Packet.(type) {
+	case *packet.SubChunk:
+		return fmt.Sprintf("translate sub chunk %v at y %v: %v", e.Position, e.SubChunkY, e.Err)
+	case *packet.ClientCacheMissResponse, *packet.ClientCacheBlobStatus:
+		return fmt.Sprintf("translate blob %x: %v", e.BlobHash, e.Err)
+	}
+	return fmt.Sprintf("translate chunk %v: %v", e.Position, e.Err)
+}
+
+// Unwrap ...
+func (e ChunkError) Unwrap() error {
+	return e.Err
+}
+
+// ChunkErrorPolicy decides what happens with chunk data that could not be translated.
+type ChunkErrorPolicy uint8
+
+const (
+	// ChunkErrorPassThrough sends the chunk data without translating it. It is the default policy.
+	ChunkErrorPassThrough ChunkErrorPolicy = iota
+	// ChunkErrorEmpty replaces the chunk data with an empty chunk.
+	ChunkErrorEmpty
+	// ChunkErrorDisconnect disconnects the connection the chunk data was translated for.
+	ChunkErrorDisconnect
+)
+
+// HandleChunkError always returns the policy itself, so that a ChunkErrorPolicy may be used as a ChunkErrorHandler
+// applying the same policy to every error.
+func (p ChunkErrorPolicy) HandleChunkError(ChunkError) ChunkErrorPolicy {
+	return p
+}
+
+// ChunkErrorHandler handles the errors that occur while translating chunk data.
+type ChunkErrorHandler interface {
+	// HandleChunkError is called for every chunk that could not be translated. The policy returned decides what
+	// happens with the chunk. It is called from the goroutine translating the packet, so it should not block.
+	HandleChunkError(err ChunkError) ChunkErrorPolicy
+}
+
+// SetChunkErrorHandler sets the ChunkErrorHandler that handles the chunks that could not be translated. It should be
+// set before the translator is used.
+func (t *DefaultBlockTranslator) SetChunkErrorHandler(handler ChunkErrorHandler) {
+	t.chunkErrors = handler
+}
+
+// handleChunkError passes the error to the ChunkErrorHandler of the translator, returning the policy to apply.
+func (t *DefaultBlockTranslator) handleChunkError(err ChunkError) ChunkErrorPolicy {
+	if t.chunkErrors == nil {
+		return ChunkErrorPassThrough
+	}
+	return t.chunkErrors.HandleChunkError(err)
+}
+
+// disconnect closes the connection passed after the packets currently written have been sent. The Disconnect packet
+// returned should be sent in place of the packet that could not be translated. Connections that are nil, or of which
+// the state is not tracked, are not closed.
+func disconnect(conn *minecraft.Conn) packet.Packet {
+	if conn != nil && tracked(conn) {
+		// The connection can't be closed right away, as it is still writing the packet being translated.
+		go conn.Close()
+	}
+	return &packet.Disconnect{Message: "Failed to translate chunk"}
+}
+
+// emptyLevelChunkPayload returns the payload and sub chunk count of a LevelChunk packet holding an empty chunk.
+func emptyLevelChunkPayload(oldFormat bool) ([]byte, uint32) {
+	return append(emptyBiomes(oldFormat), 0), 0
+}
+
+// emptySubChunk returns the encoded form of a sub chunk filled with the air block passed.
+func emptySubChunk(air uint32, oldFormat bool) []byte {
+	r := world.Overworld.Range()
+	if oldFormat {
+		r = cube.Range{0, 255}
+	}
+	return chunk.EncodeSubChunk(chunk.NewSubChunk(air), chunk.NetworkEncoding, chunk.SubChunkVersion8, r, 0)
+}
+
+// emptyBiomes returns the encoded biomes of an empty chunk.
+func emptyBiomes(oldFormat bool) []byte {
+	if oldFormat {
+		// The old format holds a single byte biome ID for every column.
+		return make([]byte, 256)
+	}
+	return chunk.EncodeBiomes(chunk.New(0, world.Overworld.Range()), chunk.NetworkEncoding)
+}
@@ -0,0 +1,74 @@
+package translator_test
+
+import (
+	"bytes"
+	v630 "github.com/flonja/multiversion/protocols/v630"
+	"github.com/flonja/multiversion/translator"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"testing"
+	"time"
+)
+
+// invalidLevelChunk returns a LevelChunk packet holding a sub chunk of an unknown version, which can't be translated.
+func invalidLevelChunk() *packet.LevelChunk {
+	return &packet.LevelChunk{SubChunkCount: 1, RawPayload: []byte{0xff, 0xff, 0xff}}
+}
+
+func TestChunkErrorPassThrough(t *testing.T) {
+	proto := v630.New().WithChunkErrorHandler(translator.ChunkErrorPassThrough)
+	pks := proto.BlockTranslator().DowngradeBlockPackets([]packet.Packet{invalidLevelChunk()}, nil)
+	if len(pks) != 1 {
+		t.Fatalf("expected 1 packet, got %v", len(pks))
+	}
+	pk, ok := pks[0].(*packet.LevelChunk)
+	if !ok {
+		t.Fatalf("expected LevelChunk, got %T", pks[0])
+	}
+	if pk.SubChunkCount != 1 || !bytes.Equal(pk.RawPayload, invalidLevelChunk().RawPayload) {
+		t.Errorf("expected the chunk to be passed through untranslated, got %v sub chunks and payload %x", pk.SubChunkCount, pk.RawPayload)
+	}
+}
+
+func TestChunkErrorEmpty(t *testing.T) {
+	proto := v630.New().WithChunkErrorHandler(translator.ChunkErrorEmpty)
+	pks := proto.BlockTranslator().DowngradeBlockPackets([]packet.Packet{invalidLevelChunk()}, nil)
+	if len(pks) != 1 {
+		t.Fatalf("expected 1 packet, got %v", len(pks))
+	}
+	pk, ok := pks[0].(*packet.LevelChunk)
+	if !ok {
+		t.Fatalf("expected LevelChunk, got %T", pks[0])
+	}
+	if pk.SubChunkCount != 0 || bytes.Equal(pk.RawPayload, invalidLevelChunk().RawPayload) {
+		t.Errorf("expected an empty chunk, got %v sub chunks and payload %x", pk.SubChunkCount, pk.RawPayload)
+	}
+}
+
+func TestChunkErrorDisconnect(t *testing.T) {
+	var errs []translator.ChunkError
+	proto := v630.New().WithChunkErrorHandler(chunkErrorHandlerFunc(func(err translator.ChunkError) translator.ChunkErrorPolicy {
+		errs = append(errs, err)
+		return translator.ChunkErrorDisconnect
+	}))
+	// A nil connection must not be closed, which would otherwise panic in a separate goroutine.
+	pks := proto.BlockTranslator().DowngradeBlockPackets([]packet.Packet{invalidLevelChunk()}, nil)
+	if len(pks) != 1 {
+		t.Fatalf("expected 1 packet, got %v", len(pks))
+	}
+	if _, ok := pks[0].(*packet.Disconnect); !ok {
+		t.Fatalf("expected Disconnect, got %T", pks[0])
+	}
+	if len(errs) != 1 || errs[0].Err == nil {
+		t.Errorf("expected the handler to be called with the error once, got %v", errs)
+	}
+	// Give a goroutine closing the connection the time to run, so that a panic fails the test.
+	time.Sleep(time.Millisecond * 50)
+}
+
+// chunkErrorHandlerFunc is a translator.ChunkErrorHandler implemented by a function.
+type chunkErrorHandlerFunc func(err translator.ChunkError) translator.ChunkErrorPolicy
+
+// HandleChunkError ...
+func (f chunkErrorHandlerFunc) HandleChunkError(err translator.ChunkError) translator.ChunkErrorPolicy {
+	return f(err)
+}
@@ -0,0 +1,206 @@
+package translator
+
+import (
+	"github.com/samber/lo"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"golang.org/x/exp/slices"
+)
+
+type CommandTranslator interface {
+	// DowngradeCommandPackets downgrades the input command packets to legacy command packets.
+	DowngradeCommandPackets([]packet.Packet, *minecraft.Conn) []packet.Packet
+	// UpgradeCommandPackets upgrades the input command packets to the latest command packets.
+	UpgradeCommandPackets([]packet.Packet, *minecraft.Conn) []packet.Packet
+}
+
+type DefaultCommandTranslator struct {
+	// argTypes holds a map to translate latest command argument types to legacy command argument types. Argument
+	// types missing from the map are the same in both versions.
+	argTypes map[uint32]uint32
+	// legacyArgTypes holds a map to translate legacy command argument types to latest command argument types.
+	legacyArgTypes map[uint32]uint32
+	// chaining is true if the legacy version supports chained subcommands.
+	chaining bool
+	// unknownConstraints holds the enum constraints unknown to the legacy version.
+	unknownConstraints map[byte]struct{}
+}
+
+func NewCommandTranslator() *DefaultCommandTranslator {
+	return &DefaultCommandTranslator{
+		argTypes:           make(map[uint32]uint32),
+		legacyArgTypes:     make(map[uint32]uint32),
+		chaining:           true,
+		unknownConstraints: make(map[byte]struct{}),
+	}
+}
+
+// WithArgType makes the latest command argument type passed show up as the legacy argument type, and the legacy
+// argument type show up as the latest argument type when upgrading. If multiple latest argument types share the same
+// legacy argument type, the latest argument type it is upgraded to should be set using WithLegacyArgType.
+func (t *DefaultCommandTranslator) WithArgType(latest, legacy uint32) *DefaultCommandTranslator {
+	t.argTypes[latest] = legacy
+	if _, ok := t.legacyArgTypes[legacy]; !ok {
+		t.legacyArgTypes[legacy] = latest
+	}
+	return t
+}
+
+// WithLegacyArgType makes the legacy command argument type passed show up as the latest argument type when upgrading,
+// regardless of the argument types registered using WithArgType.
+func (t *DefaultCommandTranslator) WithLegacyArgType(legacy, latest uint32) *DefaultCommandTranslator {
+	t.legacyArgTypes[legacy] = latest
+	return t
+}
+
+// WithoutChaining marks the legacy version as not supporting chained subcommands. Chained subcommands are then sent
+// as regular overloads, which end with an optional command parameter to continue the chain.
+func (t *DefaultCommandTranslator) WithoutChaining() *DefaultCommandTranslator {
+	t.chaining = false
+	return t
+}
+
+// WithoutEnumConstraints marks the enum constraints passed as unknown to the legacy version, so that they are
+// removed from the enum values they applied to.
+func (t *DefaultCommandTranslator) WithoutEnumConstraints(constraints ...byte) *DefaultCommandTranslator {
+	for _, constraint := range constraints {
+		t.unknownConstraints[constraint] = struct{}{}
+	}
+	return t
+}
+
+func (t *DefaultCommandTranslator) DowngradeCommandPackets(pks []packet.Packet, _ *minecraft.Conn) []packet.Packet {
+	for _, pk := range pks {
+		if pk, ok := pk.(*packet.AvailableCommands); ok {
+			if !t.chaining {
+				t.downgradeChainedSubcommands(pk)
+			}
+			t.downgradeConstraints(pk)
+			t.remapArgTypes(pk, t.argTypes)
+		}
+	}
+	return pks
+}
+
+func (t *DefaultCommandTranslator) UpgradeCommandPackets(pks []packet.Packet, _ *minecraft.Conn) []packet.Packet {
+	for _, pk := range pks {
+		if pk, ok := pk.(*packet.AvailableCommands); ok {
+			t.remapArgTypes(pk, t.legacyArgTypes)
+		}
+	}
+	return pks
+}
+
+// remapArgTypes remaps the basic argument types of all command parameters and chained subcommand values using the
+// map passed. Enum, soft enum and suffixed parameters are left alone, as they don't hold an argument type.
+func (t *DefaultCommandTranslator) remapArgTypes(pk *packet.AvailableCommands, argTypes map[uint32]uint32) {
+	for _, command := range pk.Commands {
+		for _, overload := range command.Overloads {
+			for i, parameter := range overload.Parameters {
+				if parameter.Type&(protocol.CommandArgEnum|protocol.CommandArgSoftEnum|protocol.CommandArgSuffixed) != 0 {
+					continue
+				}
+				if argType, ok := argTypes[parameter.Type&^protocol.CommandArgValid]; ok {
+					overload.Parameters[i].Type = argType | protocol.CommandArgValid
+				}
+			}
+		}
+	}
+	for _, subcommand := range pk.ChainedSubcommands {
+		for i, value := range subcommand.Values {
+			if argType, ok := argTypes[uint32(value.Value)]; ok {
+				subcommand.Values[i].Value = uint16(argType)
+			}
+		}
+	}
+}
+
+// downgradeChainedSubcommands replaces all chaining overloads with an overload for every chained subcommand of the
+// command, for legacy versions that don't support chained subcommands.
+func (t *DefaultCommandTranslator) downgradeChainedSubcommands(pk *packet.AvailableCommands) {
+	for i, command := range pk.Commands {
+		var overloads []protocol.CommandOverload
+		for _, overload := range command.Overloads {
+			if !overload.Chaining {
+				overloads = append(overloads, overload)
+				continue
+			}
+			// Parameters leading up to the chained subcommand are kept in front of every subcommand.
+			leading := slices.IndexFunc(overload.Parameters, func(parameter protocol.CommandParameter) bool {
+				return parameter.Options == protocol.ParamOptionAsChainedCommand
+			})
+			if leading == -1 {
+				leading = len(overload.Parameters)
+			}
+			for _, offset := range command.ChainedSubcommandOffsets {
+				if int(offset) >= len(pk.ChainedSubcommands) {
+					continue
+				}
+				subcommand := pk.ChainedSubcommands[offset]
+				parameters := slices.Clone(overload.Parameters[:leading])
+				parameters = append(parameters, protocol.CommandParameter{
+					Name: subcommand.Name,
+					Type: protocol.CommandArgValid | protocol.CommandArgEnum | t.subcommandEnum(pk, subcommand.Name),
+				})
+				for _, value := range subcommand.Values {
+					name := "value"
+					if int(value.Index) < len(pk.ChainedSubcommandValues) {
+						name = pk.ChainedSubcommandValues[value.Index]
+					}
+					parameters = append(parameters, protocol.CommandParameter{
+						Name: name,
+						Type: protocol.CommandArgValid | uint32(value.Value),
+					})
+				}
+				parameters = append(parameters, protocol.CommandParameter{
+					Name:     "chainedCommand",
+					Type:     protocol.CommandArgValid | protocol.CommandArgTypeCommand,
+					Optional: true,
+				})
+				overloads = append(overloads, protocol.CommandOverload{Parameters: parameters})
+			}
+		}
+		pk.Commands[i].Overloads = overloads
+		pk.Commands[i].ChainedSubcommandOffsets = nil
+	}
+	pk.ChainedSubcommands = nil
+	pk.ChainedSubcommandValues = nil
+}
+
+// subcommandEnum returns the index of an enum holding only the name of the chained subcommand passed, adding the enum
+// to the packet if it doesn't exist yet.
+func (t *DefaultCommandTranslator) subcommandEnum(pk *packet.AvailableCommands, name string) uint32 {
+	valueIndex := slices.Index(pk.EnumValues, name)
+	if valueIndex == -1 {
+		valueIndex = len(pk.EnumValues)
+		pk.EnumValues = append(pk.EnumValues, name)
+	}
+	enumIndex := slices.IndexFunc(pk.Enums, func(enum protocol.CommandEnum) bool {
+		return enum.Type == name && slices.Equal(enum.ValueIndices, []uint{uint(valueIndex)})
+	})
+	if enumIndex == -1 {
+		enumIndex = len(pk.Enums)
+		pk.Enums = append(pk.Enums, protocol.CommandEnum{Type: name, ValueIndices: []uint{uint(valueIndex)}})
+	}
+	return uint32(enumIndex)
+}
+
+// downgradeConstraints removes the enum constraints unknown to the legacy version. Constraints left without any
+// known constraint are removed altogether.
+func (t *DefaultCommandTranslator) downgradeConstraints(pk *packet.AvailableCommands) {
+	if len(t.unknownConstraints) == 0 {
+		return
+	}
+	constraints := make([]protocol.CommandEnumConstraint, 0, len(pk.Constraints))
+	for _, constraint := range pk.Constraints {
+		constraint.Constraints = lo.Filter(constraint.Constraints, func(c byte, _ int) bool {
+			_, unknown := t.unknownConstraints[c]
+			return !unknown
+		})
+		if len(constraint.Constraints) > 0 {
+			constraints = append(constraints, constraint)
+		}
+	}
+	pk.Constraints = constraints
+}
@@ -0,0 +1,206 @@
+package translator
+
+import (
+	"github.com/flonja/multiversion/mapping"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"sync"
+)
+
+// connState holds the state of a connection of which packets are translated. It is created when the first packet of
+// the connection is translated, and removed when the connection is released using ReleaseConn.
+type connState struct {
+	mu sync.RWMutex
+	// protocolID is the ID of the protocol used for the connection.
+	protocolID int32
+	// client is true if the protocol is used on the client side of the connection, such as for connections obtained
+	// using a minecraft.Dialer. Packets of the server are then upgraded, and packets of the client downgraded.
+	client bool
+	// salt is the salt of the hashes of the client blob cache blobs translated for the connection. See blobSalt.
+	salt uint64
+	// oldFormat is true if the world uses the chunk format of 1.17.40, in which the overworld ranges from 0 to 255.
+	oldFormat bool
+	// blockMappings holds the block mappings adjusted for the custom states sent in the StartGame packet of the
+	// connection, by the mapping they were adjusted from.
+	blockMappings map[mapping.Block]mapping.Block
+	// itemMappings holds the item mappings adjusted for the custom items sent in the StartGame packet of the
+	// connection, by the mapping they were adjusted from.
+	itemMappings map[mapping.Item]mapping.Item
+	// blobs tracks the hashes of the client blob cache blobs sent to the connection.
+	blobs *blobHashes
+	// itemKey is the key the tags of the item stacks that fell back, or of which the NBT was transformed, when they
+	// were downgraded for the connection are signed with.
+	itemKey []byte
+}
+
+// connStates holds the state of every open connection of which packets are translated.
+var connStates = struct {
+	mu     sync.Mutex
+	states map[*minecraft.Conn]*connState
+}{states: make(map[*minecraft.Conn]*connState)}
+
+// stateOf returns the state of the connection passed, creating it if no packet of the connection was translated yet.
+// The state is removed once the connection is released using ReleaseConn. A state that isn't stored is returned for a
+// nil connection.
+func stateOf(conn *minecraft.Conn) *connState {
+	if conn == nil {
+		return &connState{}
+	}
+	connStates.mu.Lock()
+	defer connStates.mu.Unlock()
+	if s, ok := connStates.states[conn]; ok {
+		return s
+	}
+	s := &connState{}
+	connStates.states[conn] = s
+	return s
+}
+
+// ReleaseConn removes the state kept for the connection passed, such as its adjusted block and item mappings and the
+// key of the original item stacks handed out to it. It must be called once the connection is closed, as the state is
+// kept forever otherwise. No packets of the connection should be translated after it is released.
+func ReleaseConn(conn *minecraft.Conn) {
+	connStates.mu.Lock()
+	defer connStates.mu.Unlock()
+	delete(connStates.states, conn)
+}
+
+// tracked checks if the state of the connection passed is stored.
+func tracked(conn *minecraft.Conn) bool {
+	connStates.mu.Lock()
+	defer connStates.mu.Unlock()
+	_, ok := connStates.states[conn]
+	return ok
+}
+
+// TrackConn marks the ID of the protocol used for the connection passed, and whether it is used on the client side of
+// the connection, such as for connections obtained using a minecraft.Dialer. It must be called before any packet of
+// the connection is translated, which a chain.Protocol does for every packet it converts.
+func TrackConn(conn *minecraft.Conn, protocolID int32, client bool) {
+	s := stateOf(conn)
+	s.mu.RLock()
+	tracked := s.protocolID == protocolID && s.client == client
+	s.mu.RUnlock()
+	if tracked {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.protocolID, s.client = protocolID, client
+	s.salt = blobSalt(protocolID, client, nil)
+}
+
+// trackStartGame stores the state of the connection passed, of which the StartGame packet passed is converted.
+func trackStartGame(conn *minecraft.Conn, pk *packet.StartGame) {
+	s := stateOf(conn)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.oldFormat = pk.BaseGameVersion == "1.17.40"
+}
+
+// isOldFormat checks if the world of the connection uses the chunk format of 1.17.40.
+func (s *connState) isOldFormat() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.oldFormat
+}
+
+// adjustBlockMapping makes the connection use the block mapping passed, adjusted for the custom states passed, in
+// place of the mapping itself.
+func (s *connState) adjustBlockMapping(m mapping.Block, entries []protocol.BlockEntry) {
+	adjusted := m.Adjust(entries)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if adjusted == m {
+		delete(s.blockMappings, m)
+		return
+	}
+	if s.blockMappings == nil {
+		s.blockMappings = make(map[mapping.Block]mapping.Block)
+	}
+	s.blockMappings[m] = adjusted
+}
+
+// blockMapping returns the block mapping used by the connection in place of the mapping passed, which is the mapping
+// itself unless it was adjusted for the custom states of the connection.
+func (s *connState) blockMapping(m mapping.Block) mapping.Block {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if adjusted, ok := s.blockMappings[m]; ok {
+		return adjusted
+	}
+	return m
+}
+
+// adjustItemMapping makes the connection use the item mapping passed, adjusted for the custom items passed, in place
+// of the mapping itself.
+func (s *connState) adjustItemMapping(m mapping.Item, entries []protocol.ItemEntry) {
+	adjusted := m.Adjust(entries)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if adjusted == m {
+		delete(s.itemMappings, m)
+		return
+	}
+	if s.itemMappings == nil {
+		s.itemMappings = make(map[mapping.Item]mapping.Item)
+	}
+	s.itemMappings[m] = adjusted
+}
+
+// itemMapping returns the item mapping used by the connection in place of the mapping passed, which is the mapping
+// itself unless it was adjusted for the custom items of the connection.
+func (s *connState) itemMapping(m mapping.Item) mapping.Item {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if adjusted, ok := s.itemMappings[m]; ok {
+		return adjusted
+	}
+	return m
+}
+
+// adjustBlobSalt makes the connection use the salt derived from the block entries of its StartGame packet passed for
+// the hashes of the client blob cache blobs translated for it.
+func (s *connState) adjustBlobSalt(entries []protocol.BlockEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.salt = blobSalt(s.protocolID, s.client, entries)
+}
+
+// blobSalt returns the salt of the hashes of the client blob cache blobs translated for the connection.
+func (s *connState) blobSalt() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.salt
+}
+
+// blobHashes returns the hashes of the client blob cache blobs sent to the connection.
+func (s *connState) blobHashes() *blobHashes {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.blobs == nil {
+		s.blobs = newBlobHashes()
+	}
+	return s.blobs
+}
+
+// originalItemKey returns the key the tags of the original item stacks handed out to the connection are signed with.
+func (s *connState) originalItemKey() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.itemKey == nil {
+		s.itemKey = newOriginalItemKey()
+	}
+	return s.itemKey
+}
+
+// ClientSide checks if the protocol is used on the client side of the connection passed, as marked by TrackConn.
+func ClientSide(conn *minecraft.Conn) bool {
+	s := stateOf(conn)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.client
+}
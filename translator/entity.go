@@ -0,0 +1,169 @@
+package translator
+
+import (
+	"fmt"
+	"github.com/flonja/multiversion/mapping"
+	"github.com/samber/lo"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"strings"
+)
+
+type EntityTranslator interface {
+	// DowngradeEntityMetadata downgrades the input entity metadata to legacy entity metadata.
+	DowngradeEntityMetadata(map[uint32]any) map[uint32]any
+	// DowngradeEntityPackets downgrades the input entity packets to legacy entity packets.
+	DowngradeEntityPackets([]packet.Packet, *minecraft.Conn) []packet.Packet
+	// UpgradeEntityMetadata upgrades the input entity metadata to the latest entity metadata.
+	UpgradeEntityMetadata(map[uint32]any) map[uint32]any
+	// UpgradeEntityPackets upgrades the input entity packets to the latest entity packets.
+	UpgradeEntityPackets([]packet.Packet, *minecraft.Conn) []packet.Packet
+	// Substitute makes the entity passed show up as the substitute for legacy versions that don't know the entity.
+	Substitute(identifier string, substitute EntitySubstitute)
+	// SetObserver sets the observer notified of every entity that had to be substituted.
+	SetObserver(observer TranslationObserver)
+}
+
+// EntitySubstitute describes the entity shown to legacy versions instead of a vanilla entity they don't know.
+type EntitySubstitute struct {
+	// Identifier is the identifier of the entity shown instead, such as minecraft:zombie.
+	Identifier string
+	// NameTag is the name tag shown above the substitute, unless the entity already has a name tag. If empty, the
+	// identifier of the original entity is used.
+	NameTag string
+}
+
+type DefaultEntityTranslator struct {
+	mapping mapping.Entity
+	latest  mapping.Entity
+	// flags holds the entity flags of the legacy version. If nil, the flags are the same as the latest version.
+	flags *mapping.EntityFlags
+
+	substitutes       map[string]EntitySubstitute
+	defaultSubstitute EntitySubstitute
+	observing
+}
+
+func NewEntityTranslator(mapping mapping.Entity, latestMapping mapping.Entity, flags *mapping.EntityFlags) *DefaultEntityTranslator {
+	return &DefaultEntityTranslator{mapping: mapping, latest: latestMapping, flags: flags,
+		substitutes: make(map[string]EntitySubstitute), defaultSubstitute: EntitySubstitute{Identifier: "minecraft:zombie"}}
+}
+
+// WithDefaultSubstitute sets the substitute used for entities that have no substitute registered. By default, this
+// is a zombie.
+func (t *DefaultEntityTranslator) WithDefaultSubstitute(substitute EntitySubstitute) *DefaultEntityTranslator {
+	t.defaultSubstitute = substitute
+	return t
+}
+
+func (t *DefaultEntityTranslator) Substitute(identifier string, substitute EntitySubstitute) {
+	t.substitutes[identifier] = substitute
+}
+
+// DowngradeEntityType returns the substitute of the entity type passed if the legacy version doesn't know it. Custom
+// entities are never substituted.
+func (t *DefaultEntityTranslator) DowngradeEntityType(identifier string) (EntitySubstitute, bool) {
+	if t.latest == t.mapping || !t.latest.KnownEntity(identifier) || t.mapping.KnownEntity(identifier) {
+		return EntitySubstitute{}, false
+	}
+	substitute, ok := t.substitutes[identifier]
+	if !ok {
+		substitute = t.defaultSubstitute
+	}
+	if substitute.NameTag == "" {
+		substitute.NameTag = strings.TrimPrefix(identifier, "minecraft:")
+	}
+	return substitute, true
+}
+
+func (t *DefaultEntityTranslator) DowngradeEntityMetadata(metadata map[uint32]any) map[uint32]any {
+	if t.flags != nil {
+		t.flags.Downgrade(metadata)
+	}
+	return metadata
+}
+
+func (t *DefaultEntityTranslator) DowngradeEntityPackets(pks []packet.Packet, _ *minecraft.Conn) (result []packet.Packet) {
+	for _, pk := range pks {
+		t := forPacket(t, pk)
+		switch pk := pk.(type) {
+		case *packet.AddActor:
+			if substitute, ok := t.DowngradeEntityType(pk.EntityType); ok {
+				t.observe(Fallback{Direction: DirectionDowngrade, Kind: FallbackEntity, Name: pk.EntityType})
+				// The runtime and unique IDs are kept, so that the substitute is updated like the original entity.
+				pk.EntityType = substitute.Identifier
+				if pk.EntityMetadata == nil {
+					pk.EntityMetadata = make(map[uint32]any)
+				}
+				if name, _ := pk.EntityMetadata[protocol.EntityDataKeyName].(string); name == "" {
+					pk.EntityMetadata[protocol.EntityDataKeyName] = substitute.NameTag
+					pk.EntityMetadata[protocol.EntityDataKeyAlwaysShowNameTag] = uint8(1)
+				}
+			}
+			pk.EntityMetadata = t.DowngradeEntityMetadata(pk.EntityMetadata)
+		case *packet.AddItemActor:
+			pk.EntityMetadata = t.DowngradeEntityMetadata(pk.EntityMetadata)
+		case *packet.AddPlayer:
+			pk.EntityMetadata = t.DowngradeEntityMetadata(pk.EntityMetadata)
+		case *packet.SetActorData:
+			pk.EntityMetadata = t.DowngradeEntityMetadata(pk.EntityMetadata)
+		case *packet.AvailableActorIdentifiers:
+			pk.SerialisedEntityIdentifiers = t.downgradeActorIdentifiers(pk.SerialisedEntityIdentifiers)
+		}
+		result = append(result, pk)
+	}
+	return result
+}
+
+func (t *DefaultEntityTranslator) UpgradeEntityMetadata(metadata map[uint32]any) map[uint32]any {
+	if t.flags != nil {
+		t.flags.Upgrade(metadata)
+	}
+	return metadata
+}
+
+func (t *DefaultEntityTranslator) UpgradeEntityPackets(pks []packet.Packet, _ *minecraft.Conn) (result []packet.Packet) {
+	for _, pk := range pks {
+		t := forPacket(t, pk)
+		switch pk := pk.(type) {
+		case *packet.AddActor:
+			pk.EntityMetadata = t.UpgradeEntityMetadata(pk.EntityMetadata)
+		case *packet.AddItemActor:
+			pk.EntityMetadata = t.UpgradeEntityMetadata(pk.EntityMetadata)
+		case *packet.AddPlayer:
+			pk.EntityMetadata = t.UpgradeEntityMetadata(pk.EntityMetadata)
+		case *packet.SetActorData:
+			pk.EntityMetadata = t.UpgradeEntityMetadata(pk.EntityMetadata)
+		}
+		result = append(result, pk)
+	}
+	return result
+}
+
+// downgradeActorIdentifiers removes all vanilla entities unknown to the legacy version from the serialised entity
+// identifiers passed. If the identifiers can't be decoded or encoded, the error is reported to the observer and the
+// identifiers are returned unchanged.
+func (t *DefaultEntityTranslator) downgradeActorIdentifiers(serialised []byte) []byte {
+	if t.latest == t.mapping {
+		return serialised
+	}
+	var identifiers map[string]any
+	if err := nbt.Unmarshal(serialised, &identifiers); err != nil {
+		t.observe(Fallback{Direction: DirectionDowngrade, Kind: FallbackEntity, Err: fmt.Errorf("decode entity identifiers: %w", err)})
+		return serialised
+	}
+	list, _ := identifiers["idlist"].([]any)
+	identifiers["idlist"] = lo.Filter(list, func(entry any, _ int) bool {
+		m, _ := entry.(map[string]any)
+		identifier, _ := m["id"].(string)
+		return t.mapping.KnownEntity(identifier) || !t.latest.KnownEntity(identifier)
+	})
+	data, err := nbt.Marshal(identifiers)
+	if err != nil {
+		t.observe(Fallback{Direction: DirectionDowngrade, Kind: FallbackEntity, Err: fmt.Errorf("encode entity identifiers: %w", err)})
+		return serialised
+	}
+	return data
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/sandertv/gophertunnel/minecraft"
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
 	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"reflect"
 )
 
 type ItemTranslator interface {
@@ -36,10 +37,16 @@ type ItemTranslator interface {
 	// UpgradeItemDescriptorCount upgrades the input item descriptor (with count) to the latest item descriptor (with count).
 	UpgradeItemDescriptorCount(input protocol.ItemDescriptorCount) protocol.ItemDescriptorCount
 	UpgradeItemPackets(pks []packet.Packet, conn *minecraft.Conn) []packet.Packet
-	// Register registers a custom item entry.
+	// Register registers a custom item entry. Custom items should be registered before the translator is used.
 	Register(item world.CustomItem, replacement itemupgrader.ItemMeta)
 	// CustomItems lists all custom items used as substitutes, with the runtime id as the key
 	CustomItems() map[int32]world.CustomItem
+	// SetObserver sets the observer notified of every item that could not be translated.
+	SetObserver(observer TranslationObserver)
+	// SetItemFallbacks sets the rules used to find the nearest legacy item of items that could not be translated.
+	SetItemFallbacks(fallbacks *mapping.ItemFallbacks)
+	// SetItemNBTTransformers sets the transformers run on the NBT of every item stack translated.
+	SetItemNBTTransformers(transformers *ItemNBTTransformers)
 }
 
 type DefaultItemTranslator struct {
@@ -49,22 +56,39 @@ type DefaultItemTranslator struct {
 	blockMappingLatest mapping.Block
 	ridToCustomItem    map[int32]world.CustomItem
 	originalToCustom   map[int32]int32
-	customToOriginal   map[int32]int32
+	customToOriginal   map[int32]itemupgrader.ItemMeta
+	// fallbacks holds the rules used to find the nearest legacy item of items that could not be translated. If nil,
+	// these items are translated to minecraft:info_update.
+	fallbacks *mapping.ItemFallbacks
+	// nbtTransformers holds the transformers run on the NBT of every item stack translated. If nil, the NBT is left
+	// unchanged.
+	nbtTransformers *ItemNBTTransformers
+	// state is the state of the connection the translator translates for. It is nil unless the translator was
+	// returned by forConn, in which case item stacks that fall back can be restored.
+	state *connState
+	observing
 }
 
 func NewItemTranslator(mapping mapping.Item, latestMapping mapping.Item, blockMapping mapping.Block, blockMappingLatest mapping.Block) *DefaultItemTranslator {
 	return &DefaultItemTranslator{mapping: mapping, latest: latestMapping, blockMapping: blockMapping, blockMappingLatest: blockMappingLatest,
-		ridToCustomItem: make(map[int32]world.CustomItem), originalToCustom: make(map[int32]int32), customToOriginal: make(map[int32]int32)}
+		ridToCustomItem: make(map[int32]world.CustomItem), originalToCustom: make(map[int32]int32), customToOriginal: make(map[int32]itemupgrader.ItemMeta)}
 }
 
 func (t *DefaultItemTranslator) DowngradeItemType(input protocol.ItemType) protocol.ItemType {
+	itemType, _, _ := t.downgradeItemType(input)
+	return itemType
+}
+
+// downgradeItemType downgrades the input item type to a legacy item type. If the item is unknown to the legacy
+// version and had to fall back, the original item is returned along with true.
+func (t *DefaultItemTranslator) downgradeItemType(input protocol.ItemType) (protocol.ItemType, itemupgrader.ItemMeta, bool) {
 	if t.latest == t.mapping {
-		return input
+		return input, itemupgrader.ItemMeta{}, false
 	}
 	if input.NetworkID == t.latest.Air() || input.NetworkID == 0 {
 		return protocol.ItemType{
 			NetworkID: t.mapping.Air(),
-		}
+		}, itemupgrader.ItemMeta{}, false
 	}
 	networkID := input.NetworkID
 	metadata := input.MetadataValue
@@ -73,33 +97,49 @@ func (t *DefaultItemTranslator) DowngradeItemType(input protocol.ItemType) proto
 	if networkID, ok = t.originalToCustom[input.NetworkID]; !ok {
 		itemMeta, ok := t.latest.ItemRuntimeIDToName(input.NetworkID)
 		if !ok {
+			t.observe(Fallback{Direction: DirectionDowngrade, Kind: FallbackItem, ID: input.NetworkID})
 			return protocol.ItemType{
 				NetworkID: t.mapping.Air(),
-			}
+			}, itemupgrader.ItemMeta{}, false
 		}
 		itemMeta.Meta = int16(metadata)
 		itemMeta = itemupgrader.Upgrade(itemMeta)
 
 		networkID, ok = t.mapping.ItemNameToRuntimeID(itemMeta)
 		if !ok {
-			networkID, _ = t.mapping.ItemNameToRuntimeID(itemupgrader.ItemMeta{Name: "minecraft:info_update"})
-			metadata = 0
-		} else {
-			metadata = uint32(itemMeta.Meta)
+			t.observe(Fallback{Direction: DirectionDowngrade, Kind: FallbackItem, Name: itemMeta.Name, ID: input.NetworkID})
+			return t.fallbackItemType(itemMeta), itemMeta, true
 		}
+		metadata = uint32(itemMeta.Meta)
 	}
 
 	return protocol.ItemType{
 		NetworkID:     networkID,
 		MetadataValue: metadata,
-	}
+	}, itemupgrader.ItemMeta{}, false
 }
 
 func (t *DefaultItemTranslator) DowngradeItemStack(input protocol.ItemStack) protocol.ItemStack {
 	if t.latest == t.mapping {
 		return input
 	}
-	input.ItemType = t.DowngradeItemType(input.ItemType)
+	latestType, latestNBT := input.ItemType, input.NBTData
+	transformed := false
+	if t.nbtTransformers != nil {
+		if itemMeta, ok := t.latest.ItemRuntimeIDToName(input.NetworkID); ok {
+			input.NBTData = t.nbtTransformers.Downgrade(itemMeta.Name, input.NBTData)
+			transformed = !reflect.DeepEqual(input.NBTData, latestNBT)
+		}
+	}
+	itemType, original, fellBack := t.downgradeItemType(input.ItemType)
+	input.ItemType = itemType
+	if fellBack {
+		input.NBTData = t.withOriginalItem(input.NBTData, original, latestType, latestNBT)
+	} else if transformed {
+		// Transformers may drop data the legacy version doesn't know, such as enchantments, which can't be derived
+		// from the legacy NBT, so the stack is restored as it was when it is sent back.
+		input.NBTData = t.withOriginalNBT(input.NBTData, latestType, latestNBT)
+	}
 
 	blockRuntimeId := uint32(0)
 	if input.NetworkID != t.mapping.Air() {
@@ -107,6 +147,7 @@ func (t *DefaultItemTranslator) DowngradeItemStack(input protocol.ItemStack) pro
 		itemMeta.Meta = int16(input.MetadataValue)
 		if latestBlockState, ok := item.BlockStateFromItem(itemMeta); ok {
 			if blockRuntimeId, ok = t.blockMapping.StateToRuntimeID(latestBlockState); !ok {
+				t.observe(Fallback{Direction: DirectionDowngrade, Kind: FallbackBlock, Name: latestBlockState.Name, Properties: latestBlockState.Properties, ID: input.BlockRuntimeID})
 				blockRuntimeId = t.blockMapping.Air()
 			}
 		}
@@ -191,13 +232,17 @@ func (t *DefaultItemTranslator) UpgradeItemType(input protocol.ItemType) protoco
 	networkID := input.NetworkID
 	metadata := input.MetadataValue
 
-	var ok bool
-	if networkID, ok = t.customToOriginal[input.NetworkID]; !ok {
+	if original, ok := t.customToOriginal[input.NetworkID]; ok {
+		// The item is a custom item used as a substitute, so the item it replaced is restored.
+		networkID, _ = t.latest.ItemNameToRuntimeID(original)
+		metadata = uint32(original.Meta)
+	} else {
 		itemMeta, _ := t.mapping.ItemRuntimeIDToName(input.NetworkID)
 		itemMeta.Meta = int16(metadata)
 		itemMeta = itemupgrader.Upgrade(itemMeta)
 		networkID, ok = t.latest.ItemNameToRuntimeID(itemMeta)
 		if !ok {
+			t.observe(Fallback{Direction: DirectionUpgrade, Kind: FallbackItem, Name: itemMeta.Name, ID: input.NetworkID})
 			networkID, _ = t.latest.ItemNameToRuntimeID(itemupgrader.ItemMeta{Name: "minecraft:info_update"})
 			metadata = 0
 		} else {
@@ -215,7 +260,19 @@ func (t *DefaultItemTranslator) UpgradeItemStack(input protocol.ItemStack) proto
 	if t.latest == t.mapping {
 		return input
 	}
-	input.ItemType = t.UpgradeItemType(input.ItemType)
+	if original, ok := t.originalItem(input.NBTData); ok {
+		// The item fell back or its NBT was transformed when it was downgraded, so the item stack it replaced is
+		// restored as it was sent.
+		input.ItemType, input.NBTData = original.itemType, original.nbt
+	} else {
+		input.ItemType = t.UpgradeItemType(input.ItemType)
+		input.NBTData = withoutOriginalItem(input.NBTData)
+		if t.nbtTransformers != nil {
+			if itemMeta, ok := t.latest.ItemRuntimeIDToName(input.NetworkID); ok {
+				input.NBTData = t.nbtTransformers.Upgrade(itemMeta.Name, input.NBTData)
+			}
+		}
+	}
 
 	blockRuntimeId := uint32(0)
 	if input.NetworkID != t.latest.Air() {
@@ -293,8 +350,17 @@ func (t *DefaultItemTranslator) UpgradeItemDescriptorCount(input protocol.ItemDe
 	return input
 }
 
-func (t *DefaultItemTranslator) DowngradeItemPackets(pks []packet.Packet, _ *minecraft.Conn) (result []packet.Packet) {
+func (t *DefaultItemTranslator) DowngradeItemPackets(pks []packet.Packet, conn *minecraft.Conn) (result []packet.Packet) {
+	state := stateOf(conn)
 	for _, pk := range pks {
+		if pk, ok := pk.(*packet.StartGame); ok {
+			// The custom items of the server are kept for the connection only, as other connections may be sent
+			// different custom items with the same runtime IDs.
+			custom := customItemEntries(pk.Items)
+			state.adjustItemMapping(t.latest, custom)
+			state.adjustItemMapping(t.mapping, custom)
+		}
+		t := forPacket(t.forConn(state), pk)
 		switch pk := pk.(type) {
 		case *packet.MobEquipment:
 			pk.NewItem = t.DowngradeItemInstance(pk.NewItem)
@@ -314,15 +380,8 @@ func (t *DefaultItemTranslator) DowngradeItemPackets(pks []packet.Packet, _ *min
 				return t.DowngradeItemInstance(item)
 			})
 		case *packet.ItemStackRequest:
-			for i, request := range pk.Requests {
-				for i2, action := range request.Actions {
-					if act, ok := action.(*protocol.CraftResultsDeprecatedStackRequestAction); ok {
-						act.ResultItems = lo.Map(act.ResultItems, func(item protocol.ItemStack, _ int) protocol.ItemStack {
-							return t.DowngradeItemStack(item)
-						})
-						pk.Requests[i].Actions[i2] = act
-					}
-				}
+			for _, request := range pk.Requests {
+				t.downgradeStackRequestActions(request.Actions)
 			}
 		case *packet.CraftingData:
 			for i, recipe := range pk.Recipes {
@@ -414,22 +473,8 @@ func (t *DefaultItemTranslator) DowngradeItemPackets(pks []packet.Packet, _ *min
 				}
 				pk.MaterialReducers[i] = recipe
 			}
-		//case *packet.CraftingEvent:
-		//	pk.Input = lo.Map(pk.Input, func(item protocol.ItemInstance, _ int) protocol.ItemInstance {
-		//		return t.DowngradeItemInstance(item)
-		//	})
-		//	pk.Output = lo.Map(pk.Output, func(item protocol.ItemInstance, _ int) protocol.ItemInstance {
-		//		return t.DowngradeItemInstance(item)
-		//	})
 		case *packet.PlayerAuthInput:
-			for i, action := range pk.ItemStackRequest.Actions {
-				if act, ok := action.(*protocol.CraftResultsDeprecatedStackRequestAction); ok {
-					act.ResultItems = lo.Map(act.ResultItems, func(item protocol.ItemStack, _ int) protocol.ItemStack {
-						return t.DowngradeItemStack(item)
-					})
-					pk.ItemStackRequest.Actions[i] = act
-				}
-			}
+			t.downgradeStackRequestActions(pk.ItemStackRequest.Actions)
 			for i, action := range pk.ItemInteractionData.Actions {
 				action.OldItem = t.DowngradeItemInstance(action.OldItem)
 				action.NewItem = t.DowngradeItemInstance(action.NewItem)
@@ -485,9 +530,10 @@ func (t *DefaultItemTranslator) DowngradeItemPackets(pks []packet.Packet, _ *min
 					if itemMeta, ok := t.mapping.ItemRuntimeIDToName(itemType.NetworkID); ok {
 						entry.Name = itemMeta.Name
 					}
-				} else {
-					t.latest.RegisterEntry(entry.Name)
-					entry.RuntimeID = int16(t.mapping.RegisterEntry(entry.Name))
+				} else if rid, ok := t.mapping.ItemNameToRuntimeID(itemupgrader.ItemMeta{Name: entry.Name}); ok {
+					// The custom item was added to the mapping of the connection with the runtime ID of the server, if
+					// it isn't taken by a legacy item.
+					entry.RuntimeID = int16(rid)
 				}
 				pk.Items[i] = entry
 			}
@@ -513,8 +559,15 @@ func (t *DefaultItemTranslator) DowngradeItemPackets(pks []packet.Packet, _ *min
 	return result
 }
 
-func (t *DefaultItemTranslator) UpgradeItemPackets(pks []packet.Packet, _ *minecraft.Conn) (result []packet.Packet) {
+func (t *DefaultItemTranslator) UpgradeItemPackets(pks []packet.Packet, conn *minecraft.Conn) (result []packet.Packet) {
+	state := stateOf(conn)
 	for _, pk := range pks {
+		if pk, ok := pk.(*packet.StartGame); ok {
+			custom := customItemEntries(pk.Items)
+			state.adjustItemMapping(t.mapping, custom)
+			state.adjustItemMapping(t.latest, custom)
+		}
+		t := forPacket(t.forConn(state), pk)
 		switch pk := pk.(type) {
 		case *packet.MobEquipment:
 			pk.NewItem = t.UpgradeItemInstance(pk.NewItem)
@@ -534,15 +587,8 @@ func (t *DefaultItemTranslator) UpgradeItemPackets(pks []packet.Packet, _ *minec
 				return t.UpgradeItemInstance(item)
 			})
 		case *packet.ItemStackRequest:
-			for i, request := range pk.Requests {
-				for i2, action := range request.Actions {
-					if act, ok := action.(*protocol.CraftResultsDeprecatedStackRequestAction); ok {
-						act.ResultItems = lo.Map(act.ResultItems, func(item protocol.ItemStack, _ int) protocol.ItemStack {
-							return t.UpgradeItemStack(item)
-						})
-						pk.Requests[i].Actions[i2] = act
-					}
-				}
+			for _, request := range pk.Requests {
+				t.upgradeStackRequestActions(request.Actions)
 			}
 		case *packet.CraftingData:
 			for i, recipe := range pk.Recipes {
@@ -634,22 +680,8 @@ func (t *DefaultItemTranslator) UpgradeItemPackets(pks []packet.Packet, _ *minec
 				}
 				pk.MaterialReducers[i] = recipe
 			}
-		//case *packet.CraftingEvent:
-		//	pk.Input = lo.Map(pk.Input, func(item protocol.ItemInstance, _ int) protocol.ItemInstance {
-		//		return t.UpgradeItemInstance(item)
-		//	})
-		//	pk.Output = lo.Map(pk.Output, func(item protocol.ItemInstance, _ int) protocol.ItemInstance {
-		//		return t.UpgradeItemInstance(item)
-		//	})
 		case *packet.PlayerAuthInput:
-			for i, action := range pk.ItemStackRequest.Actions {
-				if act, ok := action.(*protocol.CraftResultsDeprecatedStackRequestAction); ok {
-					act.ResultItems = lo.Map(act.ResultItems, func(item protocol.ItemStack, _ int) protocol.ItemStack {
-						return t.UpgradeItemStack(item)
-					})
-					pk.ItemStackRequest.Actions[i] = act
-				}
-			}
+			t.upgradeStackRequestActions(pk.ItemStackRequest.Actions)
 			for i, action := range pk.ItemInteractionData.Actions {
 				action.OldItem = t.UpgradeItemInstance(action.OldItem)
 				action.NewItem = t.UpgradeItemInstance(action.NewItem)
@@ -689,6 +721,8 @@ func (t *DefaultItemTranslator) UpgradeItemPackets(pks []packet.Packet, _ *minec
 				pk.EventData = (itemType.NetworkID << 16) | int32(itemType.MetadataValue)
 			}
 		case *packet.StartGame:
+			// The StartGame packet is only upgraded if it was sent by a legacy server, to a client dialing it. Custom
+			// items are left out, as they are only added for legacy clients.
 			for i, entry := range pk.Items {
 				if !entry.ComponentBased {
 					itemType := t.UpgradeItemType(protocol.ItemType{
@@ -700,34 +734,56 @@ func (t *DefaultItemTranslator) UpgradeItemPackets(pks []packet.Packet, _ *minec
 					if itemMeta, ok := t.latest.ItemRuntimeIDToName(itemType.NetworkID); ok {
 						entry.Name = itemMeta.Name
 					}
-				} else {
-					t.latest.RegisterEntry(entry.Name)
-					entry.RuntimeID = int16(t.mapping.RegisterEntry(entry.Name))
+				} else if rid, ok := t.latest.ItemNameToRuntimeID(itemupgrader.ItemMeta{Name: entry.Name}); ok {
+					entry.RuntimeID = int16(rid)
 				}
 				pk.Items[i] = entry
 			}
-			for rid, i := range t.CustomItems() {
-				name, _ := i.EncodeItem()
-				pk.Items = append(pk.Items, protocol.ItemEntry{
-					Name:           name,
-					RuntimeID:      int16(rid),
-					ComponentBased: true,
-				})
-			}
-		case *packet.ItemComponent:
-			for _, i := range t.CustomItems() {
-				name, _ := i.EncodeItem()
-				pk.Items = append(pk.Items, protocol.ItemComponentEntry{
-					Name: name,
-					Data: packbuilder.Components(i),
-				})
-			}
 		}
 		result = append(result, pk)
 	}
 	return result
 }
 
+// downgradeStackRequestActions downgrades the items held by the actions of an item stack request. Actions referring
+// to a creative item or a recipe by its network ID are left alone, as these IDs are assigned by the server in the
+// CreativeContent and CraftingData packets, which keep them when translated.
+func (t *DefaultItemTranslator) downgradeStackRequestActions(actions []protocol.StackRequestAction) {
+	for i, action := range actions {
+		switch act := action.(type) {
+		case *protocol.AutoCraftRecipeStackRequestAction:
+			act.Ingredients = lo.Map(act.Ingredients, func(item protocol.ItemDescriptorCount, _ int) protocol.ItemDescriptorCount {
+				return t.DowngradeItemDescriptorCount(item)
+			})
+			actions[i] = act
+		case *protocol.CraftResultsDeprecatedStackRequestAction:
+			act.ResultItems = lo.Map(act.ResultItems, func(item protocol.ItemStack, _ int) protocol.ItemStack {
+				return t.DowngradeItemStack(item)
+			})
+			actions[i] = act
+		}
+	}
+}
+
+// upgradeStackRequestActions upgrades the items held by the actions of an item stack request. Like when downgrading,
+// the network IDs of creative items and recipes are left alone.
+func (t *DefaultItemTranslator) upgradeStackRequestActions(actions []protocol.StackRequestAction) {
+	for i, action := range actions {
+		switch act := action.(type) {
+		case *protocol.AutoCraftRecipeStackRequestAction:
+			act.Ingredients = lo.Map(act.Ingredients, func(item protocol.ItemDescriptorCount, _ int) protocol.ItemDescriptorCount {
+				return t.UpgradeItemDescriptorCount(item)
+			})
+			actions[i] = act
+		case *protocol.CraftResultsDeprecatedStackRequestAction:
+			act.ResultItems = lo.Map(act.ResultItems, func(item protocol.ItemStack, _ int) protocol.ItemStack {
+				return t.UpgradeItemStack(item)
+			})
+			actions[i] = act
+		}
+	}
+}
+
 func (t *DefaultItemTranslator) Register(item world.CustomItem, replacement itemupgrader.ItemMeta) {
 	name, _ := item.EncodeItem()
 	originalRid, ok := t.latest.ItemNameToRuntimeID(replacement)
@@ -741,13 +797,30 @@ func (t *DefaultItemTranslator) Register(item world.CustomItem, replacement item
 	nextRID := t.mapping.RegisterEntry(name)
 	t.ridToCustomItem[nextRID] = item
 	t.originalToCustom[originalRid] = nextRID
-	t.customToOriginal[nextRID] = originalRid
+	t.customToOriginal[nextRID] = replacement
+}
+
+// forConn returns a copy of the translator that translates for the connection with the state passed, using its block
+// and item mappings.
+func (t *DefaultItemTranslator) forConn(state *connState) *DefaultItemTranslator {
+	c := *t
+	c.mapping, c.latest = state.itemMapping(t.mapping), state.itemMapping(t.latest)
+	c.blockMapping, c.blockMappingLatest = state.blockMapping(t.blockMapping), state.blockMapping(t.blockMappingLatest)
+	c.state = state
+	return &c
 }
 
 func (t *DefaultItemTranslator) CustomItems() map[int32]world.CustomItem {
 	return t.ridToCustomItem
 }
 
+// customItemEntries returns the entries of the custom items among the item entries of a StartGame packet.
+func customItemEntries(entries []protocol.ItemEntry) []protocol.ItemEntry {
+	return lo.Filter(entries, func(entry protocol.ItemEntry, _ int) bool {
+		return entry.ComponentBased
+	})
+}
+
 func removeIndex[T any](s []T, index int) []T {
 	ret := make([]T, 0)
 	ret = append(ret, s[:index]...)
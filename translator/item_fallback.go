@@ -0,0 +1,208 @@
+package translator
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"github.com/df-mc/worldupgrader/itemupgrader"
+	"github.com/flonja/multiversion/mapping"
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"strings"
+)
+
+// originalItemTag is the NBT tag of an item stack that fell back, or of which the NBT was transformed, holding the
+// item stack it replaced, so that the original stack may be restored when it is sent back by the client.
+const originalItemTag = "multiversion:original"
+
+// originalItem is an item stack of the latest version that fell back, or of which the NBT was transformed, when it was
+// downgraded for a connection.
+type originalItem struct {
+	itemType protocol.ItemType
+	// nbt is the NBT of the item stack before it was transformed to the format of the legacy version.
+	nbt map[string]any
+}
+
+// originalItemKeySize is the size of the key the tags of original item stacks are signed with.
+const originalItemKeySize = 32
+
+// newOriginalItemKey returns a random key to sign the tags of the original item stacks handed out to a connection
+// with.
+func newOriginalItemKey() []byte {
+	key := make([]byte, originalItemKeySize)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Errorf("generate original item key: %w", err))
+	}
+	return key
+}
+
+// originalItemTagOf returns the tag holding the original item stack with the item type and NBT passed, signed with the
+// key passed. The stack is restored from the tag itself, so that it can't be lost however many stacks are handed out
+// to the connection, and only tags with a valid signature are restored, so that clients cannot forge the tag to obtain
+// any item of the latest version.
+func originalItemTagOf(key []byte, itemType protocol.ItemType, nbtData map[string]any) map[string]any {
+	tag := map[string]any{
+		"NetworkID": itemType.NetworkID,
+		"Meta":      int32(itemType.MetadataValue),
+	}
+	if len(nbtData) != 0 {
+		// The NBT is stored as the client sends it back, so that the signature can be verified then.
+		nbtData = normaliseNBT(nbtData)
+		tag["NBT"] = nbtData
+	}
+	tag["Signature"] = originalItemSignature(key, itemType, nbtData)
+	return tag
+}
+
+// originalItemFromTag returns the original item stack held by the tag passed, if its signature is valid for the key
+// passed.
+func originalItemFromTag(key []byte, tag map[string]any) (originalItem, bool) {
+	networkID, ok := tag["NetworkID"].(int32)
+	if !ok {
+		return originalItem{}, false
+	}
+	meta, _ := tag["Meta"].(int32)
+	nbtData, _ := tag["NBT"].(map[string]any)
+	signature, _ := tag["Signature"].(int64)
+
+	itemType := protocol.ItemType{NetworkID: networkID, MetadataValue: uint32(meta)}
+	if signature != originalItemSignature(key, itemType, nbtData) {
+		return originalItem{}, false
+	}
+	return originalItem{itemType: itemType, nbt: nbtData}, true
+}
+
+// originalItemSignature returns the signature of an original item stack with the item type and NBT passed, using the
+// key passed.
+func originalItemSignature(key []byte, itemType protocol.ItemType, nbtData map[string]any) int64 {
+	h := hmac.New(sha256.New, key)
+	// Maps are printed with sorted keys, so equal NBT is always printed the same.
+	_, _ = fmt.Fprint(h, itemType.NetworkID, itemType.MetadataValue, nbtData)
+	return int64(binary.LittleEndian.Uint64(h.Sum(nil)))
+}
+
+// normaliseNBT returns a copy of the NBT passed with the types it has once it was encoded and decoded again, such as
+// uint8 for bools.
+func normaliseNBT(nbtData map[string]any) map[string]any {
+	b, err := nbt.Marshal(nbtData)
+	if err != nil {
+		return copyNBT(nbtData)
+	}
+	var m map[string]any
+	if err := nbt.Unmarshal(b, &m); err != nil {
+		return copyNBT(nbtData)
+	}
+	return m
+}
+
+// SetItemFallbacks sets the mapping.ItemFallbacks used to find the nearest legacy item of items that could not be
+// translated. It should be set before the translator is used.
+func (t *DefaultItemTranslator) SetItemFallbacks(fallbacks *mapping.ItemFallbacks) {
+	t.fallbacks = fallbacks
+}
+
+// fallbackItemType returns the legacy item type used instead of the item passed, which is unknown to the legacy
+// version. It is the nearest item according to the fallbacks of the translator, or minecraft:info_update if there is
+// none.
+func (t *DefaultItemTranslator) fallbackItemType(itemMeta itemupgrader.ItemMeta) protocol.ItemType {
+	if t.fallbacks != nil {
+		if target, networkID, ok := t.fallbacks.Nearest(itemMeta.Name, t.mapping); ok {
+			return protocol.ItemType{NetworkID: networkID, MetadataValue: uint32(target.Meta)}
+		}
+	}
+	networkID, _ := t.mapping.ItemNameToRuntimeID(itemupgrader.ItemMeta{Name: "minecraft:info_update"})
+	return protocol.ItemType{NetworkID: networkID}
+}
+
+// withOriginalItem returns a copy of the NBT of an item stack that fell back. The original name is shown as display
+// name, unless the stack has a custom name, and the identifier of the original item is added as lore line. If the
+// stack is translated for a connection, the original stack with the item type and NBT passed is tracked for it, so
+// that it may be restored by originalItem.
+func (t *DefaultItemTranslator) withOriginalItem(nbtData map[string]any, original itemupgrader.ItemMeta, itemType protocol.ItemType, originalNBT map[string]any) map[string]any {
+	result := make(map[string]any, len(nbtData)+2)
+	for k, v := range nbtData {
+		result[k] = v
+	}
+	display := make(map[string]any)
+	if d, ok := nbtData["display"].(map[string]any); ok {
+		for k, v := range d {
+			display[k] = v
+		}
+	}
+	if name, _ := display["Name"].(string); name == "" {
+		display["Name"] = displayName(original.Name)
+	}
+	var lore []any
+	switch l := display["Lore"].(type) {
+	case []any:
+		lore = append(lore, l...)
+	case []string:
+		for _, line := range l {
+			lore = append(lore, line)
+		}
+	}
+	display["Lore"] = append(lore, "§7"+original.Name)
+	result["display"] = display
+	if t.state != nil {
+		result[originalItemTag] = originalItemTagOf(t.state.originalItemKey(), itemType, originalNBT)
+	}
+	return result
+}
+
+// withOriginalNBT returns a copy of the NBT of an item stack of which the NBT was transformed, holding the ID of the
+// original stack with the item type and NBT passed, so that it may be restored by originalItem. The NBT passed is
+// returned unchanged if the stack is not translated for a connection.
+func (t *DefaultItemTranslator) withOriginalNBT(nbtData map[string]any, itemType protocol.ItemType, originalNBT map[string]any) map[string]any {
+	if t.state == nil {
+		return nbtData
+	}
+	result := make(map[string]any, len(nbtData)+1)
+	for k, v := range nbtData {
+		result[k] = v
+	}
+	result[originalItemTag] = originalItemTagOf(t.state.originalItemKey(), itemType, originalNBT)
+	return result
+}
+
+// originalItem returns the original item stack of the NBT of an item stack that fell back or of which the NBT was
+// transformed. False is returned if the stack has no tag, or if its tag was not handed out to the connection.
+func (t *DefaultItemTranslator) originalItem(nbtData map[string]any) (originalItem, bool) {
+	tag, ok := nbtData[originalItemTag].(map[string]any)
+	if !ok || t.state == nil {
+		return originalItem{}, false
+	}
+	return originalItemFromTag(t.state.originalItemKey(), tag)
+}
+
+// withoutOriginalItem returns the NBT passed without the tag of original item stacks.
+func withoutOriginalItem(nbtData map[string]any) map[string]any {
+	if _, ok := nbtData[originalItemTag]; !ok {
+		return nbtData
+	}
+	result := make(map[string]any, len(nbtData))
+	for k, v := range nbtData {
+		if k != originalItemTag {
+			result[k] = v
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// displayName returns a readable name for the item identifier passed, such as Trial Key for minecraft:trial_key.
+func displayName(name string) string {
+	if _, base, ok := strings.Cut(name, ":"); ok {
+		name = base
+	}
+	words := strings.Split(name, "_")
+	for i, word := range words {
+		if word != "" {
+			words[i] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,246 @@
+package translator
+
+import (
+	"slices"
+	"strings"
+)
+
+// ItemNBTTransformer transforms the NBT of item stacks of which the format differs between the latest version and a
+// legacy version. The NBT passed is a copy owned by the transformer, so it may be changed in place.
+//
+// Item stacks of which the NBT was changed by a transformer when downgraded are restored as they were when sent back
+// by the client of the connection, so UpgradeItemNBT only handles stacks that were never sent by the server, or that
+// are no longer tracked for the connection.
+type ItemNBTTransformer interface {
+	// DowngradeItemNBT transforms the NBT of an item stack of the latest version to the format of the legacy version.
+	DowngradeItemNBT(nbt map[string]any) map[string]any
+	// UpgradeItemNBT transforms the NBT of an item stack of the legacy version to the format of the latest version.
+	UpgradeItemNBT(nbt map[string]any) map[string]any
+}
+
+// ItemNBTFuncs is an ItemNBTTransformer made out of functions. A nil function leaves the NBT unchanged.
+type ItemNBTFuncs struct {
+	// Downgrade transforms the NBT of an item stack of the latest version to the format of the legacy version.
+	Downgrade func(nbt map[string]any) map[string]any
+	// Upgrade transforms the NBT of an item stack of the legacy version to the format of the latest version.
+	Upgrade func(nbt map[string]any) map[string]any
+}
+
+// DowngradeItemNBT ...
+func (f ItemNBTFuncs) DowngradeItemNBT(nbt map[string]any) map[string]any {
+	if f.Downgrade == nil {
+		return nbt
+	}
+	return f.Downgrade(nbt)
+}
+
+// UpgradeItemNBT ...
+func (f ItemNBTFuncs) UpgradeItemNBT(nbt map[string]any) map[string]any {
+	if f.Upgrade == nil {
+		return nbt
+	}
+	return f.Upgrade(nbt)
+}
+
+// SetItemNBTTransformers sets the ItemNBTTransformers run on the NBT of every item stack translated. It should be set
+// before the translator is used.
+func (t *DefaultItemTranslator) SetItemNBTTransformers(transformers *ItemNBTTransformers) {
+	t.nbtTransformers = transformers
+}
+
+// ItemNBTTransformers is a registry of ItemNBTTransformers keyed by item name. Every protocol version has its own
+// registry, holding the transformers of the format changes made in it and in the protocol versions that follow it.
+type ItemNBTTransformers struct {
+	entries []itemNBTEntry
+}
+
+// itemNBTEntry is an ItemNBTTransformer registered for a set of items.
+type itemNBTEntry struct {
+	// names holds the names of the items the transformer applies to. It applies to all items if empty.
+	names       []string
+	transformer ItemNBTTransformer
+}
+
+// NewItemNBTTransformers returns an empty ItemNBTTransformers.
+func NewItemNBTTransformers() *ItemNBTTransformers {
+	return &ItemNBTTransformers{}
+}
+
+// With registers the transformer passed for the items with the names passed, or for all items if no names are passed.
+// Transformers are run in the order they were registered in when downgrading, and in the reverse order when upgrading,
+// so the transformers of a protocol version should be registered after those of the versions that follow it.
+func (r *ItemNBTTransformers) With(transformer ItemNBTTransformer, names ...string) *ItemNBTTransformers {
+	r.entries = append(r.entries, itemNBTEntry{names: names, transformer: transformer})
+	return r
+}
+
+// Downgrade runs the transformers registered for the item with the name passed on its NBT, returning the NBT in the
+// format of the legacy version. The NBT passed is not changed.
+func (r *ItemNBTTransformers) Downgrade(name string, nbt map[string]any) map[string]any {
+	if len(nbt) == 0 {
+		return nbt
+	}
+	copied := false
+	for _, e := range r.entries {
+		if e.appliesTo(name) {
+			if !copied {
+				nbt, copied = copyNBT(nbt), true
+			}
+			nbt = e.transformer.DowngradeItemNBT(nbt)
+		}
+	}
+	return nbt
+}
+
+// Upgrade runs the transformers registered for the item with the name passed on its NBT, returning the NBT in the
+// format of the latest version. The NBT passed is not changed.
+func (r *ItemNBTTransformers) Upgrade(name string, nbt map[string]any) map[string]any {
+	if len(nbt) == 0 {
+		return nbt
+	}
+	copied := false
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		if e := r.entries[i]; e.appliesTo(name) {
+			if !copied {
+				nbt, copied = copyNBT(nbt), true
+			}
+			nbt = e.transformer.UpgradeItemNBT(nbt)
+		}
+	}
+	return nbt
+}
+
+// appliesTo checks if the transformer of the entry applies to the item with the name passed.
+func (e itemNBTEntry) appliesTo(name string) bool {
+	return len(e.names) == 0 || slices.Contains(e.names, name)
+}
+
+// The built-in transformers below cover the item NBT formats that differ between the protocol versions supported,
+// from v486 (1.18.0) to the latest. The format of written book pages (pages, with a text and photoname each), of
+// firework data (Fireworks, FireworksItem and customColor) and of custom names (display, with Name and Lore) is the
+// same in all of them, so only the formatting codes used in these texts are transformed. None of the built-ins has an
+// upgrade side: the NBT they leave is valid in the latest version, and the data they remove is restored along with
+// the original item stack.
+
+// NewEnchantmentRemover returns an ItemNBTTransformer removing the enchantments with the IDs passed from item stacks,
+// for protocol versions in which these enchantments do not exist yet.
+func NewEnchantmentRemover(ids ...int16) ItemNBTTransformer {
+	return ItemNBTFuncs{Downgrade: func(nbt map[string]any) map[string]any {
+		enchantments, ok := nbt["ench"].([]any)
+		if !ok {
+			return nbt
+		}
+		kept := make([]any, 0, len(enchantments))
+		for _, e := range enchantments {
+			if m, ok := e.(map[string]any); ok {
+				if id, ok := m["id"].(int16); ok && slices.Contains(ids, id) {
+					continue
+				}
+			}
+			kept = append(kept, e)
+		}
+		if len(kept) == 0 {
+			delete(nbt, "ench")
+		} else {
+			nbt["ench"] = kept
+		}
+		return nbt
+	}}
+}
+
+// NewTrimRemover returns an ItemNBTTransformer removing the armour trim of item stacks with a trim pattern passed, for
+// protocol versions in which these patterns do not exist yet. If no patterns are passed, all trims are removed.
+func NewTrimRemover(patterns ...string) ItemNBTTransformer {
+	return ItemNBTFuncs{Downgrade: func(nbt map[string]any) map[string]any {
+		trim, ok := nbt["Trim"].(map[string]any)
+		if !ok {
+			return nbt
+		}
+		if pattern, _ := trim["Pattern"].(string); len(patterns) == 0 || slices.Contains(patterns, pattern) {
+			delete(nbt, "Trim")
+		}
+		return nbt
+	}}
+}
+
+// NewFormattingTransformer returns an ItemNBTTransformer replacing the formatting codes in the keys of the map passed
+// with the codes they map to, in the custom name, the lore and the book pages of item stacks. It is used for
+// protocol versions that do not know some formatting codes yet.
+func NewFormattingTransformer(codes map[rune]rune) ItemNBTTransformer {
+	replace := func(s string) string {
+		if !strings.ContainsRune(s, '§') {
+			return s
+		}
+		runes := []rune(s)
+		for i := 0; i < len(runes)-1; i++ {
+			if runes[i] != '§' {
+				continue
+			}
+			if code, ok := codes[runes[i+1]]; ok {
+				runes[i+1] = code
+			}
+		}
+		return string(runes)
+	}
+	return ItemNBTFuncs{Downgrade: func(nbt map[string]any) map[string]any {
+		if display, ok := nbt["display"].(map[string]any); ok {
+			if name, ok := display["Name"].(string); ok {
+				display["Name"] = replace(name)
+			}
+			if lore, ok := display["Lore"].([]any); ok {
+				for i, line := range lore {
+					if s, ok := line.(string); ok {
+						lore[i] = replace(s)
+					}
+				}
+			}
+		}
+		if pages, ok := nbt["pages"].([]any); ok {
+			for _, page := range pages {
+				if m, ok := page.(map[string]any); ok {
+					if text, ok := m["text"].(string); ok {
+						m["text"] = replace(text)
+					}
+				}
+			}
+		}
+		return nbt
+	}}
+}
+
+// copyNBT returns a deep copy of the NBT passed. Lists are copied to []any, so that transformers only have to handle
+// the types NBT is decoded to.
+func copyNBT(nbt map[string]any) map[string]any {
+	c := make(map[string]any, len(nbt))
+	for k, v := range nbt {
+		c[k] = copyNBTValue(v)
+	}
+	return c
+}
+
+// copyNBTValue returns a deep copy of the NBT value passed.
+func copyNBTValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		return copyNBT(v)
+	case []any:
+		c := make([]any, len(v))
+		for i, e := range v {
+			c[i] = copyNBTValue(e)
+		}
+		return c
+	case []map[string]any:
+		c := make([]any, len(v))
+		for i, e := range v {
+			c[i] = copyNBT(e)
+		}
+		return c
+	case []string:
+		c := make([]any, len(v))
+		for i, e := range v {
+			c[i] = e
+		}
+		return c
+	}
+	return v
+}
@@ -0,0 +1,157 @@
+package translator_test
+
+import (
+	"github.com/df-mc/worldupgrader/itemupgrader"
+	"github.com/flonja/multiversion/protocols/latest"
+	v486 "github.com/flonja/multiversion/protocols/v486"
+	v662 "github.com/flonja/multiversion/protocols/v662"
+	"github.com/flonja/multiversion/translator"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"reflect"
+	"testing"
+)
+
+func TestStackRequestActions(t *testing.T) {
+	latestRID, _ := latest.NewItemMapping().ItemNameToRuntimeID(itemupgrader.ItemMeta{Name: "minecraft:stick"})
+	legacyRID, _ := v486.NewItemMapping().ItemNameToRuntimeID(itemupgrader.ItemMeta{Name: "minecraft:stick"})
+	if latestRID == legacyRID {
+		t.Fatalf("expected the runtime ID of sticks to differ between v486 and the latest version")
+	}
+	request := func(rid int32) *packet.ItemStackRequest {
+		return &packet.ItemStackRequest{Requests: []protocol.ItemStackRequest{{Actions: []protocol.StackRequestAction{
+			&protocol.AutoCraftRecipeStackRequestAction{RecipeNetworkID: 3, Ingredients: []protocol.ItemDescriptorCount{{
+				Descriptor: &protocol.DefaultItemDescriptor{NetworkID: int16(rid)},
+				Count:      1,
+			}}},
+			&protocol.CraftCreativeStackRequestAction{CreativeItemNetworkID: 7},
+		}}}}
+	}
+	ingredient := func(pk packet.Packet) int16 {
+		action := pk.(*packet.ItemStackRequest).Requests[0].Actions[0].(*protocol.AutoCraftRecipeStackRequestAction)
+		return action.Ingredients[0].Descriptor.(*protocol.DefaultItemDescriptor).NetworkID
+	}
+	creativeItem := func(pk packet.Packet) uint32 {
+		return pk.(*packet.ItemStackRequest).Requests[0].Actions[1].(*protocol.CraftCreativeStackRequestAction).CreativeItemNetworkID
+	}
+
+	items := v486.New().ItemTranslator()
+	downgraded := items.DowngradeItemPackets([]packet.Packet{request(latestRID)}, nil)[0]
+	if id := ingredient(downgraded); id != int16(legacyRID) {
+		t.Errorf("expected ingredient to be downgraded to %v, got %v", legacyRID, id)
+	}
+	upgraded := items.UpgradeItemPackets([]packet.Packet{request(legacyRID)}, nil)[0]
+	if id := ingredient(upgraded); id != int16(latestRID) {
+		t.Errorf("expected ingredient to be upgraded to %v, got %v", latestRID, id)
+	}
+	if id := creativeItem(downgraded); id != 7 {
+		t.Errorf("expected creative item network ID to be left alone, got %v", id)
+	}
+	if id := creativeItem(upgraded); id != 7 {
+		t.Errorf("expected creative item network ID to be left alone, got %v", id)
+	}
+}
+
+func TestOriginalItemRoundTrip(t *testing.T) {
+	maceRID, _ := latest.NewItemMapping().ItemNameToRuntimeID(itemupgrader.ItemMeta{Name: "minecraft:mace"})
+	swordRID, _ := v662.NewItemMapping().ItemNameToRuntimeID(itemupgrader.ItemMeta{Name: "minecraft:iron_sword"})
+	mace := func(damage int32) protocol.ItemStack {
+		return protocol.ItemStack{ItemType: protocol.ItemType{NetworkID: maceRID}, Count: 1, NBTData: map[string]any{
+			"Damage":  damage,
+			"display": map[string]any{"Lore": []string{"heavy"}},
+		}}
+	}
+	items := v662.New().ItemTranslator()
+	// downgrade returns the legacy stack of the stack passed, as it is sent to the connection passed.
+	downgrade := func(conn *minecraft.Conn, stack protocol.ItemStack) protocol.ItemStack {
+		pk := &packet.InventoryContent{Content: []protocol.ItemInstance{{Stack: stack}}}
+		return items.DowngradeItemPackets([]packet.Packet{pk}, conn)[0].(*packet.InventoryContent).Content[0].Stack
+	}
+	// upgrade returns the stack of the latest version of the legacy stack passed, as it is sent back by the connection
+	// passed.
+	upgrade := func(conn *minecraft.Conn, stack protocol.ItemStack) protocol.ItemStack {
+		stack.NBTData = normalisedNBT(stack.NBTData)
+		pk := &packet.MobEquipment{NewItem: protocol.ItemInstance{Stack: stack}}
+		return items.UpgradeItemPackets([]packet.Packet{pk}, conn)[0].(*packet.MobEquipment).NewItem.Stack
+	}
+
+	conn := new(minecraft.Conn)
+	defer translator.ReleaseConn(conn)
+	translator.TrackConn(conn, 662, false)
+
+	first := downgrade(conn, mace(0))
+	if first.NetworkID != swordRID {
+		t.Fatalf("expected mace to fall back to iron sword %v, got %v", swordRID, first.NetworkID)
+	}
+	// Hand out more distinct stacks than a connection could ever be expected to keep track of.
+	for i := int32(1); i <= 5000; i++ {
+		downgrade(conn, mace(i))
+	}
+	if stack := upgrade(conn, first); stack.NetworkID != maceRID || !reflect.DeepEqual(stack.NBTData, normalisedNBT(mace(0).NBTData)) {
+		t.Errorf("expected the original mace to be restored, got %v with NBT %v", stack.NetworkID, stack.NBTData)
+	}
+
+	forged := downgrade(conn, mace(0))
+	forged.NBTData["multiversion:original"].(map[string]any)["NBT"].(map[string]any)["Damage"] = int32(-100)
+	if stack := upgrade(conn, forged); stack.NetworkID == maceRID {
+		t.Errorf("expected a forged stack not to be restored, got mace with NBT %v", stack.NBTData)
+	}
+
+	other := new(minecraft.Conn)
+	defer translator.ReleaseConn(other)
+	translator.TrackConn(other, 662, false)
+	if stack := upgrade(other, first); stack.NetworkID == maceRID {
+		t.Errorf("expected a stack handed out to another connection not to be restored, got mace with NBT %v", stack.NBTData)
+	}
+}
+
+// normalisedNBT returns the NBT passed as it is decoded after it was encoded.
+func normalisedNBT(m map[string]any) map[string]any {
+	b, _ := nbt.Marshal(m)
+	var result map[string]any
+	_ = nbt.Unmarshal(b, &result)
+	return result
+}
+
+func TestCustomItemsPerConnection(t *testing.T) {
+	items := v486.New().ItemTranslator()
+	// join returns a connection that was sent a StartGame packet holding the custom item with the name passed, and the
+	// legacy runtime ID the item was sent to it with.
+	join := func(name string) (*minecraft.Conn, int32) {
+		conn := new(minecraft.Conn)
+		translator.TrackConn(conn, 486, false)
+		pk := &packet.StartGame{Items: []protocol.ItemEntry{{Name: name, RuntimeID: 3000, ComponentBased: true}}}
+		items.DowngradeItemPackets([]packet.Packet{pk}, conn)
+		return conn, int32(pk.Items[0].RuntimeID)
+	}
+	// roundTrip returns the legacy runtime ID of the item with the runtime ID passed for the connection passed, and
+	// the runtime ID it is upgraded to again.
+	roundTrip := func(conn *minecraft.Conn, rid int32) (int32, int32) {
+		stack := protocol.ItemStack{ItemType: protocol.ItemType{NetworkID: rid}, Count: 1}
+		pk := &packet.InventoryContent{Content: []protocol.ItemInstance{{Stack: stack}}}
+		legacy := items.DowngradeItemPackets([]packet.Packet{pk}, conn)[0].(*packet.InventoryContent).Content[0].Stack
+		equipment := &packet.MobEquipment{NewItem: protocol.ItemInstance{Stack: legacy}}
+		upgraded := items.UpgradeItemPackets([]packet.Packet{equipment}, conn)[0].(*packet.MobEquipment).NewItem.Stack
+		return legacy.NetworkID, upgraded.NetworkID
+	}
+
+	ruby, rubyRID := join("test:ruby")
+	defer translator.ReleaseConn(ruby)
+	sapphire, sapphireRID := join("test:sapphire")
+	defer translator.ReleaseConn(sapphire)
+	vanilla := new(minecraft.Conn)
+	defer translator.ReleaseConn(vanilla)
+	translator.TrackConn(vanilla, 486, false)
+
+	if legacy, upgraded := roundTrip(ruby, 3000); legacy != rubyRID || upgraded != 3000 {
+		t.Errorf("expected ruby to be sent as %v and sent back as 3000, got %v and %v", rubyRID, legacy, upgraded)
+	}
+	if legacy, upgraded := roundTrip(sapphire, 3000); legacy != sapphireRID || upgraded != 3000 {
+		t.Errorf("expected sapphire to be sent as %v and sent back as 3000, got %v and %v", sapphireRID, legacy, upgraded)
+	}
+	if legacy, _ := roundTrip(vanilla, 3000); legacy == rubyRID || legacy == sapphireRID {
+		t.Errorf("expected the custom items of other connections to be unknown to a connection, got %v", legacy)
+	}
+}
@@ -0,0 +1,74 @@
+package translator
+
+import (
+	"github.com/flonja/multiversion/mapping"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+type LevelEventTranslator interface {
+	// DowngradeLevelEventPackets downgrades the input level event packets to legacy level event packets.
+	DowngradeLevelEventPackets([]packet.Packet, *minecraft.Conn) []packet.Packet
+	// UpgradeLevelEventPackets upgrades the input level event packets to the latest level event packets.
+	UpgradeLevelEventPackets([]packet.Packet, *minecraft.Conn) []packet.Packet
+}
+
+type DefaultLevelEventTranslator struct {
+	mapping mapping.LevelEvent
+}
+
+func NewLevelEventTranslator(mapping mapping.LevelEvent) *DefaultLevelEventTranslator {
+	return &DefaultLevelEventTranslator{mapping: mapping}
+}
+
+func (t *DefaultLevelEventTranslator) DowngradeLevelEventPackets(pks []packet.Packet, _ *minecraft.Conn) (result []packet.Packet) {
+	for _, pk := range pks {
+		switch pk := pk.(type) {
+		case *packet.LevelEvent:
+			eventType, ok := t.downgradeEventType(pk.EventType)
+			if !ok {
+				continue
+			}
+			pk.EventType = eventType
+		case *packet.LevelEventGeneric:
+			eventID, ok := t.mapping.DowngradeLevelEvent(pk.EventID)
+			if !ok {
+				continue
+			}
+			pk.EventID = eventID
+		case *packet.SpawnParticleEffect:
+			name, ok := t.mapping.DowngradeParticleName(pk.ParticleName)
+			if !ok {
+				continue
+			}
+			pk.ParticleName = name
+		}
+		result = append(result, pk)
+	}
+	return result
+}
+
+func (t *DefaultLevelEventTranslator) UpgradeLevelEventPackets(pks []packet.Packet, _ *minecraft.Conn) []packet.Packet {
+	for _, pk := range pks {
+		switch pk := pk.(type) {
+		case *packet.LevelEvent:
+			if pk.EventType&packet.LevelEventParticleLegacyEvent != 0 {
+				pk.EventType = packet.LevelEventParticleLegacyEvent | t.mapping.UpgradeParticle(pk.EventType^packet.LevelEventParticleLegacyEvent)
+			} else {
+				pk.EventType = t.mapping.UpgradeLevelEvent(pk.EventType)
+			}
+		case *packet.LevelEventGeneric:
+			pk.EventID = t.mapping.UpgradeLevelEvent(pk.EventID)
+		}
+	}
+	return pks
+}
+
+// downgradeEventType downgrades the event type of a LevelEvent packet, which is either a level event or a particle.
+func (t *DefaultLevelEventTranslator) downgradeEventType(eventType int32) (int32, bool) {
+	if eventType&packet.LevelEventParticleLegacyEvent == 0 {
+		return t.mapping.DowngradeLevelEvent(eventType)
+	}
+	particle, ok := t.mapping.DowngradeParticle(eventType ^ packet.LevelEventParticleLegacyEvent)
+	return packet.LevelEventParticleLegacyEvent | particle, ok
+}
@@ -0,0 +1,118 @@
+package translator
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// TranslationObserver is notified of every translation that had to fall back, because the original item, block,
+// biome or entity does not exist on the other side. It may be used to log, count or alert on missing mappings.
+type TranslationObserver interface {
+	// ObserveFallback is called for every fallback. It is called from the goroutine translating the packet, so it
+	// should not block.
+	ObserveFallback(fallback Fallback)
+}
+
+// Direction is the direction of a translation.
+type Direction uint8
+
+const (
+	// DirectionDowngrade is the direction of a translation from the latest version to a legacy version.
+	DirectionDowngrade Direction = iota
+	// DirectionUpgrade is the direction of a translation from a legacy version to the latest version.
+	DirectionUpgrade
+)
+
+// FallbackKind is the kind of content that had to fall back.
+type FallbackKind uint8
+
+const (
+	FallbackItem FallbackKind = iota
+	FallbackBlock
+	FallbackBiome
+	FallbackEntity
+)
+
+// Fallback describes a single translation that had to fall back.
+type Fallback struct {
+	// ProtocolID is the ID of the legacy protocol the translation was done for.
+	ProtocolID int32
+	// Direction is the direction of the translation.
+	Direction Direction
+	// Kind is the kind of content that had to fall back.
+	Kind FallbackKind
+	// Name is the name of the original item, block, biome or entity. It is empty if the original runtime ID was not
+	// known at all.
+	Name string
+	// Properties holds the properties of the original block state. It is nil for anything but blocks.
+	Properties map[string]any
+	// ID is the original item runtime ID, block runtime ID or biome ID.
+	ID int32
+	// Packet is the packet the original came from. It is nil if the translation was not done for a packet.
+	Packet packet.Packet
+	// Err is the error that made the translation fall back, such as the failure to decode the data of the packet. The
+	// original data is then left untranslated. It is nil for content that is unknown to the other side.
+	Err error
+}
+
+// ObserverForProtocol returns a TranslationObserver that sets the ProtocolID of every fallback to the protocol ID
+// passed, before passing it to the observer.
+func ObserverForProtocol(protocolID int32, observer TranslationObserver) TranslationObserver {
+	if observer == nil {
+		return nil
+	}
+	return protocolObserver{protocolID: protocolID, observer: observer}
+}
+
+// protocolObserver is a TranslationObserver that sets the protocol ID of the fallbacks it observes.
+type protocolObserver struct {
+	protocolID int32
+	observer   TranslationObserver
+}
+
+// ObserveFallback ...
+func (o protocolObserver) ObserveFallback(fallback Fallback) {
+	fallback.ProtocolID = o.protocolID
+	o.observer.ObserveFallback(fallback)
+}
+
+// observing is embedded in translators to report their fallbacks to a TranslationObserver.
+type observing struct {
+	observer TranslationObserver
+	// packet is the packet currently translated. It is only set on copies of a translator made for a single packet,
+	// so that translators may be shared between connections.
+	packet packet.Packet
+}
+
+// SetObserver sets the TranslationObserver notified of every fallback of the translator. It should be set before
+// the translator is used.
+func (o *observing) SetObserver(observer TranslationObserver) {
+	o.observer = observer
+}
+
+// observe reports the fallback passed to the observer, if any.
+func (o *observing) observe(fallback Fallback) {
+	if o.observer == nil {
+		return
+	}
+	fallback.Packet = o.packet
+	o.observer.ObserveFallback(fallback)
+}
+
+// observed returns the observing embedded in a translator.
+func (o *observing) observed() *observing {
+	return o
+}
+
+// forPacket returns a copy of the translator passed that reports its fallbacks with the packet passed. The translator
+// itself is returned if it has no observer.
+func forPacket[T any, P interface {
+	*T
+	observed() *observing
+}](t P, pk packet.Packet) P {
+	if t.observed().observer == nil {
+		return t
+	}
+	c := *t
+	P(&c).observed().packet = pk
+	return &c
+}
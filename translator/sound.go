@@ -0,0 +1,57 @@
+package translator
+
+import (
+	"github.com/flonja/multiversion/mapping"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+type SoundTranslator interface {
+	// DowngradeSoundPackets downgrades the input sound packets to legacy sound packets.
+	DowngradeSoundPackets([]packet.Packet, *minecraft.Conn) []packet.Packet
+	// UpgradeSoundPackets upgrades the input sound packets to the latest sound packets.
+	UpgradeSoundPackets([]packet.Packet, *minecraft.Conn) []packet.Packet
+}
+
+type DefaultSoundTranslator struct {
+	mapping mapping.Sound
+}
+
+func NewSoundTranslator(mapping mapping.Sound) *DefaultSoundTranslator {
+	return &DefaultSoundTranslator{mapping: mapping}
+}
+
+func (t *DefaultSoundTranslator) DowngradeSoundPackets(pks []packet.Packet, _ *minecraft.Conn) (result []packet.Packet) {
+	for _, pk := range pks {
+		switch pk := pk.(type) {
+		case *packet.LevelSoundEvent:
+			sound, ok := t.mapping.DowngradeSound(pk.SoundType)
+			if !ok {
+				continue
+			}
+			pk.SoundType = sound
+		case *packet.PlaySound:
+			name, ok := t.mapping.DowngradeSoundName(pk.SoundName)
+			if !ok {
+				continue
+			}
+			pk.SoundName = name
+		case *packet.StopSound:
+			if pk.StopAll {
+				break
+			}
+			name, ok := t.mapping.DowngradeSoundName(pk.SoundName)
+			if !ok {
+				continue
+			}
+			pk.SoundName = name
+		}
+		result = append(result, pk)
+	}
+	return result
+}
+
+func (t *DefaultSoundTranslator) UpgradeSoundPackets(pks []packet.Packet, _ *minecraft.Conn) []packet.Packet {
+	// Sounds are only ever appended, so legacy sounds are the same in the latest version.
+	return pks
+}